@@ -0,0 +1,196 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/vfs"
+)
+
+// WithBundle set ProjectOptions to load project from a tar, tar.gz or zip archive, or from stdin when path is "-".
+// Archive content is used as project working directory, named after the archive file. ConfigPaths are
+// relative to the archive root, and discovered following WithDefaultConfigPath rules when not set.
+func WithBundle(path string) ProjectOptionsFn {
+	return func(o *ProjectOptions) error {
+		name := "bundle"
+		var r io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close() //nolint:errcheck
+			r = f
+			name = bundleName(path)
+		}
+
+		root := filepath.Join(string(filepath.Separator), name)
+		fsys, err := vfs.ReadArchive(r, root)
+		if err != nil {
+			return fmt.Errorf("failed to read bundle %s: %w", path, err)
+		}
+		if err := WithFS(fsys)(o); err != nil {
+			return err
+		}
+		o.WorkingDir = root
+		for i, p := range o.ConfigPaths {
+			if p != "-" && !filepath.IsAbs(p) {
+				o.ConfigPaths[i] = filepath.Join(root, p)
+			}
+		}
+		return WithDefaultConfigPath(o)
+	}
+}
+
+// bundleName returns archive file name without extension
+func bundleName(path string) string {
+	name := filepath.Base(path)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+	return name
+}
+
+// Bundle writes a gzip compressed tar archive with all the local files project relies on: compose files,
+// including those used by `include` and `extends`, `.env`, env and label files, configs and secrets files.
+// Paths are relative to project working directory, so the archive can be loaded by WithBundle.
+func Bundle(ctx context.Context, project *types.Project, w io.Writer) error {
+	return BundleFS(ctx, vfs.OS, project, w)
+}
+
+// BundleFS is Bundle reading project files from fsys
+func BundleFS(ctx context.Context, fsys vfs.FS, project *types.Project, w io.Writer) error {
+	details := types.ConfigDetails{
+		WorkingDir:  project.WorkingDir,
+		Environment: project.Environment,
+	}
+	for _, f := range project.ComposeFiles {
+		if f == "-" {
+			return fmt.Errorf("compose file read from stdin can't be bundled: %w", errdefs.ErrUnsupported)
+		}
+		details.ConfigFiles = append(details.ConfigFiles, types.ConfigFile{Filename: f})
+	}
+
+	// project is loaded again with all profiles enabled, so we get files used by disabled services
+	all, err := loader.LoadWithContext(ctx, details, func(o *loader.Options) {
+		o.SetProjectName(project.Name, true)
+		o.Profiles = []string{"*"}
+		o.ResolvePaths = true
+		o.FS = fsys
+	})
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	dotEnv := filepath.Join(project.WorkingDir, ".env")
+	if fi, err := fsys.Stat(dotEnv); err == nil && !fi.IsDir() {
+		files = append(files, dotEnv)
+	}
+	for _, d := range all.Dependencies {
+		switch d.Reason {
+		case types.DependencyBuildContext:
+			continue
+		case types.DependencyEnvFile:
+			if _, err := fsys.Stat(d.Path); errors.Is(err, fs.ErrNotExist) {
+				// optional env_file
+				continue
+			}
+		}
+		files, err = walkFiles(fsys, d.Path, files)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeBundle(w, fsys, project.WorkingDir, files)
+}
+
+// walkFiles appends to files the regular files found under path
+func walkFiles(fsys vfs.FS, path string, files []string) ([]string, error) {
+	fi, err := fsys.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return append(files, path), nil
+	}
+	entries, err := fs.ReadDir(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		files, err = walkFiles(fsys, filepath.Join(path, entry.Name()), files)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func writeBundle(w io.Writer, fsys vfs.FS, workingDir string, files []string) error {
+	sort.Strings(files)
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	var previous string
+	for _, f := range files {
+		if f == previous {
+			continue
+		}
+		previous = f
+		rel, err := filepath.Rel(workingDir, f)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s is outside project directory %s and can't be bundled: %w", f, workingDir, errdefs.ErrUnsupported)
+		}
+		b, err := fsys.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		err = tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     filepath.ToSlash(rel),
+			Mode:     0o644,
+			Size:     int64(len(b)),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tw.Write(b); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
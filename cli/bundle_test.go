@@ -0,0 +1,188 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/compose-spec/compose-go/v2/vfs"
+	"gotest.tools/v3/assert"
+)
+
+func TestBundle(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"compose.yaml": `
+include:
+  - db/compose.yaml
+services:
+  app:
+    extends:
+      file: common/base.yaml
+      service: base
+    env_file: app.env
+    configs:
+      - site
+configs:
+  site:
+    file: ./config/site.conf
+`,
+		"common/base.yaml":  "services:\n  base:\n    image: app:${TAG}\n",
+		"app.env":           "MODE=prod\n",
+		".env":              "TAG=1.0\n",
+		"config/site.conf":  "listen 80;\n",
+		"db/compose.yaml":   "services:\n  db:\n    image: postgres\n    env_file: db.env\n",
+		"db/db.env":         "PGDATA=/data\n",
+		"unused/notes.txt":  "not part of the bundle",
+		"compose.prod.yaml": "services: {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		assert.NilError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	options, err := NewProjectOptions(nil,
+		WithWorkingDirectory(dir),
+		WithDefaultConfigPath,
+		WithEnvFiles(),
+		WithDotEnv,
+	)
+	assert.NilError(t, err)
+	project, err := options.LoadProject(context.TODO())
+	assert.NilError(t, err)
+
+	var buf bytes.Buffer
+	assert.NilError(t, Bundle(context.TODO(), project, &buf))
+	assert.DeepEqual(t, bundleEntries(t, buf.Bytes()), []string{
+		".env",
+		"app.env",
+		"common/base.yaml",
+		"compose.yaml",
+		"config/site.conf",
+		"db/compose.yaml",
+		"db/db.env",
+	})
+
+	archive := filepath.Join(t.TempDir(), "demo.tar.gz")
+	assert.NilError(t, os.WriteFile(archive, buf.Bytes(), 0o600))
+	options, err = NewProjectOptions(nil,
+		WithBundle(archive),
+		WithEnvFiles(),
+		WithDotEnv,
+	)
+	assert.NilError(t, err)
+	bundled, err := options.LoadProject(context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, bundled.Name, "demo")
+	assert.Equal(t, bundled.WorkingDir, filepath.FromSlash("/demo"))
+	assert.Equal(t, bundled.Services["app"].Image, "app:1.0")
+	assert.Equal(t, *bundled.Services["app"].Environment["MODE"], "prod")
+	assert.Equal(t, *bundled.Services["db"].Environment["PGDATA"], "/data")
+	assert.Equal(t, bundled.Configs["site"].File, filepath.FromSlash("/demo/config/site.conf"))
+}
+
+func TestBundleConfigPaths(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NilError(t, writeBundleFromMap(&buf, map[string]string{
+		"compose.yaml":      "services:\n  web:\n    image: nginx\n",
+		"compose.prod.yaml": "services:\n  web:\n    image: nginx:stable\n",
+	}))
+	archive := filepath.Join(t.TempDir(), "site.tar")
+	assert.NilError(t, os.WriteFile(archive, buf.Bytes(), 0o600))
+
+	options, err := NewProjectOptions([]string{"compose.prod.yaml"}, WithBundle(archive))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, options.ConfigPaths, []string{filepath.FromSlash("/site/compose.prod.yaml")})
+	project, err := options.LoadProject(context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, project.Services["web"].Image, "nginx:stable")
+}
+
+func TestBundleFS(t *testing.T) {
+	root := filepath.FromSlash("/srv/site")
+	fsys := vfs.FromFS(fstest.MapFS{
+		"compose.yaml":        {Data: []byte("services:\n  web:\n    image: nginx\n    configs: [conf]\nconfigs:\n  conf:\n    file: ./conf.d\n")},
+		"conf.d/default.conf": {Data: []byte("listen 80;\n")},
+		"conf.d/extra/gzip":   {Data: []byte("gzip on;\n")},
+	}, root)
+
+	options, err := NewProjectOptions(nil, WithFS(fsys), WithWorkingDirectory(root), WithDefaultConfigPath)
+	assert.NilError(t, err)
+	project, err := options.LoadProject(context.TODO())
+	assert.NilError(t, err)
+
+	var buf bytes.Buffer
+	assert.NilError(t, BundleFS(context.TODO(), fsys, project, &buf))
+	assert.DeepEqual(t, bundleEntries(t, buf.Bytes()), []string{
+		"compose.yaml",
+		"conf.d/default.conf",
+		"conf.d/extra/gzip",
+	})
+}
+
+func TestBundleOutsideWorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "shared.env"), []byte("A=1\n"), 0o600))
+	project := filepath.Join(dir, "project")
+	assert.NilError(t, os.Mkdir(project, 0o700))
+	assert.NilError(t, os.WriteFile(filepath.Join(project, "compose.yaml"),
+		[]byte("services:\n  web:\n    image: nginx\n    env_file: ../shared.env\n"), 0o600))
+
+	options, err := NewProjectOptions(nil, WithWorkingDirectory(project), WithDefaultConfigPath)
+	assert.NilError(t, err)
+	p, err := options.LoadProject(context.TODO())
+	assert.NilError(t, err)
+	err = Bundle(context.TODO(), p, io.Discard)
+	assert.ErrorContains(t, err, "shared.env is outside project directory")
+}
+
+func bundleEntries(t *testing.T, b []byte) []string {
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	assert.NilError(t, err)
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		assert.NilError(t, err)
+		names = append(names, h.Name)
+	}
+}
+
+func writeBundleFromMap(w io.Writer, files map[string]string) error {
+	tw := tar.NewWriter(w)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			return err
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
@@ -0,0 +1,129 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cli
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/consts"
+	"golang.org/x/exp/slices"
+)
+
+// WithFileDiscovery set ProjectOptions to look for additional compose files next to the main one, named after
+// it, which is typically `compose.yaml`:
+//   - all `compose.d/*.yaml` files, in lexical order
+//   - `compose.<env>.yaml` with env set by COMPOSE_ENV environment variable
+//   - `compose.<profile>.yaml` for each active profile, in order profiles are set
+//
+// Discovered files are merged after the main file and before the override file found by WithDefaultConfigPath.
+// Discovery runs when project is loaded, so it applies to profiles set after this option. Each discovered file
+// is reported to Listeners by a "discover" event
+func WithFileDiscovery(o *ProjectOptions) error {
+	o.discovery = true
+	return nil
+}
+
+// discoveredFile is an additional compose file found by WithFileDiscovery
+type discoveredFile struct {
+	path     string
+	metadata map[string]any
+}
+
+// discoverFiles returns ConfigPaths with discovered files inserted after the main one, ignoring those already set.
+// ConfigPaths is left unchanged so discovery runs again, and reports events, each time project is loaded
+func (o *ProjectOptions) discoverFiles(workingDir string) ([]string, error) {
+	if !o.discovery || len(o.ConfigPaths) == 0 || o.ConfigPaths[0] == "-" {
+		return o.ConfigPaths, nil
+	}
+	main := o.ConfigPaths[0]
+	if !filepath.IsAbs(main) {
+		main = filepath.Join(workingDir, main)
+	}
+	discovered, err := o.findDiscoveredFiles(main)
+	if err != nil {
+		return nil, err
+	}
+
+	var added []string
+	for _, d := range discovered {
+		if slices.Contains(o.ConfigPaths, d.path) || slices.Contains(added, d.path) {
+			continue
+		}
+		added = append(added, d.path)
+		d.metadata["path"] = d.path
+		d.metadata["source"] = main
+		for _, l := range o.Listeners {
+			l("discover", d.metadata)
+		}
+	}
+	paths := append([]string{o.ConfigPaths[0]}, added...)
+	return append(paths, o.ConfigPaths[1:]...), nil
+}
+
+func (o *ProjectOptions) findDiscoveredFiles(main string) ([]discoveredFile, error) {
+	dir := filepath.Dir(main)
+	ext := filepath.Ext(main)
+	stem := strings.TrimSuffix(filepath.Base(main), ext)
+	fsys := o.filesystem()
+
+	var discovered []discoveredFile
+	fragments, err := fs.ReadDir(fsys, filepath.Join(dir, stem+".d"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	var names []string
+	for _, f := range fragments {
+		if !f.IsDir() && isComposeExtension(filepath.Ext(f.Name())) {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		discovered = append(discovered, discoveredFile{
+			path:     filepath.Join(dir, stem+".d", name),
+			metadata: map[string]any{"reason": "directory"},
+		})
+	}
+
+	find := func(suffix string, metadata map[string]any) {
+		for _, e := range []string{ext, ".yaml", ".yml"} {
+			f := filepath.Join(dir, stem+"."+suffix+e)
+			if s, err := fsys.Stat(f); err == nil && !s.IsDir() {
+				discovered = append(discovered, discoveredFile{path: f, metadata: metadata})
+				return
+			}
+		}
+	}
+	if env := strings.TrimSpace(o.Environment[consts.ComposeEnv]); env != "" {
+		find(env, map[string]any{"reason": "env", "env": env})
+	}
+	for _, profile := range o.profiles {
+		if profile == "" || profile == "*" {
+			continue
+		}
+		find(profile, map[string]any{"reason": "profile", "profile": profile})
+	}
+	return discovered, nil
+}
+
+func isComposeExtension(ext string) bool {
+	return ext == ".yaml" || ext == ".yml"
+}
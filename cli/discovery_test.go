@@ -0,0 +1,104 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/compose-spec/compose-go/v2/vfs"
+	"gotest.tools/v3/assert"
+)
+
+func TestFileDiscovery(t *testing.T) {
+	root := filepath.FromSlash("/srv/webapp")
+	fsys := vfs.FromFS(fstest.MapFS{
+		"compose.yaml":            {Data: []byte("services:\n  web:\n    image: nginx\n")},
+		"compose.override.yaml":   {Data: []byte("services:\n  web:\n    environment: [LAYER=override]\n")},
+		"compose.d/20-cache.yaml": {Data: []byte("services:\n  cache:\n    image: redis\n")},
+		"compose.d/10-db.yml":     {Data: []byte("services:\n  db:\n    image: postgres\n")},
+		"compose.d/README.md":     {Data: []byte("not a compose file")},
+		"compose.staging.yaml":    {Data: []byte("services:\n  web:\n    environment: [LAYER=staging]\n")},
+		"compose.debug.yml":       {Data: []byte("services:\n  debugger:\n    image: delve\n    profiles: [debug]\n")},
+	}, root)
+
+	var events []map[string]any
+	opts, err := NewProjectOptions(nil,
+		WithFS(fsys),
+		WithWorkingDirectory(root),
+		WithEnv([]string{"COMPOSE_ENV=staging"}),
+		WithDefaultConfigPath,
+		WithFileDiscovery,
+		WithProfiles([]string{"debug", "metrics"}),
+	)
+	assert.NilError(t, err)
+	opts.WithListeners(func(event string, metadata map[string]any) {
+		if event == "discover" {
+			events = append(events, metadata)
+		}
+	})
+
+	p, err := opts.LoadProject(context.TODO())
+	assert.NilError(t, err)
+	path := func(name string) string {
+		return filepath.Join(root, filepath.FromSlash(name))
+	}
+	assert.DeepEqual(t, p.ComposeFiles, []string{
+		path("compose.yaml"),
+		path("compose.d/10-db.yml"),
+		path("compose.d/20-cache.yaml"),
+		path("compose.staging.yaml"),
+		path("compose.debug.yml"),
+		path("compose.override.yaml"),
+	})
+	assert.DeepEqual(t, p.ServiceNames(), []string{"cache", "db", "debugger", "web"})
+	assert.Equal(t, *p.Services["web"].Environment["LAYER"], "override")
+	assert.DeepEqual(t, events, []map[string]any{
+		{"path": path("compose.d/10-db.yml"), "source": path("compose.yaml"), "reason": "directory"},
+		{"path": path("compose.d/20-cache.yaml"), "source": path("compose.yaml"), "reason": "directory"},
+		{"path": path("compose.staging.yaml"), "source": path("compose.yaml"), "reason": "env", "env": "staging"},
+		{"path": path("compose.debug.yml"), "source": path("compose.yaml"), "reason": "profile", "profile": "debug"},
+	})
+
+	// loading again discovers files again, without changing ConfigPaths
+	events = nil
+	p, err = opts.LoadProject(context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, len(p.ComposeFiles), 6)
+	assert.Equal(t, len(events), 4)
+	assert.DeepEqual(t, opts.ConfigPaths, []string{path("compose.yaml"), path("compose.override.yaml")})
+}
+
+func TestFileDiscoveryDisabled(t *testing.T) {
+	root := filepath.FromSlash("/srv/webapp")
+	fsys := vfs.FromFS(fstest.MapFS{
+		"compose.yaml":       {Data: []byte("services:\n  web:\n    image: nginx\n")},
+		"compose.debug.yaml": {Data: []byte("services:\n  debugger:\n    image: delve\n")},
+	}, root)
+	opts, err := NewProjectOptions(nil,
+		WithFS(fsys),
+		WithWorkingDirectory(root),
+		WithDefaultConfigPath,
+		WithProfiles([]string{"debug"}),
+	)
+	assert.NilError(t, err)
+	p, err := opts.LoadProject(context.TODO())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, p.ServiceNames(), []string{"web"})
+}
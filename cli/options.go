@@ -18,8 +18,10 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -33,6 +35,7 @@ import (
 	"github.com/compose-spec/compose-go/v2/loader"
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/compose-spec/compose-go/v2/utils"
+	"github.com/compose-spec/compose-go/v2/vfs"
 )
 
 // ProjectOptions provides common configuration for loading a project.
@@ -65,8 +68,19 @@ type ProjectOptions struct {
 	// NOTE: For security, the loader does not automatically expose any
 	// process environment variables. For convenience, WithOsEnv can be
 	// used if appropriate.
+	//
+	// Environment is computed from EnvSources by options setting variables.
+	// Values set directly are managed as EnvLayerProgrammatic, and variables
+	// deleted are removed from EnvSources.
 	Environment types.Mapping
 
+	// EnvSources keeps environment variables by layer, so precedence doesn't
+	// depend on the order options are applied and each value has an origin.
+	EnvSources *types.EnvSources
+
+	// computedEnvironment is Environment as last computed from EnvSources, to detect direct changes
+	computedEnvironment types.Mapping
+
 	// EnvFiles are file paths to ".env" files with additional environment
 	// variable data.
 	//
@@ -77,8 +91,35 @@ type ProjectOptions struct {
 	// exist or an error will be returned during load.
 	EnvFiles []string
 
+	// FS is the filesystem used to discover and read compose and env files.
+	//
+	// This field is optional, host filesystem is used by default. WithFS must
+	// be set before options looking for files, like WithDefaultConfigPath.
+	FS vfs.FS
+
+	// SearchTrace reports directories inspected by WithDefaultConfigPath to find compose files
+	SearchTrace []SearchStep
+
 	loadOptions []func(*loader.Options)
 
+	// search is the policy set by WithSearchOptions
+	search SearchOptions
+
+	// dotEnvFiles are the EnvFiles imported by WithDotEnv
+	dotEnvFiles []string
+
+	// envFileCheck is set by WithEnvFileCheck
+	envFileCheck *dotenv.ParseOptions
+
+	// defaultEnvFile is the default `.env` file selected by WithEnvFiles, if any
+	defaultEnvFile string
+
+	// profiles are the profiles set by WithProfiles or WithDefaultProfiles
+	profiles []string
+
+	// discovery enables discovery of additional compose files, see WithFileDiscovery
+	discovery bool
+
 	// Callbacks to retrieve metadata information during parse defined before
 	// creating the project
 	Listeners []loader.Listener
@@ -86,11 +127,28 @@ type ProjectOptions struct {
 
 type ProjectOptionsFn func(*ProjectOptions) error
 
+// WithFS set ProjectOptions to read files from fsys
+func WithFS(fsys vfs.FS) ProjectOptionsFn {
+	return func(o *ProjectOptions) error {
+		o.FS = fsys
+		o.loadOptions = append(o.loadOptions, func(options *loader.Options) {
+			options.FS = fsys
+		})
+		return nil
+	}
+}
+
+// filesystem returns the filesystem to read files from
+func (o *ProjectOptions) filesystem() vfs.FS {
+	return vfs.Or(o.FS)
+}
+
 // NewProjectOptions creates ProjectOptions
 func NewProjectOptions(configs []string, opts ...ProjectOptionsFn) (*ProjectOptions, error) {
 	options := &ProjectOptions{
 		ConfigPaths: configs,
 		Environment: map[string]string{},
+		EnvSources:  types.NewEnvSources(),
 		Listeners:   []loader.Listener{},
 	}
 	for _, o := range opts {
@@ -143,14 +201,15 @@ func WithConfigFileEnv(o *ProjectOptions) error {
 	}
 	f, ok := o.Environment[consts.ComposeFilePath]
 	if ok {
-		paths, err := absolutePaths(strings.Split(f, sep))
+		paths, err := absolutePaths(o.filesystem(), strings.Split(f, sep))
 		o.ConfigPaths = paths
 		return err
 	}
 	return nil
 }
 
-// WithDefaultConfigPath searches for default config files from working directory
+// WithDefaultConfigPath searches for default config files from working directory, then parent directories
+// following the policy set by WithSearchOptions. Directories inspected are reported by SearchTrace
 func WithDefaultConfigPath(o *ProjectOptions) error {
 	if len(o.ConfigPaths) > 0 {
 		return nil
@@ -159,43 +218,77 @@ func WithDefaultConfigPath(o *ProjectOptions) error {
 	if err != nil {
 		return err
 	}
-	for {
-		candidates := findFiles(DefaultFileNames, pwd)
-		if len(candidates) > 0 {
-			winner := candidates[0]
-			if len(candidates) > 1 {
-				logrus.Warnf("Found multiple config files with supported names: %s", strings.Join(candidates, ", "))
-				logrus.Warnf("Using %s", winner)
-			}
-			o.ConfigPaths = append(o.ConfigPaths, winner)
-
-			overrides := findFiles(DefaultOverrideFileNames, pwd)
-			if len(overrides) > 0 {
-				if len(overrides) > 1 {
-					logrus.Warnf("Found multiple override files with supported names: %s", strings.Join(overrides, ", "))
-					logrus.Warnf("Using %s", overrides[0])
-				}
-				o.ConfigPaths = append(o.ConfigPaths, overrides[0])
-			}
-			return nil
-		}
-		parent := filepath.Dir(pwd)
-		if parent == pwd {
-			// no config file found, but that's not a blocker if caller only needs project name
-			return nil
-		}
-		pwd = parent
+	files, trace, err := o.searchConfigFiles(pwd)
+	o.SearchTrace = trace
+	if err != nil {
+		return err
 	}
+	o.ConfigPaths = append(o.ConfigPaths, files...)
+	return nil
 }
 
-// WithEnv defines a key=value set of variables used for compose file interpolation
+// WithEnv defines a key=value set of variables used for compose file interpolation, as set by command line
 func WithEnv(env []string) ProjectOptionsFn {
+	return WithEnvSource(types.EnvLayerCLI, "", utils.GetAsEqualsMap(env))
+}
+
+// WithEnvSource adds variables to layer, source being the file or provider they come from. Use it to set
+// variables by code with types.EnvLayerProgrammatic, or from a remote provider with types.EnvLayerRemote
+func WithEnvSource(layer types.EnvLayer, source string, values map[string]string) ProjectOptionsFn {
 	return func(o *ProjectOptions) error {
-		for k, v := range utils.GetAsEqualsMap(env) {
-			o.Environment[k] = v
+		return o.updateEnvironment(func(sources *types.EnvSources) error {
+			sources.Add(layer, source, values)
+			return nil
+		})
+	}
+}
+
+// ResolveEnv returns the value of environment variable key, and the layer it comes from
+func (o *ProjectOptions) ResolveEnv(key string) (types.EnvValue, bool) {
+	o.syncEnvironment()
+	return o.EnvSources.Resolve(key)
+}
+
+// updateEnvironment runs update on EnvSources, then computes Environment
+func (o *ProjectOptions) updateEnvironment(update func(*types.EnvSources) error) error {
+	o.syncEnvironment()
+	if err := update(o.EnvSources); err != nil {
+		return err
+	}
+	if o.Environment == nil {
+		o.Environment = types.Mapping{}
+	}
+	for k := range o.Environment {
+		delete(o.Environment, k)
+	}
+	o.computedEnvironment = o.EnvSources.Mapping()
+	for k, v := range o.computedEnvironment {
+		o.Environment[k] = v
+	}
+	return nil
+}
+
+// syncEnvironment records changes made directly to Environment since it was last computed: values set are
+// merged into a single EnvLayerProgrammatic source, and deleted variables are removed from all layers
+func (o *ProjectOptions) syncEnvironment() {
+	if o.EnvSources == nil {
+		o.EnvSources = types.NewEnvSources()
+	}
+	for k := range o.computedEnvironment {
+		if _, ok := o.Environment[k]; !ok {
+			o.EnvSources.Unset(k)
+		}
+	}
+	direct := types.Mapping{}
+	for k, v := range o.Environment {
+		if r, ok := o.EnvSources.Resolve(k); !ok || r.Value != v {
+			direct[k] = v
 		}
-		return nil
 	}
+	if len(direct) > 0 {
+		o.EnvSources.Set(types.EnvLayerProgrammatic, "", direct)
+	}
+	o.computedEnvironment = o.EnvSources.Mapping()
 }
 
 // WithDiscardEnvFile sets discards the `env_file` section after resolving to
@@ -222,6 +315,7 @@ func WithDefaultProfiles(profiles ...string) ProjectOptionsFn {
 				profiles = append(profiles, strings.TrimSpace(s))
 			}
 		}
+		o.profiles = profiles
 		o.loadOptions = append(o.loadOptions, loader.WithProfiles(profiles))
 		return nil
 	}
@@ -230,6 +324,7 @@ func WithDefaultProfiles(profiles ...string) ProjectOptionsFn {
 // WithProfiles sets profiles to be activated
 func WithProfiles(profiles []string) ProjectOptionsFn {
 	return func(o *ProjectOptions) error {
+		o.profiles = profiles
 		o.loadOptions = append(o.loadOptions, loader.WithProfiles(profiles))
 		return nil
 	}
@@ -237,13 +332,7 @@ func WithProfiles(profiles []string) ProjectOptionsFn {
 
 // WithOsEnv imports environment variables from OS
 func WithOsEnv(o *ProjectOptions) error {
-	for k, v := range utils.GetAsEqualsMap(os.Environ()) {
-		if _, set := o.Environment[k]; set {
-			continue
-		}
-		o.Environment[k] = v
-	}
-	return nil
+	return WithEnvSource(types.EnvLayerOS, "", utils.GetAsEqualsMap(os.Environ()))(o)
 }
 
 // WithEnvFile sets an alternate env file.
@@ -261,11 +350,16 @@ func WithEnvFile(file string) ProjectOptionsFn {
 // defaults to local .env file if no explicit file is selected, until COMPOSE_DISABLE_ENV_FILE is set
 func WithEnvFiles(file ...string) ProjectOptionsFn {
 	return func(o *ProjectOptions) error {
+		o.defaultEnvFile = ""
 		if len(file) > 0 {
 			o.EnvFiles = file
 			return nil
 		}
-		if v, ok := os.LookupEnv(consts.ComposeDisableDefaultEnvFile); ok {
+		v, ok := o.Environment[consts.ComposeDisableDefaultEnvFile]
+		if !ok {
+			v, ok = os.LookupEnv(consts.ComposeDisableDefaultEnvFile)
+		}
+		if ok {
 			b, err := strconv.ParseBool(v)
 			if err != nil {
 				return err
@@ -281,7 +375,7 @@ func WithEnvFiles(file ...string) ProjectOptionsFn {
 		}
 		defaultDotEnv := filepath.Join(wd, ".env")
 
-		s, err := os.Stat(defaultDotEnv)
+		s, err := o.filesystem().Stat(defaultDotEnv)
 		if os.IsNotExist(err) {
 			return nil
 		}
@@ -290,18 +384,66 @@ func WithEnvFiles(file ...string) ProjectOptionsFn {
 		}
 		if !s.IsDir() {
 			o.EnvFiles = []string{defaultDotEnv}
+			o.defaultEnvFile = defaultDotEnv
 		}
 		return nil
 	}
 }
 
-// WithDotEnv imports environment variables from .env file
+// WithDotEnv imports environment variables from EnvFiles, as types.EnvLayerDotEnv for the default .env file
+// or types.EnvLayerEnvFile for files explicitly set
 func WithDotEnv(o *ProjectOptions) error {
-	envMap, err := dotenv.GetEnvFromFile(o.Environment, o.EnvFiles)
+	err := o.updateEnvironment(func(sources *types.EnvSources) error {
+		for _, f := range o.EnvFiles {
+			if err := o.checkEnvFile(f); err != nil {
+				return err
+			}
+			layer := types.EnvLayerEnvFile
+			if f == o.defaultEnvFile {
+				layer = types.EnvLayerDotEnv
+			}
+			if err := sources.AddFiles(o.filesystem(), layer, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	o.Environment.Merge(envMap)
+	o.dotEnvFiles = append(o.dotEnvFiles, o.EnvFiles...)
+	return nil
+}
+
+// WithEnvFileCheck checks env files for malformed lines, duplicate keys, byte order mark or CRLF line endings.
+// This applies to env files imported by WithDotEnv, and to those set by services `env_file` and `include.env_file`.
+// In strict mode, problems are reported as an error, otherwise they are logged as warnings.
+// Must be set before WithDotEnv
+func WithEnvFileCheck(strict bool) ProjectOptionsFn {
+	return func(o *ProjectOptions) error {
+		o.envFileCheck = &dotenv.ParseOptions{Strict: strict}
+		o.loadOptions = append(o.loadOptions, func(options *loader.Options) {
+			options.EnvFileCheck = o.envFileCheck
+		})
+		return nil
+	}
+}
+
+func (o *ProjectOptions) checkEnvFile(filename string) error {
+	if o.envFileCheck == nil {
+		return nil
+	}
+	diagnostics, err := dotenv.CheckFile(o.filesystem(), filename, *o.envFileCheck)
+	if errors.Is(err, fs.ErrNotExist) {
+		// reported by env file parser
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, d := range diagnostics {
+		logrus.Warn(d.String())
+	}
 	return nil
 }
 
@@ -345,6 +487,26 @@ func WithResolvedPaths(resolve bool) ProjectOptionsFn {
 	}
 }
 
+// WithVerifiedPaths set ProjectOptions to check local paths declared by the model exist
+func WithVerifiedPaths(verify bool) ProjectOptionsFn {
+	return func(o *ProjectOptions) error {
+		o.loadOptions = append(o.loadOptions, func(options *loader.Options) {
+			options.VerifyPaths = verify
+		})
+		return nil
+	}
+}
+
+// WithTargetOS set ProjectOptions to validate container paths for targetOS, linux or windows
+func WithTargetOS(targetOS string) ProjectOptionsFn {
+	return func(o *ProjectOptions) error {
+		o.loadOptions = append(o.loadOptions, func(options *loader.Options) {
+			options.TargetOS = targetOS
+		})
+		return nil
+	}
+}
+
 // WithResourceLoader register support for ResourceLoader to manage remote resources
 func WithResourceLoader(r loader.ResourceLoader) ProjectOptionsFn {
 	return func(o *ProjectOptions) error {
@@ -368,6 +530,20 @@ func WithExtension(name string, typ any) ProjectOptionsFn {
 	}
 }
 
+// WithExtensionSchema registers a JSON schema fragment to validate `x-*` extension.
+// If fragment is nil, schema is derived from the go struct type registered by WithExtension
+func WithExtensionSchema(name string, fragment map[string]any) ProjectOptionsFn {
+	return func(o *ProjectOptions) error {
+		o.loadOptions = append(o.loadOptions, func(options *loader.Options) {
+			if options.ExtensionSchemas == nil {
+				options.ExtensionSchemas = map[string]map[string]any{}
+			}
+			options.ExtensionSchemas[name] = fragment
+		})
+		return nil
+	}
+}
+
 // Append listener to event
 func (o *ProjectOptions) WithListeners(listeners ...loader.Listener) {
 	o.Listeners = append(o.Listeners, listeners...)
@@ -405,7 +581,11 @@ func (o *ProjectOptions) GetWorkingDir() (string, error) {
 
 // ReadConfigFiles reads ConfigFiles and populates the content field
 func (o *ProjectOptions) ReadConfigFiles(ctx context.Context, workingDir string, options *ProjectOptions) (*types.ConfigDetails, error) {
-	config, err := loader.LoadConfigFiles(ctx, options.ConfigPaths, workingDir, options.loadOptions...)
+	return readConfigFiles(ctx, workingDir, options.ConfigPaths, options)
+}
+
+func readConfigFiles(ctx context.Context, workingDir string, configPaths []string, options *ProjectOptions) (*types.ConfigDetails, error) {
+	config, err := loader.LoadConfigFiles(ctx, configPaths, workingDir, options.loadOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -424,7 +604,7 @@ func (o *ProjectOptions) ReadConfigFiles(ctx context.Context, workingDir string,
 			if err != nil {
 				return nil, err
 			}
-			b, err = os.ReadFile(f)
+			b, err = options.filesystem().ReadFile(f)
 			if err != nil {
 				return nil, err
 			}
@@ -457,6 +637,25 @@ func (o *ProjectOptions) LoadProject(ctx context.Context) (*types.Project, error
 		project.ComposeFiles = append(project.ComposeFiles, config.Filename)
 	}
 
+	// compose and .env files are read by ProjectOptions, as prepare sets Content loader doesn't record them
+	var dependencies []types.Dependency
+	for i, config := range config.ConfigFiles {
+		reason := types.DependencyOverride
+		if i == 0 {
+			reason = types.DependencyComposeFile
+		}
+		if !config.IsStdin() {
+			dependencies = append(dependencies, types.Dependency{Path: config.Filename, Reason: reason})
+		}
+	}
+	for _, f := range o.dotEnvFiles {
+		if abs, err := filepath.Abs(f); err == nil {
+			f = abs
+		}
+		dependencies = append(dependencies, types.Dependency{Path: f, Reason: types.DependencyDotEnv})
+	}
+	project.Dependencies = append(dependencies, project.Dependencies...)
+
 	return project, nil
 }
 
@@ -477,7 +676,12 @@ func (o *ProjectOptions) prepare(ctx context.Context) (*types.ConfigDetails, err
 		return &types.ConfigDetails{}, err
 	}
 
-	configDetails, err := o.ReadConfigFiles(ctx, defaultDir, o)
+	configPaths, err := o.discoverFiles(defaultDir)
+	if err != nil {
+		return &types.ConfigDetails{}, err
+	}
+
+	configDetails, err := readConfigFiles(ctx, defaultDir, configPaths, o)
 	if err != nil {
 		return configDetails, err
 	}
@@ -504,9 +708,11 @@ func withNamePrecedenceLoad(absWorkingDir string, options *ProjectOptions) func(
 			opts.SetProjectName(nameFromEnv, true)
 		} else {
 			dirname := filepath.Base(absWorkingDir)
-			symlink, err := filepath.EvalSymlinks(absWorkingDir)
-			if err == nil && filepath.Base(symlink) != dirname {
-				logrus.Warnf("project has been loaded without an explicit name from a symlink. Using name %q", dirname)
+			if links, ok := options.filesystem().(vfs.SymlinkFS); ok {
+				symlink, err := links.EvalSymlinks(absWorkingDir)
+				if err == nil && filepath.Base(symlink) != dirname {
+					logrus.Warnf("project has been loaded without an explicit name from a symlink. Using name %q", dirname)
+				}
 			}
 			opts.SetProjectName(
 				loader.NormalizeProjectName(dirname),
@@ -534,23 +740,23 @@ func withListeners(options *ProjectOptions) func(*loader.Options) {
 // getConfigPaths retrieves the config files for project based on project options
 func (o *ProjectOptions) getConfigPaths() ([]string, error) {
 	if len(o.ConfigPaths) != 0 {
-		return absolutePaths(o.ConfigPaths)
+		return absolutePaths(o.filesystem(), o.ConfigPaths)
 	}
 	return nil, fmt.Errorf("no configuration file provided: %w", errdefs.ErrNotFound)
 }
 
-func findFiles(names []string, pwd string) []string {
-	candidates := []string{}
+func findFiles(fsys vfs.FS, names []string, pwd string) []string {
+	var candidates []string
 	for _, n := range names {
 		f := filepath.Join(pwd, n)
-		if _, err := os.Stat(f); err == nil {
+		if _, err := fsys.Stat(f); err == nil {
 			candidates = append(candidates, f)
 		}
 	}
 	return candidates
 }
 
-func absolutePaths(p []string) ([]string, error) {
+func absolutePaths(fsys vfs.FS, p []string) ([]string, error) {
 	var paths []string
 	for _, f := range p {
 		if f == "-" {
@@ -562,7 +768,7 @@ func absolutePaths(p []string) ([]string, error) {
 			return nil, err
 		}
 		f = abs
-		if _, err := os.Stat(f); err != nil {
+		if _, err := fsys.Stat(f); err != nil {
 			return nil, err
 		}
 		paths = append(paths, f)
@@ -22,8 +22,10 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/vfs"
 	"gotest.tools/v3/assert"
 
 	"github.com/compose-spec/compose-go/v2/consts"
@@ -290,6 +292,83 @@ func TestProjectWithDotEnv(t *testing.T) {
 	assert.Equal(t, service.Ports[0].Published, "8000")
 }
 
+func TestProjectDependencies(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NilError(t, err)
+	err = os.Chdir("testdata/simple")
+	assert.NilError(t, err)
+	defer os.Chdir(wd) //nolint:errcheck
+
+	opts, err := NewProjectOptions([]string{
+		"compose-with-variables.yaml",
+		"compose-with-overrides.yaml",
+	}, WithName("my_project"), WithEnvFiles(), WithDotEnv)
+	assert.NilError(t, err)
+	p, err := ProjectFromOptions(context.TODO(), opts)
+	assert.NilError(t, err)
+	abs := func(name string) string {
+		path, err := filepath.Abs(name)
+		assert.NilError(t, err)
+		return path
+	}
+	assert.DeepEqual(t, p.Dependencies, []types.Dependency{
+		{Path: abs("compose-with-variables.yaml"), Reason: types.DependencyComposeFile},
+		{Path: abs("compose-with-overrides.yaml"), Reason: types.DependencyOverride},
+		{Path: abs(".env"), Reason: types.DependencyDotEnv},
+	})
+}
+
+func TestEnvSourcesPrecedence(t *testing.T) {
+	wd := t.TempDir()
+	err := os.WriteFile(filepath.Join(wd, ".env"), []byte("FOO=dotenv\nBAR=dotenv\nZOT=dotenv\n"), 0o700)
+	assert.NilError(t, err)
+	t.Setenv("BAR", "os")
+
+	// dotenv and os environment are loaded before command line, but have lower precedence
+	options, err := NewProjectOptions(nil,
+		WithWorkingDirectory(wd), WithEnvFiles(), WithDotEnv,
+		WithOsEnv,
+		WithEnv([]string{"FOO=cli"}),
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, options.Environment["FOO"], "cli")
+	assert.Equal(t, options.Environment["BAR"], "os")
+	assert.Equal(t, options.Environment["ZOT"], "dotenv")
+
+	v, ok := options.ResolveEnv("ZOT")
+	assert.Check(t, ok)
+	assert.DeepEqual(t, v, types.EnvValue{Value: "dotenv", Layer: types.EnvLayerDotEnv, Source: filepath.Join(wd, ".env")})
+
+	// values set directly are programmatic, with highest precedence
+	options.Environment["BAR"] = "direct"
+	assert.NilError(t, WithEnvSource(types.EnvLayerRemote, "vault", map[string]string{"BAR": "remote", "SECRET": "s3cr3t"})(options))
+	assert.Equal(t, options.Environment["BAR"], "direct")
+	assert.Equal(t, options.Environment["SECRET"], "s3cr3t")
+	v, _ = options.ResolveEnv("BAR")
+	assert.Equal(t, v.Layer, types.EnvLayerProgrammatic)
+
+	// values set directly again update the same programmatic source
+	options.Environment["BAR"] = "again"
+	options.Environment["QIX"] = "direct"
+	assert.NilError(t, WithEnv([]string{"OTHER=cli"})(options))
+	assert.DeepEqual(t, options.EnvSources.Layers("BAR")[:2], []types.EnvValue{
+		{Value: "again", Layer: types.EnvLayerProgrammatic},
+		{Value: "os", Layer: types.EnvLayerOS},
+	})
+	v, _ = options.ResolveEnv("QIX")
+	assert.Equal(t, v.Layer, types.EnvLayerProgrammatic)
+
+	// variables deleted are not restored by options applied later
+	delete(options.Environment, "ZOT")
+	delete(options.Environment, "BAR")
+	assert.NilError(t, WithEnv([]string{"OTHER=cli"})(options))
+	_, ok = options.Environment["ZOT"]
+	assert.Check(t, !ok)
+	_, ok = options.ResolveEnv("BAR")
+	assert.Check(t, !ok)
+	assert.Equal(t, options.Environment["FOO"], "cli")
+}
+
 func TestProjectWithDiscardEnvFile(t *testing.T) {
 	opts, err := NewProjectOptions([]string{
 		"testdata/env-file/compose-with-env-file.yaml",
@@ -383,3 +462,102 @@ func TestEnvVariablePrecedence(t *testing.T) {
 		})
 	}
 }
+
+func TestProjectFromFS(t *testing.T) {
+	root := filepath.FromSlash("/srv/webapp")
+	fsys := vfs.FromFS(fstest.MapFS{
+		"compose.yaml":          {Data: []byte("services:\n  web:\n    image: nginx:${TAG}\n")},
+		"compose.override.yaml": {Data: []byte("services:\n  web:\n    ports: [\"8080:80\"]\n")},
+		".env":                  {Data: []byte("TAG=1.25\n")},
+	}, root)
+
+	opts, err := NewProjectOptions(nil,
+		WithFS(fsys),
+		WithWorkingDirectory(root),
+		WithDefaultConfigPath,
+		WithEnvFiles(),
+		WithDotEnv,
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, opts.ConfigPaths, []string{
+		filepath.Join(root, "compose.yaml"),
+		filepath.Join(root, "compose.override.yaml"),
+	})
+
+	p, err := opts.LoadProject(context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, p.Name, "webapp")
+	assert.Equal(t, p.Services["web"].Image, "nginx:1.25")
+	assert.Equal(t, p.Services["web"].Ports[0].Published, "8080")
+}
+
+func TestEnvFileCheck(t *testing.T) {
+	root := filepath.FromSlash("/srv/webapp")
+	fsys := vfs.FromFS(fstest.MapFS{
+		".env": {Data: []byte("TAG=1.25\nTAG=1.26\n")},
+	}, root)
+	dotEnv := filepath.Join(root, ".env")
+
+	opts, err := NewProjectOptions(nil,
+		WithFS(fsys),
+		WithWorkingDirectory(root),
+		WithEnvFileCheck(false),
+		WithEnvFiles(),
+		WithDotEnv,
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, opts.Environment["TAG"], "1.26")
+
+	_, err = NewProjectOptions(nil,
+		WithFS(fsys),
+		WithWorkingDirectory(root),
+		WithEnvFileCheck(true),
+		WithEnvFiles(),
+		WithDotEnv,
+	)
+	assert.Error(t, err, dotEnv+":2:1: duplicate variable TAG, already set on line 1")
+}
+
+func TestEnvFileCheckServicesAndIncludes(t *testing.T) {
+	root := filepath.FromSlash("/srv/webapp")
+	fsys := vfs.FromFS(fstest.MapFS{
+		"compose.yaml": {Data: []byte(`
+include:
+  - path: db/compose.yaml
+    env_file: db/db.env
+services:
+  web:
+    image: nginx
+    env_file:
+      - web.env
+      - path: optional.env
+        required: false
+`)},
+		"web.env":         {Data: []byte("MODE=prod\r\n")},
+		"db/compose.yaml": {Data: []byte("services:\n  db:\n    image: postgres:${PG_VERSION}\n")},
+		"db/db.env":       {Data: []byte("PG_VERSION=16\nPG_VERSION=17\n")},
+	}, root)
+	load := func(strict bool) (*types.Project, error) {
+		opts, err := NewProjectOptions([]string{filepath.Join(root, "compose.yaml")},
+			WithFS(fsys),
+			WithWorkingDirectory(root),
+			WithEnvFileCheck(strict),
+		)
+		assert.NilError(t, err)
+		return opts.LoadProject(context.TODO())
+	}
+
+	p, err := load(false)
+	assert.NilError(t, err)
+	assert.Equal(t, p.Services["db"].Image, "postgres:17")
+
+	_, err = load(true)
+	assert.ErrorContains(t, err, filepath.Join(root, "db", "db.env")+":2:1: duplicate variable PG_VERSION, already set on line 1")
+
+	fsys = vfs.FromFS(fstest.MapFS{
+		"compose.yaml": {Data: []byte("services:\n  web:\n    image: nginx\n    env_file: [web.env]\n")},
+		"web.env":      {Data: []byte("MODE=prod\r\n")},
+	}, root)
+	_, err = load(true)
+	assert.Error(t, err, filepath.Join(root, "web.env")+":1:10: line ends with CRLF, use LF line endings")
+}
@@ -0,0 +1,149 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/sirupsen/logrus"
+)
+
+// SearchOptions controls how WithDefaultConfigPath looks for compose files in working directory and parents
+type SearchOptions struct {
+	// StopAtGitRoot stops search at the root directory of the git repository, identified by a `.git` entry
+	StopAtGitRoot bool
+	// StopAtMarkers stops search at the first directory containing one of these files
+	StopAtMarkers []string
+	// Strict returns an error when a directory contains multiple compose files or override files,
+	// instead of logging a warning and using the first one
+	Strict bool
+}
+
+// StopAtGitRoot prevents search to look for compose files outside the current git repository
+func StopAtGitRoot(o *SearchOptions) {
+	o.StopAtGitRoot = true
+}
+
+// StopAtMarker prevents search to look for compose files above the first directory containing one of names
+func StopAtMarker(names ...string) func(*SearchOptions) {
+	return func(o *SearchOptions) {
+		o.StopAtMarkers = append(o.StopAtMarkers, names...)
+	}
+}
+
+// StrictSearch makes search fail when multiple compose files or override files are found in the same directory
+func StrictSearch(o *SearchOptions) {
+	o.Strict = true
+}
+
+// WithSearchOptions set the policy used by WithDefaultConfigPath to look for compose files. It must be set
+// before WithDefaultConfigPath
+func WithSearchOptions(options ...func(*SearchOptions)) ProjectOptionsFn {
+	return func(o *ProjectOptions) error {
+		for _, opt := range options {
+			opt(&o.search)
+		}
+		return nil
+	}
+}
+
+// SearchStep reports a directory inspected by WithDefaultConfigPath
+type SearchStep struct {
+	// Dir is the directory inspected
+	Dir string `json:"dir"`
+	// ConfigFiles are the compose files found in Dir, the first one being selected
+	ConfigFiles []string `json:"config_files,omitempty"`
+	// Overrides are the override files found in Dir, the first one being selected
+	Overrides []string `json:"overrides,omitempty"`
+	// Stop explains why search stopped at Dir, if it did
+	Stop SearchStop `json:"stop,omitempty"`
+}
+
+// SearchStop explains why search for compose files stopped
+type SearchStop string
+
+const (
+	// SearchFound means compose files have been found
+	SearchFound = SearchStop("found")
+	// SearchGitRoot means git repository root has been reached, see StopAtGitRoot
+	SearchGitRoot = SearchStop("git root")
+	// SearchMarker means a marker file has been found, see StopAtMarker
+	SearchMarker = SearchStop("marker")
+	// SearchFilesystemRoot means there's no parent directory to inspect
+	SearchFilesystemRoot = SearchStop("filesystem root")
+)
+
+// searchConfigFiles looks for compose files in dir and its parents, and returns selected files with the steps
+// which lead to this selection
+func (o *ProjectOptions) searchConfigFiles(dir string) ([]string, []SearchStep, error) {
+	var trace []SearchStep
+	fsys := o.filesystem()
+	for {
+		step := SearchStep{
+			Dir:         dir,
+			ConfigFiles: findFiles(fsys, DefaultFileNames, dir),
+		}
+		if len(step.ConfigFiles) > 0 {
+			step.Overrides = findFiles(fsys, DefaultOverrideFileNames, dir)
+			step.Stop = SearchFound
+			trace = append(trace, step)
+
+			if err := o.checkMultipleMatches("config", step.ConfigFiles); err != nil {
+				return nil, trace, err
+			}
+			files := []string{step.ConfigFiles[0]}
+			if len(step.Overrides) > 0 {
+				if err := o.checkMultipleMatches("override", step.Overrides); err != nil {
+					return nil, trace, err
+				}
+				files = append(files, step.Overrides[0])
+			}
+			return files, trace, nil
+		}
+
+		parent := filepath.Dir(dir)
+		switch {
+		case o.search.StopAtGitRoot && len(findFiles(fsys, []string{".git"}, dir)) > 0:
+			step.Stop = SearchGitRoot
+		case len(findFiles(fsys, o.search.StopAtMarkers, dir)) > 0:
+			step.Stop = SearchMarker
+		case parent == dir:
+			step.Stop = SearchFilesystemRoot
+		}
+		trace = append(trace, step)
+		if step.Stop != "" {
+			// no config file found, but that's not a blocker if caller only needs project name
+			return nil, trace, nil
+		}
+		dir = parent
+	}
+}
+
+func (o *ProjectOptions) checkMultipleMatches(kind string, files []string) error {
+	if len(files) < 2 {
+		return nil
+	}
+	if o.search.Strict {
+		return fmt.Errorf("found multiple %s files with supported names: %s: %w", kind, strings.Join(files, ", "), errdefs.ErrInvalid)
+	}
+	logrus.Warnf("Found multiple %s files with supported names: %s", kind, strings.Join(files, ", "))
+	logrus.Warnf("Using %s", files[0])
+	return nil
+}
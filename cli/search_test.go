@@ -0,0 +1,119 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/vfs"
+	"gotest.tools/v3/assert"
+)
+
+func TestSearchConfigFiles(t *testing.T) {
+	root := filepath.FromSlash("/ws")
+	fsys := vfs.FromFS(fstest.MapFS{
+		"compose.yaml":               {Data: []byte("services: {}\n")},
+		"repo/.git/HEAD":             {Data: []byte("ref: refs/heads/main\n")},
+		"repo/tools/go.mod":          {Data: []byte("module tools\n")},
+		"repo/tools/lint/main.go":    {Data: []byte("package main\n")},
+		"multi/compose.yaml":         {Data: []byte("services: {}\n")},
+		"multi/docker-compose.yml":   {Data: []byte("services: {}\n")},
+		"multi/compose.override.yml": {Data: []byte("services: {}\n")},
+	}, root)
+	path := func(name string) string {
+		return filepath.Join(root, filepath.FromSlash(name))
+	}
+	workDir := path("repo/tools/lint")
+
+	tests := []struct {
+		name     string
+		search   []func(*SearchOptions)
+		files    []string
+		trace    []SearchStep
+		dir      string
+		expected error
+	}{
+		{
+			name:  "parent project",
+			files: []string{path("compose.yaml")},
+			trace: []SearchStep{
+				{Dir: path("repo/tools/lint")},
+				{Dir: path("repo/tools")},
+				{Dir: path("repo")},
+				{Dir: root, ConfigFiles: []string{path("compose.yaml")}, Stop: SearchFound},
+			},
+		},
+		{
+			name:   "git root",
+			search: []func(*SearchOptions){StopAtGitRoot},
+			trace: []SearchStep{
+				{Dir: path("repo/tools/lint")},
+				{Dir: path("repo/tools")},
+				{Dir: path("repo"), Stop: SearchGitRoot},
+			},
+		},
+		{
+			name:   "marker",
+			search: []func(*SearchOptions){StopAtMarker("package.json", "go.mod")},
+			trace: []SearchStep{
+				{Dir: path("repo/tools/lint")},
+				{Dir: path("repo/tools"), Stop: SearchMarker},
+			},
+		},
+		{
+			name:  "multiple matches",
+			dir:   path("multi"),
+			files: []string{path("multi/compose.yaml"), path("multi/compose.override.yml")},
+			trace: []SearchStep{
+				{
+					Dir:         path("multi"),
+					ConfigFiles: []string{path("multi/compose.yaml"), path("multi/docker-compose.yml")},
+					Overrides:   []string{path("multi/compose.override.yml")},
+					Stop:        SearchFound,
+				},
+			},
+		},
+		{
+			name:     "strict",
+			dir:      path("multi"),
+			search:   []func(*SearchOptions){StrictSearch},
+			expected: errdefs.ErrInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := workDir
+			if tt.dir != "" {
+				dir = tt.dir
+			}
+			opts := &ProjectOptions{WorkingDir: dir, FS: fsys}
+			assert.NilError(t, WithSearchOptions(tt.search...)(opts))
+			err := WithDefaultConfigPath(opts)
+			if tt.expected != nil {
+				assert.ErrorIs(t, err, tt.expected)
+				assert.ErrorContains(t, err, "found multiple config files with supported names")
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, opts.ConfigPaths, tt.files)
+			assert.DeepEqual(t, opts.SearchTrace, tt.trace)
+		})
+	}
+}
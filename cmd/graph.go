@@ -0,0 +1,85 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/graph"
+)
+
+// graphCommand renders the service dependency graph of a compose project
+func graphCommand(args []string) {
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := flags.String("format", "dot", "Output format (dot|mermaid).")
+	cluster := flags.String("cluster", "", "Group services by network or profile (network|profile).")
+	volumes := flags.Bool("volumes", false, "Render volumes shared by services.")
+	_ = flags.Parse(args)
+
+	var exportOptions []func(*graph.ExportOptions)
+	switch graph.ClusterBy(*cluster) {
+	case "":
+	case graph.ClusterByNetwork, graph.ClusterByProfile:
+		exportOptions = append(exportOptions, graph.WithClusters(graph.ClusterBy(*cluster)))
+	default:
+		exitError("invalid cluster option", fmt.Errorf("unsupported value %q", *cluster))
+	}
+	if *volumes {
+		exportOptions = append(exportOptions, graph.WithVolumes)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		exitError("can't determine current directory", err)
+	}
+
+	options, err := cli.NewProjectOptions(flags.Args(),
+		cli.WithWorkingDirectory(wd),
+		cli.WithOsEnv,
+		cli.WithDotEnv,
+		cli.WithConfigFileEnv,
+		cli.WithDefaultConfigPath,
+		// a dependency cycle is rendered, as this helps to diagnose it
+		cli.WithConsistency(false),
+	)
+	if err != nil {
+		exitError("failed to configure project options", err)
+	}
+
+	project, err := options.LoadProject(context.Background())
+	if err != nil {
+		exitError("failed to load project", err)
+	}
+
+	var out string
+	switch *format {
+	case "dot":
+		out, err = graph.ToDOT(project, exportOptions...)
+	case "mermaid":
+		out, err = graph.ToMermaid(project, exportOptions...)
+	default:
+		err = fmt.Errorf("unsupported output format %s", *format)
+	}
+	if err != nil {
+		exitError("failed to render graph", err)
+	}
+	fmt.Print(out)
+}
@@ -24,6 +24,8 @@ import (
 	"os"
 
 	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/lsp"
+	"github.com/compose-spec/compose-go/v2/utils"
 	"gopkg.in/yaml.v3"
 )
 
@@ -32,16 +34,33 @@ func main() {
 		fmt.Println(`
 Validates a compose file conforms to the Compose Specification
 
-Usage: compose-spec [OPTIONS] COMPOSE_FILE [COMPOSE_OVERRIDE_FILE]`)
+Usage: compose-spec [OPTIONS] COMPOSE_FILE [COMPOSE_OVERRIDE_FILE]
+       compose-spec graph [--format dot|mermaid] [--cluster network|profile] [--volumes] [COMPOSE_FILE...]
+       compose-spec lsp`)
 	}
 
-	var skipInterpolation, skipResolvePaths, skipNormalization, skipConsistencyCheck bool
+	if len(os.Args) > 1 && os.Args[1] == "lsp" {
+		// Language Server Protocol over stdio
+		server := lsp.NewServer(os.Stdin, os.Stdout, utils.GetAsEqualsMap(os.Environ()))
+		if err := server.Serve(context.Background()); err != nil {
+			exitError("language server failed", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "graph" {
+		graphCommand(os.Args[2:])
+		return
+	}
+
+	var skipInterpolation, skipResolvePaths, skipNormalization, skipConsistencyCheck, verifyPaths bool
 	var format string
 
 	flag.BoolVar(&skipInterpolation, "no-interpolation", false, "Don't interpolate environment variables.")
 	flag.BoolVar(&skipResolvePaths, "no-path-resolution", false, "Don't resolve file paths.")
 	flag.BoolVar(&skipNormalization, "no-normalization", false, "Don't normalize compose model.")
 	flag.BoolVar(&skipConsistencyCheck, "no-consistency", false, "Don't check model consistency.")
+	flag.BoolVar(&verifyPaths, "verify-paths", false, "Check local paths declared by the model exist.")
 	flag.StringVar(&format, "format", "yaml", "Output format (yaml|json).")
 	flag.Parse()
 
@@ -60,6 +79,7 @@ Usage: compose-spec [OPTIONS] COMPOSE_FILE [COMPOSE_OVERRIDE_FILE]`)
 		cli.WithResolvedPaths(!skipResolvePaths),
 		cli.WithNormalization(!skipNormalization),
 		cli.WithConsistency(!skipConsistencyCheck),
+		cli.WithVerifiedPaths(verifyPaths),
 	)
 	if err != nil {
 		exitError("failed to configure project options", err)
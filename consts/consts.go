@@ -22,6 +22,7 @@ const (
 	ComposeFilePath              = "COMPOSE_FILE"
 	ComposeDisableDefaultEnvFile = "COMPOSE_DISABLE_ENV_FILE"
 	ComposeProfiles              = "COMPOSE_PROFILES"
+	ComposeEnv                   = "COMPOSE_ENV"
 )
 
 const Extensions = "#extensions" // Using # prefix, we prevent risk to conflict with an actual yaml key
@@ -0,0 +1,608 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package docker
+
+import (
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/convert"
+	"github.com/compose-spec/compose-go/v2/convert/engine"
+	"github.com/compose-spec/compose-go/v2/dotenv"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"golang.org/x/exp/slices"
+)
+
+// supported lists the service attributes which have an equivalent `docker run` flag, at least partially
+var supported = []string{
+	"annotations", "blkio_config", "cap_add", "cap_drop", "cgroup", "cgroup_parent", "command", "configs",
+	"container_name", "cpu_count", "cpu_percent", "cpu_period", "cpu_quota", "cpu_rt_period", "cpu_rt_runtime",
+	"cpus", "cpuset", "cpu_shares", "deploy", "device_cgroup_rules", "devices", "dns", "dns_opt", "dns_search",
+	"domainname", "entrypoint", "env_file", "environment", "expose", "extra_hosts", "gpus", "group_add",
+	"healthcheck", "hostname", "image", "init", "ipc", "isolation", "label_file", "labels", "links", "logging",
+	"mac_address", "mem_limit", "mem_reservation", "mem_swappiness", "memswap_limit", "network_mode", "networks",
+	"oom_kill_disable", "oom_score_adj", "pid", "pids_limit", "platform", "ports", "privileged", "profiles",
+	"pull_policy", "read_only", "restart", "runtime", "scale", "secrets", "security_opt", "shm_size", "stdin_open",
+	"stop_grace_period", "stop_signal", "storage_opt", "sysctls", "tmpfs", "tty", "ulimits", "user", "userns_mode",
+	"uts", "volume_driver", "volumes", "volumes_from", "working_dir",
+	// relationships and build are handled by compose before containers are created
+	"build", "depends_on", "external_links",
+}
+
+// RunArgs renders service as an equivalent `docker run` command line.
+// Attributes which have no CLI equivalent are reported as warnings.
+func RunArgs(project *types.Project, service types.ServiceConfig) ([]string, convert.Warnings) {
+	return commandArgs("run", project, service)
+}
+
+// CreateArgs renders service as an equivalent `docker create` command line.
+// Attributes which have no CLI equivalent are reported as warnings.
+func CreateArgs(project *types.Project, service types.ServiceConfig) ([]string, convert.Warnings) {
+	return commandArgs("create", project, service)
+}
+
+var safeWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Quote renders args as a POSIX shell command line
+func Quote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = quote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quote(s string) string {
+	if safeWord.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+type command struct {
+	project  *types.Project
+	service  types.ServiceConfig
+	args     []string
+	warnings convert.Warnings
+}
+
+func (c *command) add(flag string, values ...string) {
+	for _, v := range values {
+		c.args = append(c.args, flag, v)
+	}
+}
+
+func (c *command) addBool(flag string, set bool) {
+	if set {
+		c.args = append(c.args, flag)
+	}
+}
+
+func (c *command) addInt(flag string, value int64) {
+	if value != 0 {
+		c.add(flag, strconv.FormatInt(value, 10))
+	}
+}
+
+func (c *command) addString(flag string, value string) {
+	if value != "" {
+		c.add(flag, value)
+	}
+}
+
+func (c *command) addMap(flag string, m map[string]string) {
+	for _, k := range sortedKeys(m) {
+		c.add(flag, k+"="+m[k])
+	}
+}
+
+func (c *command) warn(path tree.Path, format string, args ...any) {
+	c.warnings.Add(path, format, args...)
+}
+
+func commandArgs(verb string, project *types.Project, service types.ServiceConfig) ([]string, convert.Warnings) {
+	c := &command{
+		project: project,
+		service: service,
+		args:    []string{"docker", verb},
+	}
+	p := tree.NewPath("services", service.Name)
+	c.warnings.Unsupported(service, supported...)
+	if service.GetScale() > 1 {
+		c.warn(p.Next("scale"), "command creates a single container")
+	}
+
+	c.add("--name", engine.ContainerName(project, service, 1))
+	c.addString("--platform", service.Platform)
+	switch service.PullPolicy {
+	case "", types.PullPolicyBuild:
+	case types.PullPolicyIfNotPresent:
+		c.add("--pull", types.PullPolicyMissing)
+	default:
+		c.add("--pull", service.PullPolicy)
+	}
+	c.addString("--hostname", service.Hostname)
+	c.addString("--domainname", service.DomainName)
+	c.addString("--user", service.User)
+	c.add("--group-add", service.GroupAdd...)
+	c.addString("--workdir", service.WorkingDir)
+	c.addBool("--interactive", service.StdinOpen)
+	c.addBool("--tty", service.Tty)
+	if service.Init != nil && *service.Init {
+		c.args = append(c.args, "--init")
+	}
+	c.addBool("--privileged", service.Privileged)
+	c.addBool("--read-only", service.ReadOnly)
+	c.addString("--restart", c.restart())
+
+	for _, k := range sortedKeys(service.Environment) {
+		if v := service.Environment[k]; v != nil {
+			c.add("--env", k+"="+*v)
+		} else {
+			c.add("--env", k)
+		}
+	}
+	for i, f := range service.EnvFiles {
+		if f.Format != "" || dotenv.FormatFromFilename(f.Path) != "" {
+			c.warn(p.Next("env_file").Next(strconv.Itoa(i)).Next("format"), "not supported")
+		}
+		c.add("--env-file", f.Path)
+	}
+	c.addMap("--label", service.Labels)
+	c.add("--label-file", service.LabelFiles...)
+	c.addMap("--annotation", service.Annotations)
+
+	c.ports()
+	c.networks()
+	c.mounts()
+	c.healthcheck()
+	c.resources()
+
+	c.add("--cap-add", service.CapAdd...)
+	c.add("--cap-drop", service.CapDrop...)
+	c.add("--security-opt", service.SecurityOpt...)
+	c.addString("--cgroupns", service.Cgroup)
+	c.addString("--cgroup-parent", service.CgroupParent)
+	c.addString("--ipc", service.Ipc)
+	c.addString("--pid", service.Pid)
+	c.addString("--uts", service.Uts)
+	c.addString("--userns", service.UserNSMode)
+	c.addString("--isolation", service.Isolation)
+	c.addString("--runtime", service.Runtime)
+	c.add("--dns", service.DNS...)
+	c.add("--dns-option", service.DNSOpts...)
+	c.add("--dns-search", service.DNSSearch...)
+	c.add("--add-host", service.ExtraHosts.AsList(":")...)
+	c.addMap("--sysctl", service.Sysctls)
+	c.addMap("--storage-opt", service.StorageOpt)
+	for _, d := range service.Devices {
+		device := d.Source
+		if d.Target != "" {
+			device += ":" + d.Target
+		}
+		if d.Permissions != "" {
+			device += ":" + d.Permissions
+		}
+		c.add("--device", device)
+	}
+	c.add("--device-cgroup-rule", service.DeviceCgroupRules...)
+	for _, name := range sortedKeys(service.Ulimits) {
+		u := service.Ulimits[name]
+		if u.Single != 0 {
+			c.add("--ulimit", fmt.Sprintf("%s=%d", name, u.Single))
+		} else {
+			c.add("--ulimit", fmt.Sprintf("%s=%d:%d", name, u.Soft, u.Hard))
+		}
+	}
+	if l := service.Logging; l != nil {
+		c.addString("--log-driver", l.Driver)
+		c.addMap("--log-opt", l.Options)
+	}
+	c.addString("--mac-address", service.MacAddress)
+	c.addString("--stop-signal", service.StopSignal)
+	if service.StopGracePeriod != nil {
+		c.add("--stop-timeout", strconv.Itoa(int(time.Duration(*service.StopGracePeriod).Seconds())))
+	}
+	for _, l := range service.Links {
+		name, alias, ok := strings.Cut(l, ":")
+		if !ok {
+			alias = name
+		}
+		c.add("--link", c.container(name)+":"+alias)
+	}
+	c.add("--link", service.ExternalLinks...)
+	for _, v := range service.VolumesFrom {
+		if strings.HasPrefix(v, types.ContainerPrefix) {
+			c.add("--volumes-from", strings.TrimPrefix(v, types.ContainerPrefix))
+			continue
+		}
+		name, mode, _ := strings.Cut(strings.TrimPrefix(v, types.ServicePrefix), ":")
+		from := c.container(name)
+		if mode != "" {
+			from += ":" + mode
+		}
+		c.add("--volumes-from", from)
+	}
+
+	image := service.Image
+	if image == "" {
+		image = project.Name + engine.Separator + service.Name
+	}
+	c.commandLine(image)
+	return c.args, c.warnings
+}
+
+// container returns the name of the first container for a service
+func (c *command) container(service string) string {
+	if s, ok := c.project.Services[service]; ok {
+		return engine.ContainerName(c.project, s, 1)
+	}
+	return service
+}
+
+// commandLine sets --entrypoint, which only accepts the executable, extra entrypoint arguments being passed before command
+func (c *command) commandLine(image string) {
+	s := c.service
+	var args []string
+	switch {
+	case s.Entrypoint == nil:
+	case len(s.Entrypoint) == 0:
+		c.add("--entrypoint", "")
+	default:
+		c.add("--entrypoint", s.Entrypoint[0])
+		args = append(args, s.Entrypoint[1:]...)
+	}
+	if s.Command != nil && len(s.Command) == 0 && s.Entrypoint == nil {
+		c.warn(tree.NewPath("services", s.Name, "command"), "image command can't be reset from command line")
+	}
+	c.args = append(c.args, image)
+	c.args = append(c.args, args...)
+	c.args = append(c.args, s.Command...)
+}
+
+// restart returns restart policy, or deploy.restart_policy if not set
+func (c *command) restart() string {
+	s := c.service
+	if s.Restart != "" || s.Deploy == nil || s.Deploy.RestartPolicy == nil {
+		return s.Restart
+	}
+	policy := s.Deploy.RestartPolicy
+	switch policy.Condition {
+	case "none":
+		return types.RestartPolicyNo
+	case "on-failure":
+		if policy.MaxAttempts != nil {
+			return fmt.Sprintf("%s:%d", types.RestartPolicyOnFailure, *policy.MaxAttempts)
+		}
+		return types.RestartPolicyOnFailure
+	default:
+		return types.RestartPolicyAlways
+	}
+}
+
+func (c *command) ports() {
+	for _, p := range c.service.Ports {
+		port := strconv.FormatUint(uint64(p.Target), 10)
+		if p.Published != "" {
+			port = p.Published + ":" + port
+		}
+		switch {
+		case strings.Contains(p.HostIP, ":"):
+			port = "[" + p.HostIP + "]:" + port
+		case p.HostIP != "":
+			port = p.HostIP + ":" + port
+		}
+		if p.Protocol != "" && p.Protocol != "tcp" {
+			port += "/" + p.Protocol
+		}
+		c.add("--publish", port)
+	}
+	c.add("--expose", c.service.Expose...)
+}
+
+// networks connects container to networks, service name being set as alias like compose does
+func (c *command) networks() {
+	s := c.service
+	switch mode := s.NetworkMode; {
+	case mode == "":
+	case strings.HasPrefix(mode, types.NetworkModeServicePrefix):
+		c.add("--network", types.NetworkModeContainerPrefix+c.container(strings.TrimPrefix(mode, types.NetworkModeServicePrefix)))
+		return
+	default:
+		c.add("--network", mode)
+		return
+	}
+	for _, name := range s.NetworksByPriority() {
+		network := name
+		if n, ok := c.project.Networks[name]; ok && n.Name != "" {
+			network = n.Name
+		}
+		options := []string{"name=" + network, "alias=" + s.Name}
+		if config := s.Networks[name]; config != nil {
+			for _, a := range config.Aliases {
+				options = append(options, "alias="+a)
+			}
+			if config.Ipv4Address != "" {
+				options = append(options, "ip="+config.Ipv4Address)
+			}
+			if config.Ipv6Address != "" {
+				options = append(options, "ip6="+config.Ipv6Address)
+			}
+			if config.MacAddress != "" {
+				options = append(options, "mac-address="+config.MacAddress)
+			}
+			for _, ip := range config.LinkLocalIPs {
+				options = append(options, "link-local-ip="+ip)
+			}
+			for _, k := range sortedKeys(config.DriverOpts) {
+				options = append(options, "driver-opt="+k+"="+config.DriverOpts[k])
+			}
+		}
+		c.add("--network", csvJoin(options))
+	}
+}
+
+// mounts uses short syntax for volumes ServiceVolumeConfig.String can render, --mount otherwise
+func (c *command) mounts() {
+	s := c.service
+	p := tree.NewPath("services", s.Name)
+	for i, v := range s.Volumes {
+		if v.Type == types.VolumeTypeVolume && v.Source != "" {
+			if config, ok := c.project.Volumes[v.Source]; ok && config.Name != "" {
+				v.Source = config.Name
+			}
+		}
+		switch {
+		case v.Type == types.VolumeTypeVolume && v.Source == "" && v.Volume == nil:
+			c.add("--volume", v.Target)
+		case v.Type == types.VolumeTypeVolume && v.Source != "" && (v.Volume == nil || v.Volume.Subpath == ""),
+			v.Type == types.VolumeTypeBind && v.Bind != nil && (v.Bind.CreateHostPath || v.Bind.SELinux != "") && v.Bind.Recursive == "":
+			if v.Type == types.VolumeTypeBind && !v.Bind.CreateHostPath {
+				c.warn(p.Next("volumes").Next(strconv.Itoa(i)), "--volume creates missing host path, SELinux relabeling not being supported by --mount")
+			}
+			c.add("--volume", v.String())
+		default:
+			c.add("--mount", mount(v))
+		}
+	}
+	c.add("--tmpfs", s.Tmpfs...)
+	c.addString("--volume-driver", s.VolumeDriver)
+
+	for i, config := range s.Configs {
+		target := config.Target
+		if target == "" {
+			target = "/" + config.Source
+		}
+		c.fileMount(p.Next("configs").Next(strconv.Itoa(i)), types.FileObjectConfig(c.project.Configs[config.Source]), target)
+	}
+	for i, secret := range s.Secrets {
+		target := secret.Target
+		if target == "" {
+			target = secret.Source
+		}
+		if !strings.HasPrefix(target, "/") {
+			target = "/run/secrets/" + target
+		}
+		c.fileMount(p.Next("secrets").Next(strconv.Itoa(i)), types.FileObjectConfig(c.project.Secrets[secret.Source]), target)
+	}
+}
+
+func (c *command) fileMount(p tree.Path, config types.FileObjectConfig, target string) {
+	if config.File == "" {
+		c.warn(p, "only file based configs and secrets can be mounted from command line")
+		return
+	}
+	c.add("--mount", mount(types.ServiceVolumeConfig{Type: types.VolumeTypeBind, Source: config.File, Target: target, ReadOnly: true}))
+}
+
+func mount(v types.ServiceVolumeConfig) string {
+	options := []string{"type=" + v.Type}
+	if v.Source != "" {
+		options = append(options, "source="+v.Source)
+	}
+	options = append(options, "target="+v.Target)
+	if v.ReadOnly {
+		options = append(options, "readonly")
+	}
+	if v.Consistency != "" {
+		options = append(options, "consistency="+v.Consistency)
+	}
+	if b := v.Bind; b != nil {
+		if b.Propagation != "" {
+			options = append(options, "bind-propagation="+b.Propagation)
+		}
+		if b.Recursive != "" {
+			options = append(options, "bind-recursive="+b.Recursive)
+		}
+	}
+	if o := v.Volume; o != nil {
+		if o.NoCopy {
+			options = append(options, "volume-nocopy")
+		}
+		if o.Subpath != "" {
+			options = append(options, "volume-subpath="+o.Subpath)
+		}
+	}
+	if t := v.Tmpfs; t != nil {
+		if t.Size != 0 {
+			options = append(options, "tmpfs-size="+strconv.FormatInt(int64(t.Size), 10))
+		}
+		if t.Mode != 0 {
+			options = append(options, "tmpfs-mode="+strconv.FormatUint(uint64(t.Mode), 8))
+		}
+	}
+	return csvJoin(options)
+}
+
+// csvJoin renders options as a CSV record, as docker CLI parses --mount and --network values,
+// so that fields containing a comma or a quote are quoted
+func csvJoin(fields []string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	_ = w.Write(fields)
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (c *command) healthcheck() {
+	h := c.service.HealthCheck
+	if h == nil {
+		return
+	}
+	if h.Disable || (len(h.Test) > 0 && h.Test[0] == "NONE") {
+		c.args = append(c.args, "--no-healthcheck")
+		return
+	}
+	if len(h.Test) > 0 {
+		switch h.Test[0] {
+		case "CMD-SHELL":
+			c.add("--health-cmd", strings.Join(h.Test[1:], " "))
+		case "CMD":
+			// --health-cmd always runs with shell, so arguments are quoted
+			c.add("--health-cmd", Quote(h.Test[1:]))
+		default:
+			c.add("--health-cmd", strings.Join(h.Test, " "))
+		}
+	}
+	for _, d := range []struct {
+		flag  string
+		value *types.Duration
+	}{
+		{"--health-interval", h.Interval},
+		{"--health-timeout", h.Timeout},
+		{"--health-start-period", h.StartPeriod},
+		{"--health-start-interval", h.StartInterval},
+	} {
+		if d.value != nil {
+			c.add(d.flag, d.value.String())
+		}
+	}
+	if h.Retries != nil {
+		c.add("--health-retries", strconv.FormatUint(*h.Retries, 10))
+	}
+}
+
+// resources maps resource limits, deploy.resources taking precedence over service level attributes
+func (c *command) resources() {
+	s := c.service
+	p := tree.NewPath("services", s.Name)
+	cpus, memory, reservation, pids := float64(s.CPUS), int64(s.MemLimit), int64(s.MemReservation), s.PidsLimit
+	var devices []types.DeviceRequest
+	if d := s.Deploy; d != nil {
+		if l := d.Resources.Limits; l != nil {
+			if l.NanoCPUs != 0 {
+				cpus = float64(l.NanoCPUs)
+			}
+			if l.MemoryBytes != 0 {
+				memory = int64(l.MemoryBytes)
+			}
+			if l.Pids != 0 {
+				pids = l.Pids
+			}
+		}
+		if r := d.Resources.Reservations; r != nil {
+			if r.MemoryBytes != 0 {
+				reservation = int64(r.MemoryBytes)
+			}
+			if r.NanoCPUs != 0 {
+				c.warn(p.Next("deploy").Next("resources").Next("reservations").Next("cpus"), "not supported")
+			}
+			devices = r.Devices
+		}
+		for _, attribute := range convert.Attributes(*d) {
+			if !slices.Contains([]string{"replicas", "resources", "restart_policy"}, attribute) {
+				c.warn(p.Next("deploy").Next(attribute), "not supported")
+			}
+		}
+	}
+	if cpus != 0 {
+		c.add("--cpus", strconv.FormatFloat(cpus, 'f', -1, 32))
+	}
+	c.addInt("--cpu-shares", s.CPUShares)
+	c.addInt("--cpu-period", s.CPUPeriod)
+	c.addInt("--cpu-quota", s.CPUQuota)
+	c.addInt("--cpu-rt-period", s.CPURTPeriod)
+	c.addInt("--cpu-rt-runtime", s.CPURTRuntime)
+	c.addInt("--cpu-count", s.CPUCount)
+	c.addInt("--cpu-percent", int64(s.CPUPercent))
+	c.addString("--cpuset-cpus", s.CPUSet)
+	c.addInt("--memory", memory)
+	c.addInt("--memory-reservation", reservation)
+	c.addInt("--memory-swap", int64(s.MemSwapLimit))
+	c.addInt("--memory-swappiness", int64(s.MemSwappiness))
+	c.addBool("--oom-kill-disable", s.OomKillDisable)
+	c.addInt("--oom-score-adj", s.OomScoreAdj)
+	c.addInt("--pids-limit", pids)
+	c.addInt("--shm-size", int64(s.ShmSize))
+
+	for _, g := range append(append([]types.DeviceRequest{}, s.Gpus...), devices...) {
+		c.add("--gpus", gpus(g))
+	}
+
+	if b := s.BlkioConfig; b != nil {
+		c.addInt("--blkio-weight", int64(b.Weight))
+		for _, d := range b.WeightDevice {
+			c.add("--blkio-weight-device", fmt.Sprintf("%s:%d", d.Path, d.Weight))
+		}
+		for _, t := range []struct {
+			flag    string
+			devices []types.ThrottleDevice
+		}{
+			{"--device-read-bps", b.DeviceReadBps},
+			{"--device-read-iops", b.DeviceReadIOps},
+			{"--device-write-bps", b.DeviceWriteBps},
+			{"--device-write-iops", b.DeviceWriteIOps},
+		} {
+			for _, d := range t.devices {
+				c.add(t.flag, fmt.Sprintf("%s:%d", d.Path, d.Rate))
+			}
+		}
+	}
+}
+
+// gpus renders a device request with --gpus syntax
+func gpus(d types.DeviceRequest) string {
+	var options []string
+	switch {
+	case len(d.IDs) > 0:
+		options = append(options, `"device=`+strings.Join(d.IDs, ",")+`"`)
+	case d.Count < 0:
+		options = append(options, "count=all")
+	case d.Count > 0:
+		options = append(options, fmt.Sprintf("count=%d", d.Count))
+	default:
+		options = append(options, "count=all")
+	}
+	if d.Driver != "" {
+		options = append(options, "driver="+d.Driver)
+	}
+	var capabilities []string
+	for _, c := range d.Capabilities {
+		if c != "gpu" {
+			capabilities = append(capabilities, c)
+		}
+	}
+	if len(capabilities) > 0 {
+		options = append(options, `"capabilities=`+strings.Join(capabilities, ",")+`"`)
+	}
+	return strings.Join(options, ",")
+}
@@ -0,0 +1,168 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package docker
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/convert/internal/fixtures"
+	"golang.org/x/exp/slices"
+	"gotest.tools/v3/assert"
+)
+
+func TestRunArgs(t *testing.T) {
+	project := fixtures.Project(t, `
+services:
+  web:
+    image: nginx
+    entrypoint: ["/entrypoint.sh", "--verbose"]
+    command: ["nginx", "-g", "daemon off;"]
+    environment:
+      MODE: prod
+    labels:
+      com.example.tier: front
+    ports:
+      - "8080:80"
+      - "[::1]:53:53/udp"
+    networks:
+      front:
+        aliases: [www]
+    volumes:
+      - ./html:/usr/share/nginx/html:ro
+      - data:/data
+      - type: volume
+        source: data
+        target: /backup
+        volume:
+          subpath: backup
+      - type: bind
+        source: ./conf
+        target: /etc/nginx/conf.d
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost/it's ok"]
+      interval: 10s
+      retries: 3
+    cpus: 0.5
+    mem_limit: 256m
+    restart: always
+    post_start:
+      - command: ["echo", "started"]
+    deploy:
+      placement:
+        constraints: ["node.role==manager"]
+networks:
+  front: {}
+volumes:
+  data: {}
+`)
+	args, warnings := RunArgs(project, project.Services["web"])
+	assert.DeepEqual(t, args, []string{
+		"docker", "run",
+		"--name", "demo-web-1",
+		"--restart", "always",
+		"--env", "MODE=prod",
+		"--label", "com.example.tier=front",
+		"--publish", "8080:80",
+		"--publish", "[::1]:53:53/udp",
+		"--network", "name=demo_front,alias=web,alias=www",
+		"--volume", "/src/html:/usr/share/nginx/html:ro",
+		"--volume", "demo_data:/data:rw",
+		"--mount", "type=volume,source=demo_data,target=/backup,volume-subpath=backup",
+		"--mount", "type=bind,source=/src/conf,target=/etc/nginx/conf.d",
+		"--health-cmd", `curl -f 'http://localhost/it'\''s ok'`,
+		"--health-interval", "10s",
+		"--health-retries", "3",
+		"--cpus", "0.5",
+		"--memory", "268435456",
+		"--entrypoint", "/entrypoint.sh",
+		"nginx", "--verbose", "nginx", "-g", "daemon off;",
+	})
+
+	var messages []string
+	for _, w := range warnings {
+		messages = append(messages, w.String())
+	}
+	assert.DeepEqual(t, messages, []string{
+		"services.web.post_start: not supported",
+		"services.web.deploy.placement: not supported",
+	})
+
+	assert.Equal(t, Quote(args[len(args)-4:]), `--verbose nginx -g 'daemon off;'`)
+}
+
+// TestRunArgsRoundTrip checks command line parses back into an equivalent service
+func TestRunArgsRoundTrip(t *testing.T) {
+	project := fixtures.Project(t, `
+services:
+  db:
+    image: postgres
+    container_name: db
+    user: "999"
+    ulimits:
+      nofile:
+        soft: 20000
+        hard: 40000
+    extra_hosts:
+      - somehost=162.242.195.82
+    tmpfs: /run
+    stop_grace_period: 20s
+    network_mode: host
+`)
+	service := project.Services["db"]
+	args, warnings := CreateArgs(project, service)
+	assert.Equal(t, len(warnings), 0)
+	assert.Equal(t, args[1], "create")
+
+	parsed, _, err := ParseRun(args)
+	assert.NilError(t, err)
+	assert.Equal(t, parsed.Name, "db")
+	assert.Equal(t, parsed.User, service.User)
+	assert.DeepEqual(t, parsed.Ulimits, service.Ulimits)
+	assert.DeepEqual(t, parsed.ExtraHosts, service.ExtraHosts)
+	assert.DeepEqual(t, parsed.Tmpfs, service.Tmpfs)
+	assert.DeepEqual(t, parsed.StopGracePeriod, service.StopGracePeriod)
+	assert.Equal(t, parsed.NetworkMode, service.NetworkMode)
+}
+
+func TestMountQuoting(t *testing.T) {
+	project := fixtures.Project(t, `
+services:
+  app:
+    image: app
+    cpu_count: 2
+    cpu_percent: 50
+    volumes:
+      - type: bind
+        source: /srv/a,b
+        target: /data/"x"
+        read_only: true
+`)
+	service := project.Services["app"]
+	args, _ := RunArgs(project, service)
+	assert.Check(t, slices.Contains(args, `type=bind,"source=/srv/a,b","target=/data/""x""",readonly`), args)
+
+	parsed, _, err := ParseRun(args)
+	assert.NilError(t, err)
+	assert.Equal(t, parsed.Volumes[0].Source, "/srv/a,b")
+	assert.Equal(t, parsed.Volumes[0].Target, `/data/"x"`)
+	assert.Equal(t, parsed.CPUCount, int64(2))
+	assert.Equal(t, parsed.CPUPercent, float32(50))
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, Quote([]string{"echo", "", "a b", "it's", "$HOME", "key=value"}), `echo '' 'a b' 'it'\''s' '$HOME' key=value`)
+}
@@ -0,0 +1,461 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/convert/engine"
+	"github.com/compose-spec/compose-go/v2/format"
+	"github.com/compose-spec/compose-go/v2/types"
+	"golang.org/x/exp/slices"
+)
+
+// container is the subset of `docker inspect` output used to create a service.
+// Config and HostConfig share the Engine API container-create payloads
+type container struct {
+	ID              string                  `json:"Id"`
+	Name            string                  `json:"Name"`
+	Config          *engine.ContainerConfig `json:"Config"`
+	HostConfig      *engine.HostConfig      `json:"HostConfig"`
+	NetworkSettings struct {
+		Networks map[string]*engine.EndpointSettings `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// engine defaults reported by `docker inspect`, which are omitted from imported services
+const (
+	defaultShmSize = 64 * 1024 * 1024
+	defaultRuntime = "runc"
+	defaultLogging = "json-file"
+)
+
+// ParseInspect converts containers from `docker inspect` output into services.
+// data can be the JSON array produced by `docker inspect` or a single container.
+// As inspect reports the actual container configuration, attributes inherited from image, like environment
+// or command, are imported as well, while engine defaults are omitted.
+func ParseInspect(data []byte) ([]types.ServiceConfig, error) {
+	var containers []container
+	if d := bytes.TrimSpace(data); len(d) > 0 && d[0] == '{' {
+		var c container
+		if err := json.Unmarshal(d, &c); err != nil {
+			return nil, err
+		}
+		containers = []container{c}
+	} else if err := json.Unmarshal(d, &containers); err != nil {
+		return nil, err
+	}
+
+	var services []types.ServiceConfig
+	for _, c := range containers {
+		if c.Config == nil || c.HostConfig == nil {
+			return nil, fmt.Errorf("container %s: not a container inspect document", c.Name)
+		}
+		service, err := c.service()
+		if err != nil {
+			return nil, fmt.Errorf("container %s: %w", c.Name, err)
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+func (c container) service() (types.ServiceConfig, error) {
+	config, host := c.Config, c.HostConfig
+	name := strings.TrimPrefix(c.Name, "/")
+	s := types.ServiceConfig{
+		Name:              name,
+		ContainerName:     name,
+		Image:             config.Image,
+		Hostname:          config.Hostname,
+		DomainName:        config.Domainname,
+		User:              config.User,
+		Tty:               config.Tty,
+		StdinOpen:         config.OpenStdin,
+		WorkingDir:        config.WorkingDir,
+		MacAddress:        config.MacAddress,
+		StopSignal:        config.StopSignal,
+		Command:           config.Cmd,
+		Entrypoint:        config.Entrypoint,
+		Annotations:       host.Annotations,
+		CapAdd:            host.CapAdd,
+		CapDrop:           host.CapDrop,
+		DNS:               host.DNS,
+		DNSOpts:           host.DNSOptions,
+		DNSSearch:         host.DNSSearch,
+		GroupAdd:          host.GroupAdd,
+		OomScoreAdj:       host.OomScoreAdj,
+		Pid:               host.PidMode,
+		Privileged:        host.Privileged,
+		ReadOnly:          host.ReadonlyRootfs,
+		SecurityOpt:       host.SecurityOpt,
+		StorageOpt:        host.StorageOpt,
+		Sysctls:           host.Sysctls,
+		Uts:               host.UTSMode,
+		UserNSMode:        host.UsernsMode,
+		Isolation:         host.Isolation,
+		Init:              host.Init,
+		VolumeDriver:      host.VolumeDriver,
+		VolumesFrom:       host.VolumesFrom,
+		CgroupParent:      host.CgroupParent,
+		CPUShares:         host.CPUShares,
+		CPUPeriod:         host.CPUPeriod,
+		CPUQuota:          host.CPUQuota,
+		CPURTPeriod:       host.CPURealtimePeriod,
+		CPURTRuntime:      host.CPURealtimeRuntime,
+		CPUSet:            host.CpusetCpus,
+		CPUCount:          host.CPUCount,
+		CPUPercent:        float32(host.CPUPercent),
+		MemLimit:          types.UnitBytes(host.Memory),
+		MemReservation:    types.UnitBytes(host.MemoryReservation),
+		MemSwapLimit:      types.UnitBytes(host.MemorySwap),
+		DeviceCgroupRules: host.DeviceCgroupRules,
+	}
+
+	if len(c.ID) >= 12 && s.Hostname == c.ID[:12] {
+		// engine default
+		s.Hostname = ""
+	}
+
+	labels := types.Labels{}
+	for k, v := range config.Labels {
+		if k == engine.LabelService {
+			s.Name = v
+		}
+		if !strings.HasPrefix(k, "com.docker.compose.") {
+			labels[k] = v
+		}
+	}
+	if len(labels) > 0 {
+		s.Labels = labels
+	}
+	if len(config.Env) > 0 {
+		s.Environment = types.NewMappingWithEquals(config.Env)
+	}
+	if config.StopTimeout != nil {
+		timeout := types.Duration(time.Duration(*config.StopTimeout) * time.Second)
+		s.StopGracePeriod = &timeout
+	}
+	s.HealthCheck = healthcheck(config.Healthcheck)
+
+	if host.ShmSize != defaultShmSize {
+		s.ShmSize = types.UnitBytes(host.ShmSize)
+	}
+	if host.Runtime != defaultRuntime {
+		s.Runtime = host.Runtime
+	}
+	if host.IpcMode != "private" && host.IpcMode != "shareable" {
+		s.Ipc = host.IpcMode
+	}
+	if host.CgroupnsMode != "private" && host.CgroupnsMode != "host" {
+		s.Cgroup = host.CgroupnsMode
+	}
+	if l := host.LogConfig; l != nil && (l.Type != defaultLogging || len(l.Config) > 0) {
+		s.Logging = &types.LoggingConfig{Driver: l.Type, Options: l.Config}
+	}
+	if host.NanoCPUs != 0 {
+		s.CPUS = float32(float64(host.NanoCPUs) / 1e9)
+	}
+	if host.MemorySwappiness != nil && *host.MemorySwappiness >= 0 {
+		s.MemSwappiness = types.UnitBytes(*host.MemorySwappiness)
+	}
+	if host.PidsLimit != nil && *host.PidsLimit > 0 {
+		s.PidsLimit = *host.PidsLimit
+	}
+	if host.OomKillDisable != nil {
+		s.OomKillDisable = *host.OomKillDisable
+	}
+	switch p := host.RestartPolicy; p.Name {
+	case "", types.RestartPolicyNo:
+	case types.RestartPolicyOnFailure:
+		s.Restart = p.Name
+		if p.MaximumRetryCount > 0 {
+			s.Restart = fmt.Sprintf("%s:%d", p.Name, p.MaximumRetryCount)
+		}
+	default:
+		s.Restart = p.Name
+	}
+	if len(host.ExtraHosts) > 0 {
+		hosts, err := types.NewHostsList(host.ExtraHosts)
+		if err != nil {
+			return s, err
+		}
+		s.ExtraHosts = hosts
+	}
+	if len(host.Tmpfs) > 0 {
+		for _, target := range sortedKeys(host.Tmpfs) {
+			t := target
+			if options := host.Tmpfs[target]; options != "" {
+				t += ":" + options
+			}
+			s.Tmpfs = append(s.Tmpfs, t)
+		}
+	}
+	for _, l := range host.Links {
+		// inspect reports links as `/target:/container/alias`
+		target, alias, _ := strings.Cut(l, ":")
+		s.Links = append(s.Links, strings.TrimPrefix(target, "/")+":"+path.Base(alias))
+	}
+
+	s.Ulimits = ulimits(host.Ulimits)
+	s.Devices = devices(host.Devices)
+	s.BlkioConfig = blkio(host.Resources)
+	s.Gpus, s.Deploy = deviceRequests(host.DeviceRequests)
+
+	ports, expose, err := ports(config.ExposedPorts, host.PortBindings)
+	if err != nil {
+		return s, err
+	}
+	s.Ports, s.Expose = ports, expose
+
+	volumes, err := volumes(host)
+	if err != nil {
+		return s, err
+	}
+	s.Volumes = volumes
+
+	s.NetworkMode, s.Networks = c.networks()
+	return s, nil
+}
+
+func healthcheck(h *engine.HealthConfig) *types.HealthCheckConfig {
+	if h == nil {
+		return nil
+	}
+	if len(h.Test) > 0 && h.Test[0] == "NONE" {
+		return &types.HealthCheckConfig{Disable: true}
+	}
+	duration := func(ns int64) *types.Duration {
+		if ns == 0 {
+			return nil
+		}
+		d := types.Duration(ns)
+		return &d
+	}
+	config := &types.HealthCheckConfig{
+		Test:          h.Test,
+		Interval:      duration(h.Interval),
+		Timeout:       duration(h.Timeout),
+		StartPeriod:   duration(h.StartPeriod),
+		StartInterval: duration(h.StartInterval),
+	}
+	if h.Retries != 0 {
+		retries := uint64(h.Retries)
+		config.Retries = &retries
+	}
+	return config
+}
+
+func ulimits(list []engine.Ulimit) map[string]*types.UlimitsConfig {
+	if len(list) == 0 {
+		return nil
+	}
+	limits := map[string]*types.UlimitsConfig{}
+	for _, u := range list {
+		if u.Soft == u.Hard {
+			limits[u.Name] = &types.UlimitsConfig{Single: int(u.Soft)}
+		} else {
+			limits[u.Name] = &types.UlimitsConfig{Soft: int(u.Soft), Hard: int(u.Hard)}
+		}
+	}
+	return limits
+}
+
+func devices(list []engine.DeviceMapping) []types.DeviceMapping {
+	var mappings []types.DeviceMapping
+	for _, d := range list {
+		mappings = append(mappings, types.DeviceMapping{
+			Source:      d.PathOnHost,
+			Target:      d.PathInContainer,
+			Permissions: d.CgroupPermissions,
+		})
+	}
+	return mappings
+}
+
+func blkio(r engine.Resources) *types.BlkioConfig {
+	throttle := func(list []engine.ThrottleDevice) []types.ThrottleDevice {
+		var devices []types.ThrottleDevice
+		for _, d := range list {
+			devices = append(devices, types.ThrottleDevice{Path: d.Path, Rate: types.UnitBytes(d.Rate)})
+		}
+		return devices
+	}
+	b := &types.BlkioConfig{
+		Weight:          r.BlkioWeight,
+		DeviceReadBps:   throttle(r.BlkioDeviceReadBps),
+		DeviceReadIOps:  throttle(r.BlkioDeviceReadIOps),
+		DeviceWriteBps:  throttle(r.BlkioDeviceWriteBps),
+		DeviceWriteIOps: throttle(r.BlkioDeviceWriteIOps),
+	}
+	for _, d := range r.BlkioWeightDevice {
+		b.WeightDevice = append(b.WeightDevice, types.WeightDevice{Path: d.Path, Weight: d.Weight})
+	}
+	if b.Weight == 0 && len(b.WeightDevice)+len(b.DeviceReadBps)+len(b.DeviceReadIOps)+len(b.DeviceWriteBps)+len(b.DeviceWriteIOps) == 0 {
+		return nil
+	}
+	return b
+}
+
+// deviceRequests maps GPU requests to `gpus`, others to deploy.resources.reservations.devices
+func deviceRequests(list []engine.DeviceRequest) ([]types.DeviceRequest, *types.DeployConfig) {
+	var (
+		gpus   []types.DeviceRequest
+		deploy *types.DeployConfig
+	)
+	for _, d := range list {
+		var capabilities []string
+		for _, c := range d.Capabilities {
+			capabilities = append(capabilities, c...)
+		}
+		request := types.DeviceRequest{
+			Capabilities: capabilities,
+			Driver:       d.Driver,
+			Count:        types.DeviceCount(d.Count),
+			IDs:          d.DeviceIDs,
+			Options:      d.Options,
+		}
+		if slices.Contains(capabilities, "gpu") {
+			gpus = append(gpus, request)
+			continue
+		}
+		if deploy == nil {
+			deploy = &types.DeployConfig{Resources: types.Resources{Reservations: &types.Resource{}}}
+		}
+		deploy.Resources.Reservations.Devices = append(deploy.Resources.Reservations.Devices, request)
+	}
+	return gpus, deploy
+}
+
+// ports converts port bindings with types.ParsePortConfig, exposed ports without binding being returned as expose
+func ports(exposed map[string]struct{}, bindings map[string][]engine.PortBinding) ([]types.ServicePortConfig, types.StringOrNumberList, error) {
+	var (
+		ports  []types.ServicePortConfig
+		expose types.StringOrNumberList
+	)
+	for _, key := range sortedKeys(bindings) {
+		for _, b := range bindings[key] {
+			spec := key
+			switch {
+			case b.HostIP != "" && strings.Contains(b.HostIP, ":"):
+				spec = "[" + b.HostIP + "]:" + b.HostPort + ":" + key
+			case b.HostIP != "":
+				spec = b.HostIP + ":" + b.HostPort + ":" + key
+			case b.HostPort != "":
+				spec = b.HostPort + ":" + key
+			}
+			p, err := types.ParsePortConfig(spec)
+			if err != nil {
+				return nil, nil, err
+			}
+			ports = append(ports, p...)
+		}
+	}
+	for _, key := range sortedKeys(exposed) {
+		if _, ok := bindings[key]; ok {
+			continue
+		}
+		expose = append(expose, strings.TrimSuffix(key, "/tcp"))
+	}
+	return ports, expose, nil
+}
+
+// volumes converts legacy binds with format.ParseVolume, and mounts
+func volumes(host *engine.HostConfig) ([]types.ServiceVolumeConfig, error) {
+	var volumes []types.ServiceVolumeConfig
+	for _, b := range host.Binds {
+		v, err := format.ParseVolume(b)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, v)
+	}
+	for _, m := range host.Mounts {
+		v := types.ServiceVolumeConfig{
+			Type:        m.Type,
+			Source:      m.Source,
+			Target:      m.Target,
+			ReadOnly:    m.ReadOnly,
+			Consistency: m.Consistency,
+		}
+		if o := m.BindOptions; o != nil {
+			v.Bind = &types.ServiceVolumeBind{Propagation: o.Propagation, CreateHostPath: o.CreateMountpoint}
+		}
+		if o := m.VolumeOptions; o != nil {
+			v.Volume = &types.ServiceVolumeVolume{NoCopy: o.NoCopy, Subpath: o.Subpath}
+		}
+		if o := m.TmpfsOptions; o != nil {
+			v.Tmpfs = &types.ServiceVolumeTmpfs{Size: types.UnitBytes(o.SizeBytes), Mode: o.Mode}
+		}
+		volumes = append(volumes, v)
+	}
+	return volumes, nil
+}
+
+// networks returns network_mode for builtin modes, or networks container is connected to
+func (c container) networks() (string, map[string]*types.ServiceNetworkConfig) {
+	switch mode := c.HostConfig.NetworkMode; {
+	case mode == "" || mode == "default" || mode == "bridge":
+		return "", nil
+	case mode == "host" || mode == "none" || strings.HasPrefix(mode, types.NetworkModeContainerPrefix):
+		return mode, nil
+	}
+	networks := map[string]*types.ServiceNetworkConfig{}
+	for name, endpoint := range c.NetworkSettings.Networks {
+		if endpoint == nil {
+			networks[name] = nil
+			continue
+		}
+		// MacAddress is not imported, as inspect reports the one assigned by engine
+		config := &types.ServiceNetworkConfig{
+			DriverOpts: endpoint.DriverOpts,
+		}
+		for _, alias := range endpoint.Aliases {
+			// engine adds container name and short ID as aliases
+			if alias == strings.TrimPrefix(c.Name, "/") || (len(c.ID) >= 12 && alias == c.ID[:12]) {
+				continue
+			}
+			config.Aliases = append(config.Aliases, alias)
+		}
+		if ipam := endpoint.IPAMConfig; ipam != nil {
+			config.Ipv4Address = ipam.IPv4Address
+			config.Ipv6Address = ipam.IPv6Address
+			config.LinkLocalIPs = ipam.LinkLocalIPs
+		}
+		if len(config.DriverOpts) == 0 && len(config.Aliases) == 0 &&
+			config.Ipv4Address == "" && config.Ipv6Address == "" && len(config.LinkLocalIPs) == 0 {
+			config = nil
+		}
+		networks[name] = config
+	}
+	return "", networks
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,91 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package docker
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseInspect(t *testing.T) {
+	b, err := os.ReadFile("testdata/inspect.json")
+	assert.NilError(t, err)
+	services, err := ParseInspect(b)
+	assert.NilError(t, err)
+	assert.Equal(t, len(services), 1)
+
+	retries := uint64(3)
+	interval := types.Duration(30 * time.Second)
+	stop := types.Duration(20 * time.Second)
+	assert.DeepEqual(t, services[0], types.ServiceConfig{
+		Name:          "web",
+		ContainerName: "demo-web-1",
+		Image:         "nginx:1.25",
+		Entrypoint:    types.ShellCommand{"/docker-entrypoint.sh"},
+		Command:       types.ShellCommand{"nginx", "-g", "daemon off;"},
+		Environment: types.NewMappingWithEquals([]string{
+			"MODE=prod",
+			"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+		}),
+		Labels: types.Labels{"com.example.tier": "front"},
+		HealthCheck: &types.HealthCheckConfig{
+			Test:     types.HealthCheckTest{"CMD-SHELL", "curl -f http://localhost"},
+			Interval: &interval,
+			Retries:  &retries,
+		},
+		StopSignal:      "SIGQUIT",
+		StopGracePeriod: &stop,
+		Ports: []types.ServicePortConfig{
+			{Mode: "ingress", HostIP: "::1", Target: 443, Published: "8443", Protocol: "tcp"},
+			{Mode: "ingress", Target: 80, Published: "8080", Protocol: "tcp"},
+		},
+		Expose: types.StringOrNumberList{"9000/udp"},
+		Volumes: []types.ServiceVolumeConfig{
+			{Type: types.VolumeTypeBind, Source: "/srv/html", Target: "/usr/share/nginx/html", ReadOnly: true, Bind: &types.ServiceVolumeBind{CreateHostPath: true}},
+			{Type: types.VolumeTypeVolume, Source: "demo_cache", Target: "/cache", Volume: &types.ServiceVolumeVolume{NoCopy: true}},
+		},
+		Restart:    "on-failure:5",
+		CapAdd:     []string{"NET_ADMIN"},
+		DNS:        types.StringList{},
+		ExtraHosts: types.HostsList{"somehost": []string{"162.242.195.82"}},
+		Links:      []string{"demo-db-1:db"},
+		Tmpfs:      types.StringList{"/run:size=10m"},
+		MemLimit:   256 * 1024 * 1024,
+		CPUS:       1.5,
+		Ulimits: map[string]*types.UlimitsConfig{
+			"nofile": {Soft: 20000, Hard: 40000},
+			"nproc":  {Single: 65535},
+		},
+		Networks: map[string]*types.ServiceNetworkConfig{
+			"demo_front": {Aliases: []string{"web"}},
+		},
+	})
+}
+
+func TestParseInspectSingle(t *testing.T) {
+	services, err := ParseInspect([]byte(`{"Name": "/db", "Config": {"Image": "postgres"}, "HostConfig": {"NetworkMode": "host"}}`))
+	assert.NilError(t, err)
+	assert.Equal(t, services[0].Name, "db")
+	assert.Equal(t, services[0].NetworkMode, "host")
+
+	_, err = ParseInspect([]byte(`[{"Name": "/image"}]`))
+	assert.Error(t, err, "container /image: not a container inspect document")
+}
@@ -0,0 +1,821 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package docker
+
+import (
+	"encoding/csv"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/convert"
+	"github.com/compose-spec/compose-go/v2/format"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/go-units"
+	"github.com/mattn/go-shellwords"
+)
+
+// flag declares a `docker run` flag and how it applies to a service
+type flag struct {
+	name  string
+	short string
+	// boolean flags don't consume a value, but accept `--flag=false`
+	boolean bool
+	apply   func(r *runParser, value string) error
+}
+
+// runParser collects state for flags which depend on each other, like --network-alias
+type runParser struct {
+	service  *types.ServiceConfig
+	warnings convert.Warnings
+	aliases  []string
+	ipv4     string
+	ipv6     string
+	hosts    []string
+	ignored  []string
+}
+
+// ParseRun converts a `docker run` or `docker create` command line into a service.
+// Arguments can be a full command line, starting with `docker run`, or only the flags, image and command.
+// Flags without compose equivalent, like `--rm`, are reported as warnings. Unknown flags are rejected.
+func ParseRun(args []string) (*types.ServiceConfig, convert.Warnings, error) {
+	args = trimRunCommand(args)
+	r := &runParser{service: &types.ServiceConfig{}}
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			i++
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			break
+		}
+		consumed, err := r.parseFlag(arg, args[i+1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		i += consumed
+	}
+	if i >= len(args) {
+		return nil, nil, fmt.Errorf("docker run requires an image")
+	}
+	r.service.Image = args[i]
+	if command := args[i+1:]; len(command) > 0 {
+		r.service.Command = command
+	}
+	if err := r.finish(); err != nil {
+		return nil, nil, err
+	}
+	return r.service, r.warnings, nil
+}
+
+// ParseRunCommandLine splits a shell command line into arguments before parsing it with ParseRun
+func ParseRunCommandLine(commandLine string) (*types.ServiceConfig, convert.Warnings, error) {
+	parser := shellwords.NewParser()
+	args, err := parser.Parse(commandLine)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ParseRun(args)
+}
+
+func trimRunCommand(args []string) []string {
+	if len(args) > 0 && (args[0] == "docker" || path.Base(args[0]) == "docker") {
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "container" {
+		args = args[1:]
+	}
+	if len(args) > 0 && (args[0] == "run" || args[0] == "create") {
+		args = args[1:]
+	}
+	return args
+}
+
+// parseFlag applies a flag and returns the number of following arguments consumed as value
+func (r *runParser) parseFlag(arg string, next []string) (int, error) {
+	if strings.HasPrefix(arg, "--") {
+		name, value, hasValue := strings.Cut(arg[2:], "=")
+		f, ok := longFlags[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown flag: --%s", name)
+		}
+		return r.applyFlag(f, "--"+name, value, hasValue, next)
+	}
+
+	// short flags can be combined, like `-it`, the last one possibly taking a value, like `-itp80:80`
+	shorts := arg[1:]
+	for j, c := range shorts {
+		f, ok := shortFlags[string(c)]
+		if !ok {
+			return 0, fmt.Errorf("unknown shorthand flag: %q in %s", c, arg)
+		}
+		rest := shorts[j+1:]
+		if f.boolean {
+			if err := f.apply(r, "true"); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		value := strings.TrimPrefix(rest, "=")
+		return r.applyFlag(f, "-"+string(c), value, rest != "", next)
+	}
+	return 0, nil
+}
+
+func (r *runParser) applyFlag(f *flag, name string, value string, hasValue bool, next []string) (int, error) {
+	consumed := 0
+	switch {
+	case f.boolean && !hasValue:
+		value = "true"
+	case !hasValue:
+		if len(next) == 0 {
+			return 0, fmt.Errorf("flag needs an argument: %s", name)
+		}
+		value = next[0]
+		consumed = 1
+	}
+	if err := f.apply(r, value); err != nil {
+		return 0, fmt.Errorf("invalid argument %q for %s: %w", value, name, err)
+	}
+	return consumed, nil
+}
+
+// finish applies flags which relate to others
+func (r *runParser) finish() error {
+	s := r.service
+	if len(r.hosts) > 0 {
+		hosts, err := types.NewHostsList(r.hosts)
+		if err != nil {
+			return err
+		}
+		s.ExtraHosts = hosts
+	}
+	if len(r.aliases) > 0 || r.ipv4 != "" || r.ipv6 != "" {
+		if len(s.Networks) == 0 {
+			return fmt.Errorf("network aliases and static IP addresses require a user-defined network")
+		}
+		for name, config := range s.Networks {
+			if config == nil {
+				config = &types.ServiceNetworkConfig{}
+				s.Networks[name] = config
+			}
+			config.Aliases = append(config.Aliases, r.aliases...)
+			if r.ipv4 != "" {
+				config.Ipv4Address = r.ipv4
+			}
+			if r.ipv6 != "" {
+				config.Ipv6Address = r.ipv6
+			}
+		}
+	}
+	if s.Name == "" {
+		s.Name = serviceName(s.ContainerName, s.Image)
+	}
+	for _, f := range r.ignored {
+		r.warnings.Add(tree.NewPath("services", s.Name), "%s has no compose equivalent and is ignored", f)
+	}
+	return nil
+}
+
+// serviceName derives a service name from container name, or image repository name
+func serviceName(container string, image string) string {
+	if container != "" {
+		return container
+	}
+	name := path.Base(image)
+	if i := strings.IndexAny(name, ":@"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+var (
+	longFlags  = map[string]*flag{}
+	shortFlags = map[string]*flag{}
+)
+
+func init() {
+	for i := range runFlags {
+		f := &runFlags[i]
+		longFlags[f.name] = f
+		if f.short != "" {
+			shortFlags[f.short] = f
+		}
+	}
+}
+
+func ignore(name string, short string, boolean bool) flag {
+	return flag{name: name, short: short, boolean: boolean, apply: func(r *runParser, _ string) error {
+		r.ignored = append(r.ignored, "--"+name)
+		return nil
+	}}
+}
+
+func boolValue(value string) (bool, error) {
+	return strconv.ParseBool(value)
+}
+
+func setBool(target func(*types.ServiceConfig) *bool) func(*runParser, string) error {
+	return func(r *runParser, value string) error {
+		b, err := boolValue(value)
+		*target(r.service) = b
+		return err
+	}
+}
+
+func setString(target func(*types.ServiceConfig) *string) func(*runParser, string) error {
+	return func(r *runParser, value string) error {
+		*target(r.service) = value
+		return nil
+	}
+}
+
+func appendString(target func(*types.ServiceConfig) *[]string) func(*runParser, string) error {
+	return func(r *runParser, value string) error {
+		t := target(r.service)
+		*t = append(*t, value)
+		return nil
+	}
+}
+
+func setInt64(target func(*types.ServiceConfig) *int64) func(*runParser, string) error {
+	return func(r *runParser, value string) error {
+		i, err := strconv.ParseInt(value, 10, 64)
+		*target(r.service) = i
+		return err
+	}
+}
+
+func setBytes(target func(*types.ServiceConfig) *types.UnitBytes) func(*runParser, string) error {
+	return func(r *runParser, value string) error {
+		if value == "-1" {
+			// unlimited
+			*target(r.service) = -1
+			return nil
+		}
+		b, err := units.RAMInBytes(value)
+		*target(r.service) = types.UnitBytes(b)
+		return err
+	}
+}
+
+func setDuration(target func(*types.HealthCheckConfig) **types.Duration) func(*runParser, string) error {
+	return func(r *runParser, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		duration := types.Duration(d)
+		*target(r.healthcheck()) = &duration
+		return nil
+	}
+}
+
+func (r *runParser) healthcheck() *types.HealthCheckConfig {
+	if r.service.HealthCheck == nil {
+		r.service.HealthCheck = &types.HealthCheckConfig{}
+	}
+	return r.service.HealthCheck
+}
+
+func (r *runParser) blkio() *types.BlkioConfig {
+	if r.service.BlkioConfig == nil {
+		r.service.BlkioConfig = &types.BlkioConfig{}
+	}
+	return r.service.BlkioConfig
+}
+
+func (r *runParser) logging() *types.LoggingConfig {
+	if r.service.Logging == nil {
+		r.service.Logging = &types.LoggingConfig{}
+	}
+	return r.service.Logging
+}
+
+func throttleDevice(target func(*types.BlkioConfig) *[]types.ThrottleDevice, bytes bool) func(*runParser, string) error {
+	return func(r *runParser, value string) error {
+		device, rate, ok := strings.Cut(value, ":")
+		if !ok {
+			return fmt.Errorf("expected path:rate")
+		}
+		var (
+			v   int64
+			err error
+		)
+		if bytes {
+			v, err = units.RAMInBytes(rate)
+		} else {
+			v, err = strconv.ParseInt(rate, 10, 64)
+		}
+		if err != nil {
+			return err
+		}
+		t := target(r.blkio())
+		*t = append(*t, types.ThrottleDevice{Path: device, Rate: types.UnitBytes(v)})
+		return nil
+	}
+}
+
+var runFlags = []flag{
+	{name: "add-host", apply: func(r *runParser, v string) error {
+		r.hosts = append(r.hosts, v)
+		return nil
+	}},
+	{name: "annotation", apply: func(r *runParser, v string) error {
+		key, value, _ := strings.Cut(v, "=")
+		if r.service.Annotations == nil {
+			r.service.Annotations = types.Mapping{}
+		}
+		r.service.Annotations[key] = value
+		return nil
+	}},
+	ignore("attach", "a", false),
+	{name: "blkio-weight", apply: func(r *runParser, v string) error {
+		w, err := strconv.ParseUint(v, 10, 16)
+		r.blkio().Weight = uint16(w)
+		return err
+	}},
+	{name: "blkio-weight-device", apply: func(r *runParser, v string) error {
+		device, weight, ok := strings.Cut(v, ":")
+		if !ok {
+			return fmt.Errorf("expected path:weight")
+		}
+		w, err := strconv.ParseUint(weight, 10, 16)
+		if err != nil {
+			return err
+		}
+		r.blkio().WeightDevice = append(r.blkio().WeightDevice, types.WeightDevice{Path: device, Weight: uint16(w)})
+		return nil
+	}},
+	{name: "cap-add", apply: appendString(func(s *types.ServiceConfig) *[]string { return &s.CapAdd })},
+	{name: "cap-drop", apply: appendString(func(s *types.ServiceConfig) *[]string { return &s.CapDrop })},
+	{name: "cgroup-parent", apply: setString(func(s *types.ServiceConfig) *string { return &s.CgroupParent })},
+	{name: "cgroupns", apply: setString(func(s *types.ServiceConfig) *string { return &s.Cgroup })},
+	ignore("cidfile", "", false),
+	{name: "cpu-count", apply: setInt64(func(s *types.ServiceConfig) *int64 { return &s.CPUCount })},
+	{name: "cpu-percent", apply: func(r *runParser, v string) error {
+		f, err := strconv.ParseFloat(v, 32)
+		r.service.CPUPercent = float32(f)
+		return err
+	}},
+	{name: "cpu-period", apply: setInt64(func(s *types.ServiceConfig) *int64 { return &s.CPUPeriod })},
+	{name: "cpu-quota", apply: setInt64(func(s *types.ServiceConfig) *int64 { return &s.CPUQuota })},
+	{name: "cpu-rt-period", apply: setInt64(func(s *types.ServiceConfig) *int64 { return &s.CPURTPeriod })},
+	{name: "cpu-rt-runtime", apply: setInt64(func(s *types.ServiceConfig) *int64 { return &s.CPURTRuntime })},
+	{name: "cpu-shares", short: "c", apply: setInt64(func(s *types.ServiceConfig) *int64 { return &s.CPUShares })},
+	{name: "cpus", apply: func(r *runParser, v string) error {
+		f, err := strconv.ParseFloat(v, 32)
+		r.service.CPUS = float32(f)
+		return err
+	}},
+	{name: "cpuset-cpus", apply: setString(func(s *types.ServiceConfig) *string { return &s.CPUSet })},
+	{name: "detach", short: "d", boolean: true, apply: func(r *runParser, _ string) error { return nil }},
+	ignore("detach-keys", "", false),
+	{name: "device", apply: func(r *runParser, v string) error {
+		parts := strings.Split(v, ":")
+		device := types.DeviceMapping{Source: parts[0], Target: parts[0], Permissions: "rwm"}
+		if len(parts) > 1 {
+			device.Target = parts[1]
+		}
+		if len(parts) > 2 {
+			device.Permissions = parts[2]
+		}
+		r.service.Devices = append(r.service.Devices, device)
+		return nil
+	}},
+	{name: "device-cgroup-rule", apply: appendString(func(s *types.ServiceConfig) *[]string { return &s.DeviceCgroupRules })},
+	{name: "device-read-bps", apply: throttleDevice(func(b *types.BlkioConfig) *[]types.ThrottleDevice { return &b.DeviceReadBps }, true)},
+	{name: "device-read-iops", apply: throttleDevice(func(b *types.BlkioConfig) *[]types.ThrottleDevice { return &b.DeviceReadIOps }, false)},
+	{name: "device-write-bps", apply: throttleDevice(func(b *types.BlkioConfig) *[]types.ThrottleDevice { return &b.DeviceWriteBps }, true)},
+	{name: "device-write-iops", apply: throttleDevice(func(b *types.BlkioConfig) *[]types.ThrottleDevice { return &b.DeviceWriteIOps }, false)},
+	ignore("disable-content-trust", "", true),
+	{name: "dns", apply: func(r *runParser, v string) error {
+		r.service.DNS = append(r.service.DNS, v)
+		return nil
+	}},
+	{name: "dns-option", apply: appendString(func(s *types.ServiceConfig) *[]string { return &s.DNSOpts })},
+	{name: "dns-search", apply: func(r *runParser, v string) error {
+		r.service.DNSSearch = append(r.service.DNSSearch, v)
+		return nil
+	}},
+	{name: "domainname", apply: setString(func(s *types.ServiceConfig) *string { return &s.DomainName })},
+	{name: "entrypoint", apply: func(r *runParser, v string) error {
+		// docker run doesn't split entrypoint, an empty value resets image entrypoint
+		r.service.Entrypoint = types.ShellCommand{}
+		if v != "" {
+			r.service.Entrypoint = types.ShellCommand{v}
+		}
+		return nil
+	}},
+	{name: "env", short: "e", apply: func(r *runParser, v string) error {
+		if r.service.Environment == nil {
+			r.service.Environment = types.MappingWithEquals{}
+		}
+		r.service.Environment.OverrideBy(types.NewMappingWithEquals([]string{v}))
+		return nil
+	}},
+	{name: "env-file", apply: func(r *runParser, v string) error {
+		r.service.EnvFiles = append(r.service.EnvFiles, types.EnvFile{Path: v, Required: true})
+		return nil
+	}},
+	{name: "expose", apply: func(r *runParser, v string) error {
+		r.service.Expose = append(r.service.Expose, v)
+		return nil
+	}},
+	{name: "gpus", apply: func(r *runParser, v string) error {
+		request := types.DeviceRequest{Capabilities: []string{"gpu"}}
+		for _, field := range strings.Split(v, ",") {
+			key, value, ok := strings.Cut(field, "=")
+			switch {
+			case !ok && key == "all":
+				request.Count = -1
+			case !ok:
+				count, err := strconv.ParseInt(key, 10, 64)
+				if err != nil {
+					return err
+				}
+				request.Count = types.DeviceCount(count)
+			case key == "count":
+				if value == "all" {
+					request.Count = -1
+					continue
+				}
+				count, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return err
+				}
+				request.Count = types.DeviceCount(count)
+			case key == "device":
+				request.IDs = strings.Split(strings.Trim(value, `"`), ",")
+			case key == "driver":
+				request.Driver = value
+			case key == "capabilities":
+				request.Capabilities = strings.Split(value, ",")
+			default:
+				return fmt.Errorf("unexpected key %q", key)
+			}
+		}
+		r.service.Gpus = append(r.service.Gpus, request)
+		return nil
+	}},
+	{name: "group-add", apply: appendString(func(s *types.ServiceConfig) *[]string { return &s.GroupAdd })},
+	{name: "health-cmd", apply: func(r *runParser, v string) error {
+		r.healthcheck().Test = types.HealthCheckTest{"CMD-SHELL", v}
+		return nil
+	}},
+	{name: "health-interval", apply: setDuration(func(h *types.HealthCheckConfig) **types.Duration { return &h.Interval })},
+	{name: "health-retries", apply: func(r *runParser, v string) error {
+		retries, err := strconv.ParseUint(v, 10, 64)
+		r.healthcheck().Retries = &retries
+		return err
+	}},
+	{name: "health-start-interval", apply: setDuration(func(h *types.HealthCheckConfig) **types.Duration { return &h.StartInterval })},
+	{name: "health-start-period", apply: setDuration(func(h *types.HealthCheckConfig) **types.Duration { return &h.StartPeriod })},
+	{name: "health-timeout", apply: setDuration(func(h *types.HealthCheckConfig) **types.Duration { return &h.Timeout })},
+	{name: "hostname", short: "h", apply: setString(func(s *types.ServiceConfig) *string { return &s.Hostname })},
+	{name: "init", boolean: true, apply: func(r *runParser, v string) error {
+		b, err := boolValue(v)
+		r.service.Init = &b
+		return err
+	}},
+	{name: "interactive", short: "i", boolean: true, apply: setBool(func(s *types.ServiceConfig) *bool { return &s.StdinOpen })},
+	{name: "ip", apply: func(r *runParser, v string) error {
+		r.ipv4 = v
+		return nil
+	}},
+	{name: "ip6", apply: func(r *runParser, v string) error {
+		r.ipv6 = v
+		return nil
+	}},
+	{name: "ipc", apply: setString(func(s *types.ServiceConfig) *string { return &s.Ipc })},
+	{name: "isolation", apply: setString(func(s *types.ServiceConfig) *string { return &s.Isolation })},
+	{name: "label", short: "l", apply: func(r *runParser, v string) error {
+		key, value, _ := strings.Cut(v, "=")
+		if r.service.Labels == nil {
+			r.service.Labels = types.Labels{}
+		}
+		r.service.Labels[key] = value
+		return nil
+	}},
+	{name: "label-file", apply: appendString(func(s *types.ServiceConfig) *[]string { return &s.LabelFiles })},
+	{name: "link", apply: appendString(func(s *types.ServiceConfig) *[]string { return &s.Links })},
+	{name: "log-driver", apply: func(r *runParser, v string) error {
+		r.logging().Driver = v
+		return nil
+	}},
+	{name: "log-opt", apply: func(r *runParser, v string) error {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value")
+		}
+		if r.logging().Options == nil {
+			r.logging().Options = types.Options{}
+		}
+		r.logging().Options[key] = value
+		return nil
+	}},
+	{name: "mac-address", apply: setString(func(s *types.ServiceConfig) *string { return &s.MacAddress })},
+	{name: "memory", short: "m", apply: setBytes(func(s *types.ServiceConfig) *types.UnitBytes { return &s.MemLimit })},
+	{name: "memory-reservation", apply: setBytes(func(s *types.ServiceConfig) *types.UnitBytes { return &s.MemReservation })},
+	{name: "memory-swap", apply: setBytes(func(s *types.ServiceConfig) *types.UnitBytes { return &s.MemSwapLimit })},
+	{name: "memory-swappiness", apply: func(r *runParser, v string) error {
+		i, err := strconv.ParseInt(v, 10, 64)
+		if i >= 0 {
+			r.service.MemSwappiness = types.UnitBytes(i)
+		}
+		return err
+	}},
+	{name: "mount", apply: func(r *runParser, v string) error {
+		volume, err := parseMount(v)
+		r.service.Volumes = append(r.service.Volumes, volume)
+		return err
+	}},
+	{name: "name", apply: setString(func(s *types.ServiceConfig) *string { return &s.ContainerName })},
+	{name: "network", apply: func(r *runParser, v string) error {
+		return r.network(v)
+	}},
+	{name: "net", apply: func(r *runParser, v string) error {
+		return r.network(v)
+	}},
+	{name: "network-alias", apply: func(r *runParser, v string) error {
+		r.aliases = append(r.aliases, v)
+		return nil
+	}},
+	{name: "net-alias", apply: func(r *runParser, v string) error {
+		r.aliases = append(r.aliases, v)
+		return nil
+	}},
+	{name: "no-healthcheck", boolean: true, apply: func(r *runParser, v string) error {
+		b, err := boolValue(v)
+		r.healthcheck().Disable = b
+		return err
+	}},
+	{name: "oom-kill-disable", boolean: true, apply: setBool(func(s *types.ServiceConfig) *bool { return &s.OomKillDisable })},
+	{name: "oom-score-adj", apply: setInt64(func(s *types.ServiceConfig) *int64 { return &s.OomScoreAdj })},
+	{name: "pid", apply: setString(func(s *types.ServiceConfig) *string { return &s.Pid })},
+	{name: "pids-limit", apply: setInt64(func(s *types.ServiceConfig) *int64 { return &s.PidsLimit })},
+	{name: "platform", apply: setString(func(s *types.ServiceConfig) *string { return &s.Platform })},
+	{name: "privileged", boolean: true, apply: setBool(func(s *types.ServiceConfig) *bool { return &s.Privileged })},
+	{name: "publish", short: "p", apply: func(r *runParser, v string) error {
+		ports, err := types.ParsePortConfig(v)
+		r.service.Ports = append(r.service.Ports, ports...)
+		return err
+	}},
+	ignore("publish-all", "P", true),
+	{name: "pull", apply: func(r *runParser, v string) error {
+		switch v {
+		case types.PullPolicyAlways, types.PullPolicyMissing, types.PullPolicyNever:
+			r.service.PullPolicy = v
+			return nil
+		}
+		return fmt.Errorf("unexpected pull policy")
+	}},
+	ignore("quiet", "q", true),
+	{name: "read-only", boolean: true, apply: setBool(func(s *types.ServiceConfig) *bool { return &s.ReadOnly })},
+	{name: "restart", apply: setString(func(s *types.ServiceConfig) *string { return &s.Restart })},
+	ignore("rm", "", true),
+	{name: "runtime", apply: setString(func(s *types.ServiceConfig) *string { return &s.Runtime })},
+	{name: "security-opt", apply: appendString(func(s *types.ServiceConfig) *[]string { return &s.SecurityOpt })},
+	{name: "shm-size", apply: setBytes(func(s *types.ServiceConfig) *types.UnitBytes { return &s.ShmSize })},
+	ignore("sig-proxy", "", true),
+	{name: "stop-signal", apply: setString(func(s *types.ServiceConfig) *string { return &s.StopSignal })},
+	{name: "stop-timeout", apply: func(r *runParser, v string) error {
+		seconds, err := strconv.Atoi(v)
+		timeout := types.Duration(time.Duration(seconds) * time.Second)
+		r.service.StopGracePeriod = &timeout
+		return err
+	}},
+	{name: "storage-opt", apply: func(r *runParser, v string) error {
+		key, value, _ := strings.Cut(v, "=")
+		if r.service.StorageOpt == nil {
+			r.service.StorageOpt = map[string]string{}
+		}
+		r.service.StorageOpt[key] = value
+		return nil
+	}},
+	{name: "sysctl", apply: func(r *runParser, v string) error {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value")
+		}
+		if r.service.Sysctls == nil {
+			r.service.Sysctls = types.Mapping{}
+		}
+		r.service.Sysctls[key] = value
+		return nil
+	}},
+	{name: "tmpfs", apply: func(r *runParser, v string) error {
+		r.service.Tmpfs = append(r.service.Tmpfs, v)
+		return nil
+	}},
+	{name: "tty", short: "t", boolean: true, apply: setBool(func(s *types.ServiceConfig) *bool { return &s.Tty })},
+	{name: "ulimit", apply: func(r *runParser, v string) error {
+		name, limits, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("expected name=soft[:hard]")
+		}
+		ulimit, err := parseUlimit(limits)
+		if err != nil {
+			return err
+		}
+		if r.service.Ulimits == nil {
+			r.service.Ulimits = map[string]*types.UlimitsConfig{}
+		}
+		r.service.Ulimits[name] = ulimit
+		return nil
+	}},
+	{name: "user", short: "u", apply: setString(func(s *types.ServiceConfig) *string { return &s.User })},
+	{name: "userns", apply: setString(func(s *types.ServiceConfig) *string { return &s.UserNSMode })},
+	{name: "uts", apply: setString(func(s *types.ServiceConfig) *string { return &s.Uts })},
+	{name: "volume", short: "v", apply: func(r *runParser, v string) error {
+		volume, err := format.ParseVolume(v)
+		r.service.Volumes = append(r.service.Volumes, volume)
+		return err
+	}},
+	{name: "volume-driver", apply: setString(func(s *types.ServiceConfig) *string { return &s.VolumeDriver })},
+	{name: "volumes-from", apply: appendString(func(s *types.ServiceConfig) *[]string { return &s.VolumesFrom })},
+	{name: "workdir", short: "w", apply: setString(func(s *types.ServiceConfig) *string { return &s.WorkingDir })},
+}
+
+// csvFields splits options set as a CSV record, as docker CLI does for --mount and --network
+func csvFields(value string) ([]string, error) {
+	fields, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid options %q: %w", value, err)
+	}
+	return fields, nil
+}
+
+// network sets network_mode for builtin modes, or attaches service to a user-defined network.
+// Advanced syntax `name=front,alias=www,ip=...` sets network attachment options
+func (r *runParser) network(value string) error {
+	name := value
+	var config *types.ServiceNetworkConfig
+	if strings.Contains(value, "=") {
+		name = ""
+		config = &types.ServiceNetworkConfig{}
+		fields, err := csvFields(value)
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			key, val, _ := strings.Cut(field, "=")
+			switch key {
+			case "name":
+				name = val
+			case "alias":
+				config.Aliases = append(config.Aliases, val)
+			case "ip":
+				config.Ipv4Address = val
+			case "ip6":
+				config.Ipv6Address = val
+			case "mac-address":
+				config.MacAddress = val
+			case "link-local-ip":
+				config.LinkLocalIPs = append(config.LinkLocalIPs, val)
+			case "driver-opt":
+				k, v, _ := strings.Cut(val, "=")
+				if config.DriverOpts == nil {
+					config.DriverOpts = types.Options{}
+				}
+				config.DriverOpts[k] = v
+			default:
+				return fmt.Errorf("unexpected key %q", key)
+			}
+		}
+	}
+	switch {
+	case name == "":
+		return fmt.Errorf("network name is required")
+	case name == "host" || name == "none" || name == "bridge" || name == "default",
+		strings.HasPrefix(name, types.NetworkModeContainerPrefix):
+		r.service.NetworkMode = name
+		return nil
+	}
+	if r.service.Networks == nil {
+		r.service.Networks = map[string]*types.ServiceNetworkConfig{}
+	}
+	r.service.Networks[name] = config
+	return nil
+}
+
+// parseUlimit parses `soft[:hard]`, a single value setting both limits
+func parseUlimit(value string) (*types.UlimitsConfig, error) {
+	soft, hard, ok := strings.Cut(value, ":")
+	s, err := strconv.Atoi(soft)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &types.UlimitsConfig{Single: s}, nil
+	}
+	h, err := strconv.Atoi(hard)
+	if err != nil {
+		return nil, err
+	}
+	return &types.UlimitsConfig{Soft: s, Hard: h}, nil
+}
+
+// parseMount parses the comma separated key=value syntax used by --mount
+func parseMount(value string) (types.ServiceVolumeConfig, error) {
+	volume := types.ServiceVolumeConfig{Type: types.VolumeTypeVolume}
+	fields, err := csvFields(value)
+	if err != nil {
+		return volume, err
+	}
+	for _, field := range fields {
+		key, val, hasValue := strings.Cut(field, "=")
+		key = strings.ToLower(key)
+		switch key {
+		case "type":
+			volume.Type = val
+		case "source", "src":
+			volume.Source = val
+		case "target", "dst", "destination":
+			volume.Target = val
+		case "readonly", "ro":
+			ro := true
+			if hasValue {
+				var err error
+				if ro, err = strconv.ParseBool(val); err != nil {
+					return volume, err
+				}
+			}
+			volume.ReadOnly = ro
+		case "consistency":
+			volume.Consistency = val
+		case "bind-propagation":
+			volume.Bind = bindOptions(volume.Bind)
+			volume.Bind.Propagation = val
+		case "bind-recursive":
+			volume.Bind = bindOptions(volume.Bind)
+			volume.Bind.Recursive = val
+		case "volume-nocopy":
+			volume.Volume = volumeOptions(volume.Volume)
+			volume.Volume.NoCopy = !hasValue || val == "true" || val == "1"
+		case "volume-subpath":
+			volume.Volume = volumeOptions(volume.Volume)
+			volume.Volume.Subpath = val
+		case "tmpfs-size":
+			size, err := units.RAMInBytes(val)
+			if err != nil {
+				return volume, err
+			}
+			volume.Tmpfs = tmpfsOptions(volume.Tmpfs)
+			volume.Tmpfs.Size = types.UnitBytes(size)
+		case "tmpfs-mode":
+			mode, err := strconv.ParseUint(val, 8, 32)
+			if err != nil {
+				return volume, err
+			}
+			volume.Tmpfs = tmpfsOptions(volume.Tmpfs)
+			volume.Tmpfs.Mode = uint32(mode)
+		default:
+			return volume, fmt.Errorf("unexpected key %q", key)
+		}
+	}
+	if volume.Target == "" {
+		return volume, fmt.Errorf("target is required")
+	}
+	return volume, nil
+}
+
+func bindOptions(b *types.ServiceVolumeBind) *types.ServiceVolumeBind {
+	if b == nil {
+		return &types.ServiceVolumeBind{}
+	}
+	return b
+}
+
+func volumeOptions(v *types.ServiceVolumeVolume) *types.ServiceVolumeVolume {
+	if v == nil {
+		return &types.ServiceVolumeVolume{}
+	}
+	return v
+}
+
+func tmpfsOptions(t *types.ServiceVolumeTmpfs) *types.ServiceVolumeTmpfs {
+	if t == nil {
+		return &types.ServiceVolumeTmpfs{}
+	}
+	return t
+}
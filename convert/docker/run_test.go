@@ -0,0 +1,139 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestParseRun(t *testing.T) {
+	service, warnings, err := ParseRunCommandLine(`docker run -d --rm -it --name web -p 8080:80 -p127.0.0.1:53:53/udp ` +
+		`-v /srv/html:/usr/share/nginx/html:ro --mount type=tmpfs,target=/cache,tmpfs-size=64m ` +
+		`-e MODE=prod -e HOME --env-file=web.env -l com.example.tier=front --network front --network-alias www ` +
+		`--restart unless-stopped --health-cmd "curl -f http://localhost" --health-interval 10s --health-retries 3 ` +
+		`--ulimit nofile=20000:40000 --ulimit nproc=65535 --add-host somehost:162.242.195.82 ` +
+		`--cpus 1.5 -m 256m --entrypoint /entrypoint.sh nginx:1.25 nginx -g "daemon off;"`)
+	assert.NilError(t, err)
+
+	retries := uint64(3)
+	interval := types.Duration(10 * time.Second)
+	mode := "prod"
+	assert.DeepEqual(t, *service, types.ServiceConfig{
+		Name:          "web",
+		ContainerName: "web",
+		Image:         "nginx:1.25",
+		Entrypoint:    types.ShellCommand{"/entrypoint.sh"},
+		Command:       types.ShellCommand{"nginx", "-g", "daemon off;"},
+		StdinOpen:     true,
+		Tty:           true,
+		Ports: []types.ServicePortConfig{
+			{Mode: "ingress", Target: 80, Published: "8080", Protocol: "tcp"},
+			{Mode: "ingress", HostIP: "127.0.0.1", Target: 53, Published: "53", Protocol: "udp"},
+		},
+		Volumes: []types.ServiceVolumeConfig{
+			{Type: types.VolumeTypeBind, Source: "/srv/html", Target: "/usr/share/nginx/html", ReadOnly: true, Bind: &types.ServiceVolumeBind{CreateHostPath: true}},
+			{Type: types.VolumeTypeTmpfs, Target: "/cache", Tmpfs: &types.ServiceVolumeTmpfs{Size: 64 * 1024 * 1024}},
+		},
+		Environment: types.MappingWithEquals{"MODE": &mode, "HOME": nil},
+		EnvFiles:    []types.EnvFile{{Path: "web.env", Required: true}},
+		Labels:      types.Labels{"com.example.tier": "front"},
+		Networks: map[string]*types.ServiceNetworkConfig{
+			"front": {Aliases: []string{"www"}},
+		},
+		Restart: types.RestartPolicyUnlessStopped,
+		HealthCheck: &types.HealthCheckConfig{
+			Test:     types.HealthCheckTest{"CMD-SHELL", "curl -f http://localhost"},
+			Interval: &interval,
+			Retries:  &retries,
+		},
+		Ulimits: map[string]*types.UlimitsConfig{
+			"nofile": {Soft: 20000, Hard: 40000},
+			"nproc":  {Single: 65535},
+		},
+		ExtraHosts: types.HostsList{"somehost": []string{"162.242.195.82"}},
+		CPUS:       1.5,
+		MemLimit:   256 * 1024 * 1024,
+	})
+
+	assert.Check(t, is.Len(warnings, 1))
+	assert.Equal(t, warnings[0].String(), "services.web: --rm has no compose equivalent and is ignored")
+}
+
+func TestParseRunErrors(t *testing.T) {
+	tests := []struct {
+		args  []string
+		error string
+	}{
+		{args: []string{"run", "--unknown", "nginx"}, error: "unknown flag: --unknown"},
+		{args: []string{"run", "-x", "nginx"}, error: `unknown shorthand flag: 'x' in -x`},
+		{args: []string{"run", "-p"}, error: "flag needs an argument: -p"},
+		{args: []string{"run", "-d"}, error: "docker run requires an image"},
+		{args: []string{"run", "--ulimit", "nofile", "nginx"}, error: `invalid argument "nofile" for --ulimit: expected name=soft[:hard]`},
+		{args: []string{"run", "--network-alias", "www", "nginx"}, error: "network aliases and static IP addresses require a user-defined network"},
+	}
+	for _, tt := range tests {
+		_, _, err := ParseRun(tt.args)
+		assert.Error(t, err, tt.error)
+	}
+}
+
+func TestParseRunName(t *testing.T) {
+	service, _, err := ParseRun([]string{"registry.example.com/team/api:1.0", "serve"})
+	assert.NilError(t, err)
+	assert.Equal(t, service.Name, "api")
+	assert.DeepEqual(t, service.Command, types.ShellCommand{"serve"})
+
+	service, _, err = ParseRun([]string{"--entrypoint=", "--network=host", "alpine"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, service.Entrypoint, types.ShellCommand{})
+	assert.Equal(t, service.NetworkMode, "host")
+}
+
+func TestParseRunNetworkOptions(t *testing.T) {
+	service, _, err := ParseRun([]string{"--network", "name=front,alias=web,alias=www,ip=10.0.0.2,driver-opt=com.example=1", "--network", "back", "nginx"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, service.Networks, map[string]*types.ServiceNetworkConfig{
+		"front": {
+			Aliases:     []string{"web", "www"},
+			Ipv4Address: "10.0.0.2",
+			DriverOpts:  types.Options{"com.example": "1"},
+		},
+		"back": nil,
+	})
+}
+
+func TestParseRunCPUCount(t *testing.T) {
+	service, _, err := ParseRun([]string{"--cpu-count", "2", "--cpu-percent", "50", "nginx"})
+	assert.NilError(t, err)
+	assert.Equal(t, service.CPUCount, int64(2))
+	assert.Equal(t, service.CPUPercent, float32(50))
+}
+
+func TestParseRunMountCSV(t *testing.T) {
+	service, _, err := ParseRun([]string{"--mount", `type=bind,"source=/srv/a,b",target=/data`, "nginx"})
+	assert.NilError(t, err)
+	assert.Equal(t, service.Volumes[0].Source, "/srv/a,b")
+	assert.Equal(t, service.Volumes[0].Target, "/data")
+
+	_, _, err = ParseRun([]string{"--mount", `type=bind,"source=/srv`, "nginx"})
+	assert.ErrorContains(t, err, "invalid options")
+}
@@ -0,0 +1,244 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package engine
+
+// Subset of the Docker Engine API payloads produced by the converter, declared locally so we don't depend on docker modules.
+// see https://docs.docker.com/engine/api/latest/
+
+// ContainerCreateRequest is the payload for `POST /containers/create`
+type ContainerCreateRequest struct {
+	// Name is passed as query parameter
+	Name string `json:"-"`
+	ContainerConfig
+	HostConfig       *HostConfig       `json:"HostConfig,omitempty"`
+	NetworkingConfig *NetworkingConfig `json:"NetworkingConfig,omitempty"`
+}
+
+type ContainerConfig struct {
+	Hostname     string              `json:"Hostname,omitempty"`
+	Domainname   string              `json:"Domainname,omitempty"`
+	User         string              `json:"User,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Tty          bool                `json:"Tty,omitempty"`
+	OpenStdin    bool                `json:"OpenStdin,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Cmd          []string            `json:"Cmd"`
+	Healthcheck  *HealthConfig       `json:"Healthcheck,omitempty"`
+	Image        string              `json:"Image"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint"`
+	MacAddress   string              `json:"MacAddress,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	StopSignal   string              `json:"StopSignal,omitempty"`
+	StopTimeout  *int                `json:"StopTimeout,omitempty"`
+}
+
+// HealthConfig durations are expressed in nanoseconds
+type HealthConfig struct {
+	Test          []string `json:"Test,omitempty"`
+	Interval      int64    `json:"Interval,omitempty"`
+	Timeout       int64    `json:"Timeout,omitempty"`
+	StartPeriod   int64    `json:"StartPeriod,omitempty"`
+	StartInterval int64    `json:"StartInterval,omitempty"`
+	Retries       int      `json:"Retries,omitempty"`
+}
+
+type HostConfig struct {
+	Binds          []string                 `json:"Binds,omitempty"`
+	LogConfig      *LogConfig               `json:"LogConfig,omitempty"`
+	NetworkMode    string                   `json:"NetworkMode,omitempty"`
+	PortBindings   map[string][]PortBinding `json:"PortBindings,omitempty"`
+	RestartPolicy  RestartPolicy            `json:"RestartPolicy"`
+	VolumeDriver   string                   `json:"VolumeDriver,omitempty"`
+	VolumesFrom    []string                 `json:"VolumesFrom,omitempty"`
+	Mounts         []Mount                  `json:"Mounts,omitempty"`
+	Annotations    map[string]string        `json:"Annotations,omitempty"`
+	CapAdd         []string                 `json:"CapAdd,omitempty"`
+	CapDrop        []string                 `json:"CapDrop,omitempty"`
+	CgroupnsMode   string                   `json:"CgroupnsMode,omitempty"`
+	DNS            []string                 `json:"Dns,omitempty"`
+	DNSOptions     []string                 `json:"DnsOptions,omitempty"`
+	DNSSearch      []string                 `json:"DnsSearch,omitempty"`
+	ExtraHosts     []string                 `json:"ExtraHosts,omitempty"`
+	GroupAdd       []string                 `json:"GroupAdd,omitempty"`
+	IpcMode        string                   `json:"IpcMode,omitempty"`
+	Links          []string                 `json:"Links,omitempty"`
+	OomScoreAdj    int64                    `json:"OomScoreAdj,omitempty"`
+	PidMode        string                   `json:"PidMode,omitempty"`
+	Privileged     bool                     `json:"Privileged,omitempty"`
+	ReadonlyRootfs bool                     `json:"ReadonlyRootfs,omitempty"`
+	SecurityOpt    []string                 `json:"SecurityOpt,omitempty"`
+	StorageOpt     map[string]string        `json:"StorageOpt,omitempty"`
+	Tmpfs          map[string]string        `json:"Tmpfs,omitempty"`
+	UTSMode        string                   `json:"UTSMode,omitempty"`
+	UsernsMode     string                   `json:"UsernsMode,omitempty"`
+	ShmSize        int64                    `json:"ShmSize,omitempty"`
+	Sysctls        map[string]string        `json:"Sysctls,omitempty"`
+	Runtime        string                   `json:"Runtime,omitempty"`
+	Isolation      string                   `json:"Isolation,omitempty"`
+	Init           *bool                    `json:"Init,omitempty"`
+	Resources
+}
+
+type Resources struct {
+	CPUShares            int64            `json:"CpuShares,omitempty"`
+	Memory               int64            `json:"Memory,omitempty"`
+	NanoCPUs             int64            `json:"NanoCpus,omitempty"`
+	CgroupParent         string           `json:"CgroupParent,omitempty"`
+	BlkioWeight          uint16           `json:"BlkioWeight,omitempty"`
+	BlkioWeightDevice    []WeightDevice   `json:"BlkioWeightDevice,omitempty"`
+	BlkioDeviceReadBps   []ThrottleDevice `json:"BlkioDeviceReadBps,omitempty"`
+	BlkioDeviceWriteBps  []ThrottleDevice `json:"BlkioDeviceWriteBps,omitempty"`
+	BlkioDeviceReadIOps  []ThrottleDevice `json:"BlkioDeviceReadIOps,omitempty"`
+	BlkioDeviceWriteIOps []ThrottleDevice `json:"BlkioDeviceWriteIOps,omitempty"`
+	CPUPeriod            int64            `json:"CpuPeriod,omitempty"`
+	CPUQuota             int64            `json:"CpuQuota,omitempty"`
+	CPURealtimePeriod    int64            `json:"CpuRealtimePeriod,omitempty"`
+	CPURealtimeRuntime   int64            `json:"CpuRealtimeRuntime,omitempty"`
+	CpusetCpus           string           `json:"CpusetCpus,omitempty"`
+	Devices              []DeviceMapping  `json:"Devices,omitempty"`
+	DeviceCgroupRules    []string         `json:"DeviceCgroupRules,omitempty"`
+	DeviceRequests       []DeviceRequest  `json:"DeviceRequests,omitempty"`
+	MemoryReservation    int64            `json:"MemoryReservation,omitempty"`
+	MemorySwap           int64            `json:"MemorySwap,omitempty"`
+	MemorySwappiness     *int64           `json:"MemorySwappiness,omitempty"`
+	OomKillDisable       *bool            `json:"OomKillDisable,omitempty"`
+	PidsLimit            *int64           `json:"PidsLimit,omitempty"`
+	Ulimits              []Ulimit         `json:"Ulimits,omitempty"`
+	CPUCount             int64            `json:"CpuCount,omitempty"`
+	CPUPercent           int64            `json:"CpuPercent,omitempty"`
+}
+
+type LogConfig struct {
+	Type   string            `json:"Type"`
+	Config map[string]string `json:"Config,omitempty"`
+}
+
+type PortBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+type RestartPolicy struct {
+	Name              string `json:"Name"`
+	MaximumRetryCount int    `json:"MaximumRetryCount,omitempty"`
+}
+
+type Mount struct {
+	Type          string         `json:"Type"`
+	Source        string         `json:"Source,omitempty"`
+	Target        string         `json:"Target"`
+	ReadOnly      bool           `json:"ReadOnly,omitempty"`
+	Consistency   string         `json:"Consistency,omitempty"`
+	BindOptions   *BindOptions   `json:"BindOptions,omitempty"`
+	VolumeOptions *VolumeOptions `json:"VolumeOptions,omitempty"`
+	TmpfsOptions  *TmpfsOptions  `json:"TmpfsOptions,omitempty"`
+}
+
+type BindOptions struct {
+	Propagation      string `json:"Propagation,omitempty"`
+	CreateMountpoint bool   `json:"CreateMountpoint,omitempty"`
+}
+
+type VolumeOptions struct {
+	NoCopy  bool   `json:"NoCopy,omitempty"`
+	Subpath string `json:"Subpath,omitempty"`
+}
+
+type TmpfsOptions struct {
+	SizeBytes int64  `json:"SizeBytes,omitempty"`
+	Mode      uint32 `json:"Mode,omitempty"`
+}
+
+type WeightDevice struct {
+	Path   string `json:"Path"`
+	Weight uint16 `json:"Weight"`
+}
+
+type ThrottleDevice struct {
+	Path string `json:"Path"`
+	Rate uint64 `json:"Rate"`
+}
+
+type DeviceMapping struct {
+	PathOnHost        string `json:"PathOnHost"`
+	PathInContainer   string `json:"PathInContainer"`
+	CgroupPermissions string `json:"CgroupPermissions"`
+}
+
+type DeviceRequest struct {
+	Driver       string            `json:"Driver,omitempty"`
+	Count        int               `json:"Count,omitempty"`
+	DeviceIDs    []string          `json:"DeviceIDs,omitempty"`
+	Capabilities [][]string        `json:"Capabilities,omitempty"`
+	Options      map[string]string `json:"Options,omitempty"`
+}
+
+type Ulimit struct {
+	Name string `json:"Name"`
+	Soft int64  `json:"Soft"`
+	Hard int64  `json:"Hard"`
+}
+
+type NetworkingConfig struct {
+	EndpointsConfig map[string]*EndpointSettings `json:"EndpointsConfig"`
+}
+
+type EndpointSettings struct {
+	IPAMConfig *EndpointIPAMConfig `json:"IPAMConfig,omitempty"`
+	Aliases    []string            `json:"Aliases,omitempty"`
+	MacAddress string              `json:"MacAddress,omitempty"`
+	DriverOpts map[string]string   `json:"DriverOpts,omitempty"`
+}
+
+type EndpointIPAMConfig struct {
+	IPv4Address  string   `json:"IPv4Address,omitempty"`
+	IPv6Address  string   `json:"IPv6Address,omitempty"`
+	LinkLocalIPs []string `json:"LinkLocalIPs,omitempty"`
+}
+
+// NetworkCreateRequest is the payload for `POST /networks/create`
+type NetworkCreateRequest struct {
+	Name       string            `json:"Name"`
+	Driver     string            `json:"Driver,omitempty"`
+	Internal   bool              `json:"Internal,omitempty"`
+	Attachable bool              `json:"Attachable,omitempty"`
+	EnableIPv6 *bool             `json:"EnableIPv6,omitempty"`
+	IPAM       *IPAM             `json:"IPAM,omitempty"`
+	Options    map[string]string `json:"Options,omitempty"`
+	Labels     map[string]string `json:"Labels,omitempty"`
+}
+
+type IPAM struct {
+	Driver string       `json:"Driver,omitempty"`
+	Config []IPAMConfig `json:"Config,omitempty"`
+}
+
+type IPAMConfig struct {
+	Subnet     string            `json:"Subnet,omitempty"`
+	IPRange    string            `json:"IPRange,omitempty"`
+	Gateway    string            `json:"Gateway,omitempty"`
+	AuxAddress map[string]string `json:"AuxiliaryAddresses,omitempty"`
+}
+
+// VolumeCreateRequest is the payload for `POST /volumes/create`
+type VolumeCreateRequest struct {
+	Name       string            `json:"Name"`
+	Driver     string            `json:"Driver,omitempty"`
+	DriverOpts map[string]string `json:"DriverOpts,omitempty"`
+	Labels     map[string]string `json:"Labels,omitempty"`
+}
@@ -0,0 +1,690 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package engine
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/convert"
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"golang.org/x/exp/slices"
+)
+
+// Labels set by docker compose to track resources it manages
+const (
+	LabelProject      = "com.docker.compose.project"
+	LabelService      = "com.docker.compose.service"
+	LabelNumber       = "com.docker.compose.container-number"
+	LabelOneOff       = "com.docker.compose.oneoff"
+	LabelWorkingDir   = "com.docker.compose.project.working_dir"
+	LabelConfigFiles  = "com.docker.compose.project.config_files"
+	LabelDependencies = "com.docker.compose.depends_on"
+	LabelNetwork      = "com.docker.compose.network"
+	LabelVolume       = "com.docker.compose.volume"
+)
+
+// Separator is used to build container names from project, service and container number
+const Separator = "-"
+
+// Result of converting a compose project, resources being listed in the order they have to be created
+type Result struct {
+	Networks   []NetworkCreateRequest
+	Volumes    []VolumeCreateRequest
+	Containers []ContainerCreateRequest
+	Warnings   convert.Warnings
+}
+
+// ContainerName returns the name of container number for service, starting at 1
+func ContainerName(project *types.Project, service types.ServiceConfig, number int) string {
+	if service.ContainerName != "" {
+		return service.ContainerName
+	}
+	return strings.Join([]string{project.Name, service.Name, strconv.Itoa(number)}, Separator)
+}
+
+// NanoCPUs converts a number of CPUs, as set by `cpus` or `deploy.resources.limits.cpus`, into engine NanoCpus.
+// Conversion relies on the shortest decimal representation of the float32 value, so that 0.1 gives 100000000
+// and not 100000001 as a plain float conversion would.
+func NanoCPUs(cpus float32) int64 {
+	f, err := strconv.ParseFloat(strconv.FormatFloat(float64(cpus), 'f', -1, 32), 64)
+	if err != nil {
+		f = float64(cpus)
+	}
+	return int64(math.Round(f * 1e9))
+}
+
+// MemorySwappiness converts `mem_swappiness` into engine MemorySwappiness.
+// As 0 can't be distinguished from an unset value, it returns nil so engine applies its default (-1, inherited from host)
+func MemorySwappiness(swappiness types.UnitBytes) *int64 {
+	if swappiness == 0 {
+		return nil
+	}
+	v := int64(swappiness)
+	return &v
+}
+
+type converter struct {
+	project  *types.Project
+	warnings convert.Warnings
+}
+
+// Convert produces Engine API payloads to create networks, volumes and containers for a compose project.
+// External resources are not created, and services are scaled according to ServiceConfig.GetScale.
+func Convert(project *types.Project) (*Result, error) {
+	c := converter{project: project}
+	result := &Result{}
+	for _, name := range sortedKeys(project.Networks) {
+		if n := project.Networks[name]; !n.External {
+			result.Networks = append(result.Networks, c.network(name, n))
+		}
+	}
+	for _, name := range sortedKeys(project.Volumes) {
+		if v := project.Volumes[name]; !v.External {
+			result.Volumes = append(result.Volumes, c.volume(name, v))
+		}
+	}
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		scale := service.GetScale()
+		if scale > 1 && service.ContainerName != "" {
+			return nil, fmt.Errorf("services.%s: container_name %q can't be used with scale %d: %w", name, service.ContainerName, scale, errdefs.ErrInvalid)
+		}
+		for number := 1; number <= scale; number++ {
+			result.Containers = append(result.Containers, c.container(service, number))
+		}
+	}
+	result.Warnings = c.warnings
+	return result, nil
+}
+
+func (c *converter) labels(labels ...map[string]string) map[string]string {
+	l := map[string]string{
+		LabelProject: c.project.Name,
+	}
+	for _, m := range labels {
+		for k, v := range m {
+			l[k] = v
+		}
+	}
+	return l
+}
+
+func (c *converter) network(name string, network types.NetworkConfig) NetworkCreateRequest {
+	req := NetworkCreateRequest{
+		Name:       network.Name,
+		Driver:     network.Driver,
+		Internal:   network.Internal,
+		Attachable: network.Attachable,
+		EnableIPv6: network.EnableIPv6,
+		Options:    network.DriverOpts,
+		Labels:     c.labels(network.Labels, network.CustomLabels, map[string]string{LabelNetwork: name}),
+	}
+	if network.Ipam.Driver != "" || len(network.Ipam.Config) > 0 {
+		req.IPAM = &IPAM{Driver: network.Ipam.Driver}
+		for _, pool := range network.Ipam.Config {
+			req.IPAM.Config = append(req.IPAM.Config, IPAMConfig{
+				Subnet:     pool.Subnet,
+				IPRange:    pool.IPRange,
+				Gateway:    pool.Gateway,
+				AuxAddress: pool.AuxiliaryAddresses,
+			})
+		}
+	}
+	return req
+}
+
+func (c *converter) volume(name string, volume types.VolumeConfig) VolumeCreateRequest {
+	return VolumeCreateRequest{
+		Name:       volume.Name,
+		Driver:     volume.Driver,
+		DriverOpts: volume.DriverOpts,
+		Labels:     c.labels(volume.Labels, volume.CustomLabels, map[string]string{LabelVolume: name}),
+	}
+}
+
+func (c *converter) container(service types.ServiceConfig, number int) ContainerCreateRequest {
+	image := service.Image
+	if image == "" {
+		image = c.project.Name + Separator + service.Name
+	}
+	config := ContainerConfig{
+		Hostname:     service.Hostname,
+		Domainname:   service.DomainName,
+		User:         service.User,
+		ExposedPorts: c.exposedPorts(service),
+		Tty:          service.Tty,
+		OpenStdin:    service.StdinOpen,
+		Env:          environment(service.Environment),
+		Cmd:          service.Command,
+		Healthcheck:  healthcheck(service.HealthCheck),
+		Image:        image,
+		WorkingDir:   service.WorkingDir,
+		Entrypoint:   service.Entrypoint,
+		MacAddress:   service.MacAddress,
+		Labels:       c.containerLabels(service, number),
+		StopSignal:   service.StopSignal,
+	}
+	if service.StopGracePeriod != nil {
+		timeout := int(time.Duration(*service.StopGracePeriod).Seconds())
+		config.StopTimeout = &timeout
+	}
+
+	host := &HostConfig{
+		LogConfig:      logConfig(service),
+		PortBindings:   portBindings(service.Ports),
+		RestartPolicy:  restartPolicy(service),
+		VolumeDriver:   service.VolumeDriver,
+		VolumesFrom:    c.volumesFrom(service),
+		Annotations:    service.Annotations,
+		CapAdd:         service.CapAdd,
+		CapDrop:        service.CapDrop,
+		CgroupnsMode:   service.Cgroup,
+		DNS:            service.DNS,
+		DNSOptions:     service.DNSOpts,
+		DNSSearch:      service.DNSSearch,
+		ExtraHosts:     service.ExtraHosts.AsList(":"),
+		GroupAdd:       service.GroupAdd,
+		IpcMode:        service.Ipc,
+		Links:          c.links(service),
+		OomScoreAdj:    service.OomScoreAdj,
+		PidMode:        service.Pid,
+		Privileged:     service.Privileged,
+		ReadonlyRootfs: service.ReadOnly,
+		SecurityOpt:    service.SecurityOpt,
+		StorageOpt:     service.StorageOpt,
+		Tmpfs:          tmpfs(service.Tmpfs),
+		UTSMode:        service.Uts,
+		UsernsMode:     service.UserNSMode,
+		ShmSize:        int64(service.ShmSize),
+		Sysctls:        service.Sysctls,
+		Runtime:        service.Runtime,
+		Isolation:      service.Isolation,
+		Init:           service.Init,
+		Resources:      resources(service),
+	}
+	host.Binds, host.Mounts = c.mounts(service)
+
+	req := ContainerCreateRequest{
+		Name:            ContainerName(c.project, service, number),
+		ContainerConfig: config,
+		HostConfig:      host,
+	}
+	host.NetworkMode, req.NetworkingConfig = c.networking(service)
+	return req
+}
+
+func (c *converter) containerLabels(service types.ServiceConfig, number int) map[string]string {
+	labels := c.labels(service.Labels, service.CustomLabels, map[string]string{
+		LabelService:    service.Name,
+		LabelNumber:     strconv.Itoa(number),
+		LabelOneOff:     "False",
+		LabelWorkingDir: c.project.WorkingDir,
+	})
+	if len(c.project.ComposeFiles) > 0 {
+		labels[LabelConfigFiles] = strings.Join(c.project.ComposeFiles, ",")
+	}
+	var dependencies []string
+	for _, name := range sortedKeys(service.DependsOn) {
+		d := service.DependsOn[name]
+		dependencies = append(dependencies, fmt.Sprintf("%s:%s:%t", name, d.Condition, d.Restart))
+	}
+	if len(dependencies) > 0 {
+		labels[LabelDependencies] = strings.Join(dependencies, ",")
+	}
+	return labels
+}
+
+func environment(env types.MappingWithEquals) []string {
+	var list []string
+	for _, k := range sortedKeys(env) {
+		if v := env[k]; v != nil {
+			list = append(list, k+"="+*v)
+		}
+	}
+	return list
+}
+
+func healthcheck(h *types.HealthCheckConfig) *HealthConfig {
+	if h == nil {
+		return nil
+	}
+	if h.Disable {
+		return &HealthConfig{Test: []string{"NONE"}}
+	}
+	config := &HealthConfig{
+		Test:          h.Test,
+		Interval:      nanoseconds(h.Interval),
+		Timeout:       nanoseconds(h.Timeout),
+		StartPeriod:   nanoseconds(h.StartPeriod),
+		StartInterval: nanoseconds(h.StartInterval),
+	}
+	if h.Retries != nil {
+		config.Retries = int(*h.Retries)
+	}
+	return config
+}
+
+func nanoseconds(d *types.Duration) int64 {
+	if d == nil {
+		return 0
+	}
+	return int64(*d)
+}
+
+func logConfig(service types.ServiceConfig) *LogConfig {
+	if service.Logging != nil {
+		return &LogConfig{Type: service.Logging.Driver, Config: service.Logging.Options}
+	}
+	if service.LogDriver != "" {
+		return &LogConfig{Type: service.LogDriver, Config: service.LogOpt}
+	}
+	return nil
+}
+
+// portKey formats a port as used by ExposedPorts and PortBindings, like `80/tcp`
+func portKey(port string, protocol string) string {
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	return port + "/" + protocol
+}
+
+func (c *converter) exposedPorts(service types.ServiceConfig) map[string]struct{} {
+	ports := map[string]struct{}{}
+	for _, p := range service.Ports {
+		ports[portKey(strconv.FormatUint(uint64(p.Target), 10), p.Protocol)] = struct{}{}
+	}
+	for i, e := range service.Expose {
+		port, protocol, _ := strings.Cut(e, "/")
+		start, end, isRange := strings.Cut(port, "-")
+		first, err := strconv.Atoi(start)
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(end)
+		}
+		if err != nil {
+			c.warnings.Add(tree.NewPath("services", service.Name, "expose", strconv.Itoa(i)), "invalid port %q", e)
+			continue
+		}
+		for p := first; p <= last; p++ {
+			ports[portKey(strconv.Itoa(p), protocol)] = struct{}{}
+		}
+	}
+	if len(ports) == 0 {
+		return nil
+	}
+	return ports
+}
+
+func portBindings(ports []types.ServicePortConfig) map[string][]PortBinding {
+	if len(ports) == 0 {
+		return nil
+	}
+	bindings := map[string][]PortBinding{}
+	for _, p := range ports {
+		key := portKey(strconv.FormatUint(uint64(p.Target), 10), p.Protocol)
+		bindings[key] = append(bindings[key], PortBinding{HostIP: p.HostIP, HostPort: p.Published})
+	}
+	return bindings
+}
+
+// restartPolicy maps restart, or deploy.restart_policy if not set
+func restartPolicy(service types.ServiceConfig) RestartPolicy {
+	if service.Restart == "" && service.Deploy != nil && service.Deploy.RestartPolicy != nil {
+		policy := service.Deploy.RestartPolicy
+		var attempts int
+		if policy.MaxAttempts != nil {
+			attempts = int(*policy.MaxAttempts)
+		}
+		switch policy.Condition {
+		case "none":
+			return RestartPolicy{Name: types.RestartPolicyNo}
+		case "on-failure":
+			return RestartPolicy{Name: types.RestartPolicyOnFailure, MaximumRetryCount: attempts}
+		default:
+			return RestartPolicy{Name: types.RestartPolicyAlways}
+		}
+	}
+	name, retries, _ := strings.Cut(service.Restart, ":")
+	if name == "" {
+		name = types.RestartPolicyNo
+	}
+	count, _ := strconv.Atoi(retries)
+	return RestartPolicy{Name: name, MaximumRetryCount: count}
+}
+
+// resources maps service resources, deploy.resources taking precedence over service level attributes
+func resources(service types.ServiceConfig) Resources {
+	r := Resources{
+		CPUShares:          service.CPUShares,
+		Memory:             int64(service.MemLimit),
+		NanoCPUs:           NanoCPUs(service.CPUS),
+		CgroupParent:       service.CgroupParent,
+		CPUPeriod:          service.CPUPeriod,
+		CPUQuota:           service.CPUQuota,
+		CPURealtimePeriod:  service.CPURTPeriod,
+		CPURealtimeRuntime: service.CPURTRuntime,
+		CpusetCpus:         service.CPUSet,
+		Devices:            devices(service.Devices),
+		DeviceCgroupRules:  service.DeviceCgroupRules,
+		MemoryReservation:  int64(service.MemReservation),
+		MemorySwap:         int64(service.MemSwapLimit),
+		MemorySwappiness:   MemorySwappiness(service.MemSwappiness),
+		Ulimits:            ulimits(service.Ulimits),
+		CPUCount:           service.CPUCount,
+		CPUPercent:         int64(service.CPUPercent),
+	}
+	if service.OomKillDisable {
+		r.OomKillDisable = &service.OomKillDisable
+	}
+	pids := service.PidsLimit
+	if b := service.BlkioConfig; b != nil {
+		r.BlkioWeight = b.Weight
+		for _, d := range b.WeightDevice {
+			r.BlkioWeightDevice = append(r.BlkioWeightDevice, WeightDevice{Path: d.Path, Weight: d.Weight})
+		}
+		r.BlkioDeviceReadBps = throttle(b.DeviceReadBps)
+		r.BlkioDeviceWriteBps = throttle(b.DeviceWriteBps)
+		r.BlkioDeviceReadIOps = throttle(b.DeviceReadIOps)
+		r.BlkioDeviceWriteIOps = throttle(b.DeviceWriteIOps)
+	}
+	r.DeviceRequests = deviceRequests(service.Gpus, true)
+	if service.Deploy != nil {
+		if limits := service.Deploy.Resources.Limits; limits != nil {
+			if limits.NanoCPUs != 0 {
+				r.NanoCPUs = NanoCPUs(float32(limits.NanoCPUs))
+			}
+			if limits.MemoryBytes != 0 {
+				r.Memory = int64(limits.MemoryBytes)
+			}
+			if limits.Pids != 0 {
+				pids = limits.Pids
+			}
+		}
+		if reservations := service.Deploy.Resources.Reservations; reservations != nil {
+			if reservations.MemoryBytes != 0 {
+				r.MemoryReservation = int64(reservations.MemoryBytes)
+			}
+			r.DeviceRequests = append(r.DeviceRequests, deviceRequests(reservations.Devices, false)...)
+		}
+	}
+	if pids != 0 {
+		r.PidsLimit = &pids
+	}
+	return r
+}
+
+func throttle(devices []types.ThrottleDevice) []ThrottleDevice {
+	var list []ThrottleDevice
+	for _, d := range devices {
+		list = append(list, ThrottleDevice{Path: d.Path, Rate: uint64(d.Rate)})
+	}
+	return list
+}
+
+func devices(mappings []types.DeviceMapping) []DeviceMapping {
+	var list []DeviceMapping
+	for _, d := range mappings {
+		m := DeviceMapping{
+			PathOnHost:        d.Source,
+			PathInContainer:   d.Target,
+			CgroupPermissions: d.Permissions,
+		}
+		if m.PathInContainer == "" {
+			m.PathInContainer = m.PathOnHost
+		}
+		if m.CgroupPermissions == "" {
+			m.CgroupPermissions = "rwm"
+		}
+		list = append(list, m)
+	}
+	return list
+}
+
+// deviceRequests maps device reservations, gpu capability being implied for `gpus`
+func deviceRequests(requests []types.DeviceRequest, gpu bool) []DeviceRequest {
+	var list []DeviceRequest
+	for _, d := range requests {
+		capabilities := d.Capabilities
+		if gpu && !slices.Contains(capabilities, "gpu") {
+			capabilities = append(append([]string{}, capabilities...), "gpu")
+		}
+		r := DeviceRequest{
+			Driver:    d.Driver,
+			Count:     int(d.Count),
+			DeviceIDs: d.IDs,
+			Options:   d.Options,
+		}
+		if len(capabilities) > 0 {
+			r.Capabilities = [][]string{capabilities}
+		}
+		list = append(list, r)
+	}
+	return list
+}
+
+func ulimits(limits map[string]*types.UlimitsConfig) []Ulimit {
+	var list []Ulimit
+	for _, name := range sortedKeys(limits) {
+		l := limits[name]
+		u := Ulimit{Name: name, Soft: int64(l.Soft), Hard: int64(l.Hard)}
+		if l.Single != 0 {
+			u.Soft, u.Hard = int64(l.Single), int64(l.Single)
+		}
+		list = append(list, u)
+	}
+	return list
+}
+
+func tmpfs(list []string) map[string]string {
+	if len(list) == 0 {
+		return nil
+	}
+	m := map[string]string{}
+	for _, t := range list {
+		target, options, _ := strings.Cut(t, ":")
+		m[target] = options
+	}
+	return m
+}
+
+// serviceContainer returns the name of the first container for a service, or name if service doesn't exist in project
+func (c *converter) serviceContainer(name string) string {
+	if service, ok := c.project.Services[name]; ok {
+		return ContainerName(c.project, service, 1)
+	}
+	return name
+}
+
+func (c *converter) volumesFrom(service types.ServiceConfig) []string {
+	var list []string
+	for _, v := range service.VolumesFrom {
+		switch {
+		case strings.HasPrefix(v, types.ContainerPrefix):
+			list = append(list, strings.TrimPrefix(v, types.ContainerPrefix))
+		default:
+			name, mode, _ := strings.Cut(strings.TrimPrefix(v, types.ServicePrefix), ":")
+			from := c.serviceContainer(name)
+			if mode != "" {
+				from += ":" + mode
+			}
+			list = append(list, from)
+		}
+	}
+	return list
+}
+
+// links maps links to services as `container:alias`, external links being used as is
+func (c *converter) links(service types.ServiceConfig) []string {
+	var list []string
+	for _, l := range service.Links {
+		name, alias, ok := strings.Cut(l, ":")
+		if !ok {
+			alias = name
+		}
+		list = append(list, c.serviceContainer(name)+":"+alias)
+	}
+	return append(list, service.ExternalLinks...)
+}
+
+func (c *converter) mounts(service types.ServiceConfig) ([]string, []Mount) {
+	var (
+		binds  []string
+		mounts []Mount
+	)
+	for _, v := range service.Volumes {
+		m := Mount{
+			Type:        v.Type,
+			Source:      v.Source,
+			Target:      v.Target,
+			ReadOnly:    v.ReadOnly,
+			Consistency: v.Consistency,
+		}
+		switch v.Type {
+		case types.VolumeTypeVolume:
+			if config, ok := c.project.Volumes[v.Source]; ok {
+				m.Source = config.Name
+			}
+			if v.Volume != nil && (v.Volume.NoCopy || v.Volume.Subpath != "") {
+				m.VolumeOptions = &VolumeOptions{NoCopy: v.Volume.NoCopy, Subpath: v.Volume.Subpath}
+			}
+		case types.VolumeTypeBind:
+			if v.Bind != nil && v.Bind.SELinux != "" {
+				// Mount API doesn't support SELinux relabeling, which requires legacy Binds
+				binds = append(binds, bind(v))
+				continue
+			}
+			if v.Bind != nil && (v.Bind.Propagation != "" || v.Bind.CreateHostPath) {
+				m.BindOptions = &BindOptions{Propagation: v.Bind.Propagation, CreateMountpoint: v.Bind.CreateHostPath}
+			}
+		case types.VolumeTypeTmpfs:
+			if v.Tmpfs != nil {
+				m.TmpfsOptions = &TmpfsOptions{SizeBytes: int64(v.Tmpfs.Size), Mode: v.Tmpfs.Mode}
+			}
+		}
+		mounts = append(mounts, m)
+	}
+
+	for i, config := range service.Configs {
+		target := config.Target
+		if target == "" {
+			target = "/" + config.Source
+		}
+		if m, ok := c.fileMount(tree.NewPath("services", service.Name, "configs", strconv.Itoa(i)), types.FileObjectConfig(c.project.Configs[config.Source]), target); ok {
+			mounts = append(mounts, m)
+		}
+	}
+	for i, secret := range service.Secrets {
+		target := secret.Target
+		if target == "" {
+			target = secret.Source
+		}
+		if !path.IsAbs(target) {
+			target = path.Join("/run/secrets", target)
+		}
+		if m, ok := c.fileMount(tree.NewPath("services", service.Name, "secrets", strconv.Itoa(i)), types.FileObjectConfig(c.project.Secrets[secret.Source]), target); ok {
+			mounts = append(mounts, m)
+		}
+	}
+	return binds, mounts
+}
+
+// fileMount bind mounts a file based config or secret, others having to be copied into container once created
+func (c *converter) fileMount(p tree.Path, config types.FileObjectConfig, target string) (Mount, bool) {
+	if config.File == "" {
+		c.warnings.Add(p, "content must be copied into container after creation")
+		return Mount{}, false
+	}
+	return Mount{
+		Type:     types.VolumeTypeBind,
+		Source:   config.File,
+		Target:   target,
+		ReadOnly: true,
+	}, true
+}
+
+func bind(v types.ServiceVolumeConfig) string {
+	options := []string{"rw"}
+	if v.ReadOnly {
+		options[0] = "ro"
+	}
+	options = append(options, v.Bind.SELinux)
+	if v.Bind.Propagation != "" {
+		options = append(options, v.Bind.Propagation)
+	}
+	return v.Source + ":" + v.Target + ":" + strings.Join(options, ",")
+}
+
+// networking returns the network mode and endpoints to connect container to, using first network by priority as network mode
+func (c *converter) networking(service types.ServiceConfig) (string, *NetworkingConfig) {
+	switch mode := service.NetworkMode; {
+	case mode == "":
+	case strings.HasPrefix(mode, types.NetworkModeServicePrefix):
+		return types.NetworkModeContainerPrefix + c.serviceContainer(strings.TrimPrefix(mode, types.NetworkModeServicePrefix)), nil
+	default:
+		return mode, nil
+	}
+	names := service.NetworksByPriority()
+	if len(names) == 0 {
+		return "", nil
+	}
+	endpoints := map[string]*EndpointSettings{}
+	for _, name := range names {
+		network := name
+		if n, ok := c.project.Networks[name]; ok && n.Name != "" {
+			network = n.Name
+		}
+		endpoint := &EndpointSettings{
+			Aliases: []string{service.Name},
+		}
+		if config := service.Networks[name]; config != nil {
+			endpoint.Aliases = append(endpoint.Aliases, config.Aliases...)
+			endpoint.MacAddress = config.MacAddress
+			endpoint.DriverOpts = config.DriverOpts
+			if config.Ipv4Address != "" || config.Ipv6Address != "" || len(config.LinkLocalIPs) > 0 {
+				endpoint.IPAMConfig = &EndpointIPAMConfig{
+					IPv4Address:  config.Ipv4Address,
+					IPv6Address:  config.Ipv6Address,
+					LinkLocalIPs: config.LinkLocalIPs,
+				}
+			}
+		}
+		endpoints[network] = endpoint
+	}
+	mode := names[0]
+	if n, ok := c.project.Networks[mode]; ok && n.Name != "" {
+		mode = n.Name
+	}
+	return mode, &NetworkingConfig{EndpointsConfig: endpoints}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
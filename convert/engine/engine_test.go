@@ -0,0 +1,108 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func load(t *testing.T, file string) *types.Project {
+	t.Helper()
+	b, err := os.ReadFile(file)
+	assert.NilError(t, err)
+	p, err := loader.LoadWithContext(context.Background(), types.ConfigDetails{
+		WorkingDir: "/src",
+		ConfigFiles: []types.ConfigFile{
+			{Filename: "/src/compose.yaml", Content: b},
+		},
+		Environment: map[string]string{},
+	})
+	assert.NilError(t, err)
+	return p
+}
+
+// TestConvert compares conversion of testdata/*.yaml with golden files, run with `-update` to refresh them
+func TestConvert(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.yaml")
+	assert.NilError(t, err)
+	for _, file := range files {
+		name := filepath.Base(file[:len(file)-len(filepath.Ext(file))])
+		t.Run(name, func(t *testing.T) {
+			result, err := Convert(load(t, file))
+			assert.NilError(t, err)
+
+			// container name is not part of the payload, so containers are indexed by name
+			output := struct {
+				Networks   []NetworkCreateRequest
+				Volumes    []VolumeCreateRequest
+				Containers map[string]ContainerCreateRequest
+				Warnings   []string
+			}{
+				Networks:   result.Networks,
+				Volumes:    result.Volumes,
+				Containers: map[string]ContainerCreateRequest{},
+			}
+			for _, c := range result.Containers {
+				output.Containers[c.Name] = c
+			}
+			for _, w := range result.Warnings {
+				output.Warnings = append(output.Warnings, w.String())
+			}
+			b, err := json.MarshalIndent(output, "", "  ")
+			assert.NilError(t, err)
+			golden.Assert(t, string(b)+"\n", name+".golden.json")
+		})
+	}
+}
+
+func TestNanoCPUs(t *testing.T) {
+	assert.Equal(t, NanoCPUs(0), int64(0))
+	assert.Equal(t, NanoCPUs(0.1), int64(100000000))
+	assert.Equal(t, NanoCPUs(1.5), int64(1500000000))
+	assert.Equal(t, NanoCPUs(0.33), int64(330000000))
+}
+
+func TestMemorySwappiness(t *testing.T) {
+	assert.Check(t, MemorySwappiness(0) == nil)
+	assert.Equal(t, *MemorySwappiness(60), int64(60))
+}
+
+func TestContainerNameWithScale(t *testing.T) {
+	project := &types.Project{
+		Name: "demo",
+		Services: types.Services{
+			"web": {Name: "web", Image: "nginx", ContainerName: "web", Scale: intPtr(2)},
+		},
+	}
+	_, err := Convert(project)
+	assert.ErrorIs(t, err, errdefs.ErrInvalid)
+	assert.Equal(t, ContainerName(project, types.ServiceConfig{Name: "web"}, 3), "demo-web-3")
+}
+
+func intPtr(i int) *int {
+	return &i
+}
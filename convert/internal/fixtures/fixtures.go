@@ -14,40 +14,30 @@
    limitations under the License.
 */
 
-package loader
+// Package fixtures loads compose projects used by converters tests
+package fixtures
 
 import (
+	"context"
 	"testing"
 
+	"github.com/compose-spec/compose-go/v2/loader"
 	"github.com/compose-spec/compose-go/v2/types"
 	"gotest.tools/v3/assert"
 )
 
-func TestConvertWindowsVolumePath(t *testing.T) {
-	var testcases = []struct {
-		windowsPath           string
-		expectedConvertedPath string
-	}{
-		{
-			windowsPath:           "c:\\hello\\docker",
-			expectedConvertedPath: "/c/hello/docker",
+// Project loads a project named demo from yaml, with /src as working directory
+func Project(t *testing.T, yaml string, options ...func(*loader.Options)) *types.Project {
+	t.Helper()
+	p, err := loader.LoadWithContext(context.Background(), types.ConfigDetails{
+		WorkingDir: "/src",
+		ConfigFiles: []types.ConfigFile{
+			{Filename: "compose.yaml", Content: []byte(yaml)},
 		},
-		{
-			windowsPath:           "d:\\compose",
-			expectedConvertedPath: "/d/compose",
-		},
-		{
-			windowsPath:           "e:\\path with spaces\\compose",
-			expectedConvertedPath: "/e/path with spaces/compose",
-		},
-	}
-	for _, testcase := range testcases {
-		volume := types.ServiceVolumeConfig{
-			Type:   "bind",
-			Source: testcase.windowsPath,
-			Target: "/test",
-		}
-
-		assert.Equal(t, testcase.expectedConvertedPath, convertVolumePath(volume).Source)
-	}
+		Environment: map[string]string{},
+	}, append([]func(*loader.Options){func(o *loader.Options) {
+		o.SetProjectName("demo", true)
+	}}, options...)...)
+	assert.NilError(t, err)
+	return p
 }
@@ -0,0 +1,888 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/convert"
+	"github.com/compose-spec/compose-go/v2/transform"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/vfs"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// LabelService is set on workloads and pods to select containers for a compose service
+	LabelService = "app.kubernetes.io/name"
+	// LabelProject is set on all objects to identify the compose project
+	LabelProject = "app.kubernetes.io/part-of"
+	// AnnotationDependsOn lists the services a workload depends on
+	AnnotationDependsOn = "compose-spec.io/depends-on"
+
+	defaultStorageSize = "1Gi"
+	defaultWaitImage   = "busybox"
+)
+
+// Options customize conversion
+type Options struct {
+	// StorageSize is the capacity requested by PersistentVolumeClaims, default to 1Gi
+	StorageSize string
+	// StorageClassName is set on PersistentVolumeClaims if not empty
+	StorageClassName string
+	// InitContainers converts depends_on into initContainers waiting for dependencies to accept connections.
+	// By default, dependencies are only declared by AnnotationDependsOn
+	InitContainers bool
+	// WaitImage is the image used by initContainers, default to busybox
+	WaitImage string
+	// FS is the filesystem project was loaded from, used to read configs and secrets files.
+	// Default to host filesystem
+	FS vfs.FS
+}
+
+// Result of converting a compose project
+type Result struct {
+	Objects  []Object
+	Warnings convert.Warnings
+}
+
+// YAML renders objects as a multi-document YAML stream
+func (r *Result) YAML() ([]byte, error) {
+	var b bytes.Buffer
+	for i, o := range r.Objects {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		out, err := yaml.Marshal(o)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(out)
+	}
+	return b.Bytes(), nil
+}
+
+// supported lists the service attributes the converter knows how to map, at least partially
+var supported = []string{
+	"annotations", "cap_add", "cap_drop", "command", "configs", "cpus", "depends_on", "deploy",
+	"dns", "dns_opt", "dns_search", "entrypoint", "env_file", "environment", "expose", "extra_hosts",
+	"healthcheck", "hostname", "image", "labels", "mem_limit", "mem_reservation", "networks",
+	"ports", "privileged", "profiles", "pull_policy", "read_only", "restart", "runtime", "scale",
+	"secrets", "stdin_open", "stop_grace_period", "sysctls", "tmpfs", "tty", "user", "volumes",
+	"working_dir",
+}
+
+type converter struct {
+	project  *types.Project
+	options  Options
+	warnings convert.Warnings
+	// templated are volumes declared as volumeClaimTemplates by replicated StatefulSets
+	templated map[string]bool
+}
+
+// Convert produces Kubernetes objects for a compose project.
+// Attributes which can't be converted are reported as warnings.
+func Convert(project *types.Project, options Options) (*Result, error) {
+	if options.StorageSize == "" {
+		options.StorageSize = defaultStorageSize
+	}
+	if options.WaitImage == "" {
+		options.WaitImage = defaultWaitImage
+	}
+	c := converter{
+		project:   project,
+		options:   options,
+		templated: map[string]bool{},
+	}
+
+	var objects []Object
+	for _, name := range sortedKeys(project.Configs) {
+		cm, err := c.configMap(name, types.FileObjectConfig(project.Configs[name]))
+		if err != nil {
+			return nil, err
+		}
+		if cm != nil {
+			objects = append(objects, cm)
+		}
+	}
+	for _, name := range sortedKeys(project.Secrets) {
+		secret, err := c.secret(name, types.FileObjectConfig(project.Secrets[name]))
+		if err != nil {
+			return nil, err
+		}
+		if secret != nil {
+			objects = append(objects, secret)
+		}
+	}
+	// replicas of a StatefulSet get their own volume, so a shared claim is only created for other services
+	claimed := map[string]bool{}
+	for _, service := range project.Services {
+		replicated := c.stateful(service) && service.GetScale() > 1
+		for _, v := range service.Volumes {
+			if v.Type != types.VolumeTypeVolume || v.Source == "" {
+				continue
+			}
+			if config, ok := project.Volumes[v.Source]; ok && replicated && !bool(config.External) {
+				c.templated[v.Source] = true
+			} else {
+				claimed[v.Source] = true
+			}
+		}
+	}
+	for _, name := range sortedKeys(project.Volumes) {
+		if c.templated[name] && !claimed[name] {
+			continue
+		}
+		if pvc := c.persistentVolumeClaim(name, project.Volumes[name]); pvc != nil {
+			objects = append(objects, pvc)
+		}
+	}
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		if svc := c.service(service); svc != nil {
+			objects = append(objects, svc)
+		}
+	}
+	for _, name := range project.ServiceNames() {
+		workload, err := c.workload(project.Services[name])
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, workload)
+	}
+	return &Result{
+		Objects:  objects,
+		Warnings: c.warnings,
+	}, nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// objectName converts a compose resource name into a valid Kubernetes object name (RFC 1123 label)
+func objectName(name string) string {
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(name, "-")
+}
+
+func (c *converter) meta(name string, labels map[string]string) ObjectMeta {
+	l := map[string]string{
+		LabelProject: objectName(c.project.Name),
+	}
+	for k, v := range labels {
+		l[k] = v
+	}
+	return ObjectMeta{Name: objectName(name), Labels: l}
+}
+
+func (c *converter) selector(service string) map[string]string {
+	return map[string]string{
+		LabelService: objectName(service),
+		LabelProject: objectName(c.project.Name),
+	}
+}
+
+// content reads the content of a config or secret
+func (c *converter) content(kind string, name string, config types.FileObjectConfig) (string, bool, error) {
+	switch {
+	case bool(config.External):
+		return "", false, nil
+	case config.Content != "":
+		return config.Content, true, nil
+	case config.Environment != "":
+		return c.project.Environment[config.Environment], true, nil
+	case config.File != "":
+		b, err := vfs.Or(c.options.FS).ReadFile(config.File)
+		if err != nil {
+			return "", false, fmt.Errorf("%s %s: %w", kind, name, err)
+		}
+		return string(b), true, nil
+	}
+	c.warnings.Add(tree.NewPath(kind, name), "no content to convert")
+	return "", false, nil
+}
+
+func (c *converter) configMap(name string, config types.FileObjectConfig) (*ConfigMap, error) {
+	content, ok, err := c.content("configs", name, config)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &ConfigMap{
+		TypeMeta:   TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: c.meta(name, nil),
+		Data:       map[string]string{name: content},
+	}, nil
+}
+
+func (c *converter) secret(name string, config types.FileObjectConfig) (*Secret, error) {
+	content, ok, err := c.content("secrets", name, config)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &Secret{
+		TypeMeta:   TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: c.meta(name, nil),
+		Type:       "Opaque",
+		StringData: map[string]string{name: content},
+	}, nil
+}
+
+func (c *converter) persistentVolumeClaim(name string, volume types.VolumeConfig) *PersistentVolumeClaim {
+	if volume.External {
+		return nil
+	}
+	p := tree.NewPath("volumes", name)
+	if volume.Driver != "" && volume.Driver != "local" {
+		c.warnings.Add(p.Next("driver"), "volume drivers are not supported, use StorageClassName")
+	}
+	if len(volume.DriverOpts) > 0 {
+		c.warnings.Add(p.Next("driver_opts"), "not supported")
+	}
+	spec := PersistentVolumeClaimSpec{
+		AccessModes:      []string{"ReadWriteOnce"},
+		StorageClassName: c.options.StorageClassName,
+		Resources: ResourceRequirements{
+			Requests: map[string]string{"storage": c.options.StorageSize},
+		},
+	}
+	return &PersistentVolumeClaim{
+		TypeMeta:   TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+		ObjectMeta: c.meta(name, nil),
+		Spec:       spec,
+	}
+}
+
+// service creates a Kubernetes Service for ports declared by a compose service, nil if service has no port
+func (c *converter) service(service types.ServiceConfig) *Service {
+	var ports []ServicePort
+	seen := map[string]bool{}
+	add := func(port, target uint32, protocol string) {
+		protocol = strings.ToUpper(protocol)
+		if protocol == "" {
+			protocol = "TCP"
+		}
+		name := fmt.Sprintf("%d-%s", port, strings.ToLower(protocol))
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		ports = append(ports, ServicePort{Name: name, Protocol: protocol, Port: port, TargetPort: target})
+	}
+	for i, p := range service.Ports {
+		port := p.Target
+		if p.Published != "" {
+			start, end, err := transform.PublishedRange(p.Published)
+			switch {
+			case err != nil:
+				c.warnings.Add(tree.NewPath("services", service.Name, "ports", strconv.Itoa(i), "published"),
+					"invalid port %q, using target port", p.Published)
+			case start != end:
+				// a Service load-balances replicas behind a single port
+				c.warnings.Add(tree.NewPath("services", service.Name, "ports", strconv.Itoa(i), "published"),
+					"port range %q is not supported, using port %d", p.Published, start)
+				port = start
+			default:
+				port = start
+			}
+		}
+		if p.HostIP != "" {
+			c.warnings.Add(tree.NewPath("services", service.Name, "ports", strconv.Itoa(i), "host_ip"), "not supported")
+		}
+		add(port, p.Target, p.Protocol)
+	}
+	for i, e := range service.Expose {
+		port, protocol, _ := strings.Cut(e, "/")
+		target, err := strconv.ParseUint(port, 10, 32)
+		if err != nil {
+			c.warnings.Add(tree.NewPath("services", service.Name, "expose", strconv.Itoa(i)), "port range %q is not supported", e)
+			continue
+		}
+		add(uint32(target), uint32(target), protocol)
+	}
+
+	spec := ServiceSpec{Selector: c.selector(service.Name), Ports: ports}
+	if len(ports) == 0 {
+		if !c.stateful(service) {
+			return nil
+		}
+		// a StatefulSet requires a governing service
+		spec.ClusterIP = "None"
+	}
+	return &Service{
+		TypeMeta:   TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: c.meta(service.Name, nil),
+		Spec:       spec,
+	}
+}
+
+// stateful checks if a service uses named volumes, and as such should be deployed as a StatefulSet
+func (c *converter) stateful(service types.ServiceConfig) bool {
+	for _, v := range service.Volumes {
+		if v.Type == types.VolumeTypeVolume && v.Source != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *converter) workload(service types.ServiceConfig) (Object, error) {
+	p := tree.NewPath("services", service.Name)
+	c.warnings.Unsupported(service, supported...)
+
+	pod, err := c.podSpec(service)
+	if err != nil {
+		return nil, err
+	}
+	template := PodTemplateSpec{
+		ObjectMeta: ObjectMeta{
+			Labels:      c.selector(service.Name),
+			Annotations: c.annotations(service),
+		},
+		Spec: pod,
+	}
+
+	replicas := service.GetScale()
+	if service.Deploy != nil {
+		if service.Deploy.Mode == "global" {
+			c.warnings.Add(p.Next("deploy").Next("mode"), "global mode is not supported, use a DaemonSet")
+		}
+		for _, attribute := range convert.Attributes(service.Deploy) {
+			switch attribute {
+			case "mode", "replicas", "resources", "labels":
+			case "restart_policy":
+				c.restartPolicy(p.Next("deploy").Next(attribute), service.Deploy.RestartPolicy)
+			default:
+				c.warnings.Add(p.Next("deploy").Next(attribute), "not supported")
+			}
+		}
+	}
+
+	meta := c.meta(service.Name, c.selector(service.Name))
+	if deps := service.GetDependencies(); len(deps) > 0 {
+		sort.Strings(deps)
+		meta.Annotations = map[string]string{AnnotationDependsOn: strings.Join(deps, ",")}
+	}
+	if service.Deploy != nil && len(service.Deploy.Labels) > 0 {
+		if meta.Annotations == nil {
+			meta.Annotations = map[string]string{}
+		}
+		for k, v := range service.Deploy.Labels {
+			meta.Annotations[k] = v
+		}
+	}
+
+	if c.stateful(service) {
+		return &StatefulSet{
+			TypeMeta:   TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+			ObjectMeta: meta,
+			Spec: StatefulSetSpec{
+				Replicas:             &replicas,
+				ServiceName:          objectName(service.Name),
+				Selector:             LabelSelector{MatchLabels: c.selector(service.Name)},
+				Template:             template,
+				VolumeClaimTemplates: c.volumeClaimTemplates(service, &template.Spec),
+			},
+		}, nil
+	}
+	return &Deployment{
+		TypeMeta:   TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: meta,
+		Spec: DeploymentSpec{
+			Replicas: &replicas,
+			Selector: LabelSelector{MatchLabels: c.selector(service.Name)},
+			Template: template,
+		},
+	}, nil
+}
+
+// volumeClaimTemplates moves volumes of a replicated StatefulSet from shared claims to templates,
+// as a ReadWriteOnce claim can't be mounted by pods running on distinct nodes
+func (c *converter) volumeClaimTemplates(service types.ServiceConfig, pod *PodSpec) []PersistentVolumeClaim {
+	replicas := service.GetScale()
+	if replicas <= 1 {
+		return nil
+	}
+	var templates []PersistentVolumeClaim
+	for i, v := range service.Volumes {
+		if v.Type != types.VolumeTypeVolume || !c.templated[v.Source] {
+			continue
+		}
+		name := objectName(v.Source)
+		if slices.ContainsFunc(templates, func(t PersistentVolumeClaim) bool { return t.Name == name }) {
+			continue
+		}
+		c.warnings.Add(tree.NewPath("services", service.Name, "volumes", strconv.Itoa(i)),
+			"volume %s is not shared by the %d replicas, each one gets its own PersistentVolumeClaim", v.Source, replicas)
+		pod.Volumes = slices.DeleteFunc(pod.Volumes, func(v Volume) bool { return v.Name == name })
+		claim := c.persistentVolumeClaim(v.Source, c.project.Volumes[v.Source])
+		claim.TypeMeta = TypeMeta{}
+		templates = append(templates, *claim)
+	}
+	return templates
+}
+
+// restartPolicy checks deploy restart policy is compatible with pods, which are always restarted
+func (c *converter) restartPolicy(p tree.Path, policy *types.RestartPolicy) {
+	if policy == nil {
+		return
+	}
+	switch policy.Condition {
+	case "", "any":
+	default:
+		c.warnings.Add(p.Next("condition"), "%q is not supported, pods are always restarted", policy.Condition)
+	}
+	if policy.Delay != nil {
+		c.warnings.Add(p.Next("delay"), "not supported")
+	}
+	if policy.MaxAttempts != nil {
+		c.warnings.Add(p.Next("max_attempts"), "not supported")
+	}
+	if policy.Window != nil {
+		c.warnings.Add(p.Next("window"), "not supported")
+	}
+}
+
+// annotations converts compose labels and annotations into pod annotations, as label values are constrained by Kubernetes
+func (c *converter) annotations(service types.ServiceConfig) map[string]string {
+	if len(service.Labels) == 0 && len(service.Annotations) == 0 {
+		return nil
+	}
+	a := map[string]string{}
+	for k, v := range service.Labels {
+		a[k] = v
+	}
+	for k, v := range service.Annotations {
+		a[k] = v
+	}
+	return a
+}
+
+func (c *converter) podSpec(service types.ServiceConfig) (PodSpec, error) {
+	p := tree.NewPath("services", service.Name)
+	container, err := c.container(service)
+	if err != nil {
+		return PodSpec{}, err
+	}
+	pod := PodSpec{
+		Hostname:         service.Hostname,
+		RuntimeClassName: service.Runtime,
+		RestartPolicy:    "Always",
+	}
+
+	switch {
+	case service.Restart == "" || service.Restart == types.RestartPolicyAlways || service.Restart == types.RestartPolicyUnlessStopped:
+	default:
+		c.warnings.Add(p.Next("restart"), "%q is not supported, pods are always restarted", service.Restart)
+	}
+
+	if service.StopGracePeriod != nil {
+		seconds := int64(math.Ceil(time.Duration(*service.StopGracePeriod).Seconds()))
+		pod.TerminationGracePeriodSeconds = &seconds
+	}
+
+	for _, host := range sortedKeys(service.ExtraHosts) {
+		for _, ip := range service.ExtraHosts[host] {
+			pod.HostAliases = append(pod.HostAliases, HostAlias{IP: ip, Hostnames: []string{host}})
+		}
+	}
+
+	if len(service.DNS) > 0 || len(service.DNSSearch) > 0 || len(service.DNSOpts) > 0 {
+		dns := &PodDNSConfig{
+			Nameservers: service.DNS,
+			Searches:    service.DNSSearch,
+		}
+		for _, opt := range service.DNSOpts {
+			name, value, _ := strings.Cut(opt, ":")
+			dns.Options = append(dns.Options, PodDNSConfigOpts{Name: name, Value: value})
+		}
+		pod.DNSConfig = dns
+	}
+
+	if len(service.Sysctls) > 0 {
+		sc := &PodSecurityContext{}
+		for _, k := range sortedKeys(service.Sysctls) {
+			sc.Sysctls = append(sc.Sysctls, Sysctl{Name: k, Value: service.Sysctls[k]})
+		}
+		pod.SecurityContext = sc
+	}
+
+	for name := range service.Networks {
+		if name != "default" {
+			c.warnings.Add(p.Next("networks").Next(name), "Kubernetes uses a flat network, service is reachable by all pods")
+		}
+	}
+
+	volumes, mounts := c.volumes(service)
+	pod.Volumes = volumes
+	container.VolumeMounts = mounts
+
+	pod.InitContainers = c.initContainers(service)
+	pod.Containers = []Container{container}
+	return pod, nil
+}
+
+func (c *converter) container(service types.ServiceConfig) (Container, error) {
+	p := tree.NewPath("services", service.Name)
+	container := Container{
+		Name:       objectName(service.Name),
+		Image:      service.Image,
+		Command:    service.Entrypoint,
+		Args:       service.Command,
+		WorkingDir: service.WorkingDir,
+		Stdin:      service.StdinOpen,
+		TTY:        service.Tty,
+	}
+	if service.Image == "" {
+		c.warnings.Add(p.Next("build"), "image must be built and pushed to a registry, set image")
+	}
+
+	switch service.PullPolicy {
+	case types.PullPolicyAlways:
+		container.ImagePullPolicy = "Always"
+	case types.PullPolicyNever:
+		container.ImagePullPolicy = "Never"
+	case types.PullPolicyMissing, types.PullPolicyIfNotPresent:
+		container.ImagePullPolicy = "IfNotPresent"
+	case "":
+	default:
+		c.warnings.Add(p.Next("pull_policy"), "%q is not supported", service.PullPolicy)
+	}
+
+	for _, port := range service.Ports {
+		protocol := strings.ToUpper(port.Protocol)
+		if protocol == "TCP" {
+			protocol = ""
+		}
+		container.Ports = append(container.Ports, ContainerPort{ContainerPort: port.Target, Protocol: protocol})
+	}
+
+	for _, name := range sortedKeys(service.Environment) {
+		value := service.Environment[name]
+		if value == nil {
+			c.warnings.Add(p.Next("environment").Next(name), "variable is not set")
+			continue
+		}
+		container.Env = append(container.Env, EnvVar{Name: name, Value: *value})
+	}
+
+	container.Resources = c.resources(service)
+	container.SecurityContext = c.securityContext(service)
+
+	if probe := c.probe(service); probe != nil {
+		container.LivenessProbe = probe
+		readiness := *probe
+		container.ReadinessProbe = &readiness
+	}
+	return container, nil
+}
+
+func (c *converter) resources(service types.ServiceConfig) *ResourceRequirements {
+	p := tree.NewPath("services", service.Name, "deploy", "resources")
+	limits := map[string]string{}
+	requests := map[string]string{}
+	set := func(m map[string]string, r *types.Resource, p tree.Path) {
+		if r == nil {
+			return
+		}
+		if r.NanoCPUs != 0 {
+			m["cpu"] = formatCPUs(float64(r.NanoCPUs))
+		}
+		if r.MemoryBytes != 0 {
+			m["memory"] = strconv.FormatInt(int64(r.MemoryBytes), 10)
+		}
+		if r.Pids != 0 {
+			c.warnings.Add(p.Next("pids"), "not supported")
+		}
+		if len(r.Devices) > 0 {
+			c.warnings.Add(p.Next("devices"), "not supported")
+		}
+		if len(r.GenericResources) > 0 {
+			c.warnings.Add(p.Next("generic_resources"), "not supported")
+		}
+	}
+	if service.CPUS != 0 {
+		limits["cpu"] = formatCPUs(float64(service.CPUS))
+	}
+	if service.MemLimit != 0 {
+		limits["memory"] = strconv.FormatInt(int64(service.MemLimit), 10)
+	}
+	if service.MemReservation != 0 {
+		requests["memory"] = strconv.FormatInt(int64(service.MemReservation), 10)
+	}
+	if service.Deploy != nil {
+		set(limits, service.Deploy.Resources.Limits, p.Next("limits"))
+		set(requests, service.Deploy.Resources.Reservations, p.Next("reservations"))
+	}
+	if len(limits) == 0 && len(requests) == 0 {
+		return nil
+	}
+	r := &ResourceRequirements{}
+	if len(limits) > 0 {
+		r.Limits = limits
+	}
+	if len(requests) > 0 {
+		r.Requests = requests
+	}
+	return r
+}
+
+// formatCPUs renders a number of CPUs as a Kubernetes quantity, using millicores when not a whole number
+func formatCPUs(cpus float64) string {
+	if cpus == math.Trunc(cpus) {
+		return strconv.FormatFloat(cpus, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%dm", int64(math.Round(cpus*1000)))
+}
+
+func (c *converter) securityContext(service types.ServiceConfig) *SecurityContext {
+	sc := SecurityContext{}
+	set := false
+	if service.Privileged {
+		sc.Privileged = &service.Privileged
+		set = true
+	}
+	if service.ReadOnly {
+		sc.ReadOnlyRootFilesystem = &service.ReadOnly
+		set = true
+	}
+	if len(service.CapAdd) > 0 || len(service.CapDrop) > 0 {
+		sc.Capabilities = &Capabilities{Add: service.CapAdd, Drop: service.CapDrop}
+		set = true
+	}
+	if service.User != "" {
+		user, group, hasGroup := strings.Cut(service.User, ":")
+		uid, err := strconv.ParseInt(user, 10, 64)
+		if err != nil {
+			c.warnings.Add(tree.NewPath("services", service.Name, "user"), "only numeric user IDs are supported")
+		} else {
+			sc.RunAsUser = &uid
+			set = true
+		}
+		if hasGroup {
+			if gid, err := strconv.ParseInt(group, 10, 64); err == nil {
+				sc.RunAsGroup = &gid
+			}
+		}
+	}
+	if !set {
+		return nil
+	}
+	return &sc
+}
+
+func (c *converter) probe(service types.ServiceConfig) *Probe {
+	hc := service.HealthCheck
+	if hc == nil || hc.Disable || len(hc.Test) == 0 {
+		return nil
+	}
+	var command []string
+	switch hc.Test[0] {
+	case "NONE":
+		return nil
+	case "CMD":
+		command = hc.Test[1:]
+	case "CMD-SHELL":
+		command = append([]string{"/bin/sh", "-c"}, hc.Test[1:]...)
+	default:
+		command = hc.Test
+	}
+	probe := &Probe{
+		Exec:                &ExecAction{Command: command},
+		InitialDelaySeconds: seconds(hc.StartPeriod),
+		PeriodSeconds:       seconds(hc.Interval),
+		TimeoutSeconds:      seconds(hc.Timeout),
+	}
+	if hc.Retries != nil {
+		probe.FailureThreshold = *hc.Retries
+	}
+	if hc.StartInterval != nil {
+		c.warnings.Add(tree.NewPath("services", service.Name, "healthcheck", "start_interval"), "not supported")
+	}
+	return probe
+}
+
+func seconds(d *types.Duration) int64 {
+	if d == nil {
+		return 0
+	}
+	return int64(math.Ceil(time.Duration(*d).Seconds()))
+}
+
+func (c *converter) volumes(service types.ServiceConfig) ([]Volume, []VolumeMount) {
+	p := tree.NewPath("services", service.Name)
+	var (
+		volumes []Volume
+		mounts  []VolumeMount
+	)
+	for i, v := range service.Volumes {
+		name := fmt.Sprintf("%s-%d", v.Type, i)
+		volume := Volume{}
+		switch v.Type {
+		case types.VolumeTypeVolume:
+			if v.Source == "" {
+				volume.EmptyDir = &EmptyDirVolumeSource{}
+				break
+			}
+			claim := objectName(v.Source)
+			if config, ok := c.project.Volumes[v.Source]; ok && bool(config.External) {
+				claim = objectName(config.Name)
+			}
+			name = claim
+			volume.PersistentVolumeClaim = &PersistentVolumeClaimVolumeSource{ClaimName: claim}
+		case types.VolumeTypeBind:
+			c.warnings.Add(p.Next("volumes").Next(strconv.Itoa(i)), "bind mount converted into a hostPath volume, which is not portable across nodes")
+			volume.HostPath = &HostPathVolumeSource{Path: v.Source}
+		case types.VolumeTypeTmpfs:
+			volume.EmptyDir = &EmptyDirVolumeSource{Medium: "Memory"}
+			if v.Tmpfs != nil && v.Tmpfs.Size != 0 {
+				volume.EmptyDir.SizeLimit = strconv.FormatInt(int64(v.Tmpfs.Size), 10)
+			}
+		default:
+			c.warnings.Add(p.Next("volumes").Next(strconv.Itoa(i)), "volume type %q is not supported", v.Type)
+			continue
+		}
+		volume.Name = name
+		if !slices.ContainsFunc(volumes, func(v Volume) bool { return v.Name == name }) {
+			volumes = append(volumes, volume)
+		}
+		mounts = append(mounts, VolumeMount{Name: name, MountPath: v.Target, ReadOnly: v.ReadOnly})
+	}
+
+	for i, t := range service.Tmpfs {
+		target, _, _ := strings.Cut(t, ":")
+		name := fmt.Sprintf("tmpfs-%d", len(service.Volumes)+i)
+		volumes = append(volumes, Volume{Name: name, EmptyDir: &EmptyDirVolumeSource{Medium: "Memory"}})
+		mounts = append(mounts, VolumeMount{Name: name, MountPath: target})
+	}
+
+	for _, config := range service.Configs {
+		target := config.Target
+		if target == "" {
+			target = "/" + config.Source
+		}
+		name := "config-" + objectName(config.Source)
+		volumes = append(volumes, Volume{
+			Name: name,
+			ConfigMap: &ConfigMapVolumeSource{
+				Name:  objectName(c.fileObjectName("configs", config.Source)),
+				Items: []KeyToPath{{Key: config.Source, Path: path.Base(target), Mode: config.Mode}},
+			},
+		})
+		mounts = append(mounts, VolumeMount{Name: name, MountPath: target, SubPath: path.Base(target), ReadOnly: true})
+	}
+
+	for _, secret := range service.Secrets {
+		target := secret.Target
+		if target == "" {
+			target = secret.Source
+		}
+		if !path.IsAbs(target) {
+			target = path.Join("/run/secrets", target)
+		}
+		name := "secret-" + objectName(secret.Source)
+		volumes = append(volumes, Volume{
+			Name: name,
+			Secret: &SecretVolumeSource{
+				SecretName: objectName(c.fileObjectName("secrets", secret.Source)),
+				Items:      []KeyToPath{{Key: secret.Source, Path: path.Base(target), Mode: secret.Mode}},
+			},
+		})
+		mounts = append(mounts, VolumeMount{Name: name, MountPath: target, SubPath: path.Base(target), ReadOnly: true})
+	}
+	return volumes, mounts
+}
+
+// fileObjectName returns the name of the ConfigMap or Secret for a config or secret, which is the external name for external ones
+func (c *converter) fileObjectName(kind string, name string) string {
+	var config types.FileObjectConfig
+	switch kind {
+	case "configs":
+		config = types.FileObjectConfig(c.project.Configs[name])
+	case "secrets":
+		config = types.FileObjectConfig(c.project.Secrets[name])
+	}
+	if config.External && config.Name != "" {
+		return config.Name
+	}
+	return name
+}
+
+// initContainers waits for dependencies to accept connections on their first port, when Options.InitContainers is set
+func (c *converter) initContainers(service types.ServiceConfig) []Container {
+	if !c.options.InitContainers {
+		return nil
+	}
+	var containers []Container
+	for _, dep := range sortedKeys(service.DependsOn) {
+		p := tree.NewPath("services", service.Name, "depends_on", dep)
+		if service.DependsOn[dep].Condition == types.ServiceConditionCompletedSuccessfully {
+			c.warnings.Add(p, "condition %s is not supported", types.ServiceConditionCompletedSuccessfully)
+			continue
+		}
+		target, ok := c.project.Services[dep]
+		if !ok {
+			continue
+		}
+		port, ok := firstPort(target)
+		if !ok {
+			c.warnings.Add(p, "service has no port to wait for")
+			continue
+		}
+		host := objectName(dep)
+		containers = append(containers, Container{
+			Name:    "wait-for-" + host,
+			Image:   c.options.WaitImage,
+			Command: []string{"sh", "-c", fmt.Sprintf("until nc -z %s %d; do sleep 1; done", host, port)},
+		})
+	}
+	return containers
+}
+
+// firstPort returns the port exposed by the Kubernetes Service for a compose service
+func firstPort(service types.ServiceConfig) (uint32, bool) {
+	for _, p := range service.Ports {
+		if published, _, err := transform.PublishedRange(p.Published); err == nil {
+			return published, true
+		}
+		return p.Target, true
+	}
+	for _, e := range service.Expose {
+		port, _, _ := strings.Cut(e, "/")
+		if target, err := strconv.ParseUint(port, 10, 32); err == nil {
+			return uint32(target), true
+		}
+	}
+	return 0, false
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
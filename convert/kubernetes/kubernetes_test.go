@@ -0,0 +1,271 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/compose-spec/compose-go/v2/convert/internal/fixtures"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/vfs"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+const demo = `
+services:
+  web:
+    image: nginx
+    command: ["nginx", "-g", "daemon off;"]
+    ports:
+      - "8080-8081:80"
+    environment:
+      MODE: prod
+    depends_on:
+      db:
+        condition: service_healthy
+    configs:
+      - source: site
+        target: /etc/nginx/conf.d/site.conf
+    deploy:
+      replicas: 2
+      resources:
+        limits:
+          cpus: "0.5"
+          memory: 128M
+        reservations:
+          memory: 64M
+    devices:
+      - /dev/fuse:/dev/fuse
+  db:
+    image: postgres
+    expose:
+      - "5432"
+    volumes:
+      - data:/var/lib/postgresql/data
+    secrets:
+      - password
+    healthcheck:
+      test: ["CMD", "pg_isready"]
+      interval: 10s
+      timeout: 5s
+      retries: 3
+      start_period: 30s
+    restart: on-failure
+volumes:
+  data: {}
+configs:
+  site:
+    content: "server {}"
+secrets:
+  password:
+    environment: DB_PASSWORD
+`
+
+func TestConvert(t *testing.T) {
+	project := fixtures.Project(t, demo)
+	result, err := Convert(project, Options{InitContainers: true})
+	assert.NilError(t, err)
+
+	var kinds []string
+	for _, o := range result.Objects {
+		kinds = append(kinds, o.GetKind()+"/"+o.GetName())
+	}
+	assert.DeepEqual(t, kinds, []string{
+		"ConfigMap/site",
+		"Secret/password",
+		"PersistentVolumeClaim/data",
+		"Service/db",
+		"Service/web",
+		"StatefulSet/db",
+		"Deployment/web",
+	})
+
+	db := result.Objects[5].(*StatefulSet)
+	assert.Equal(t, db.Spec.ServiceName, "db")
+	container := db.Spec.Template.Spec.Containers[0]
+	assert.DeepEqual(t, container.LivenessProbe, &Probe{
+		Exec:                &ExecAction{Command: []string{"pg_isready"}},
+		InitialDelaySeconds: 30,
+		PeriodSeconds:       10,
+		TimeoutSeconds:      5,
+		FailureThreshold:    3,
+	})
+	assert.DeepEqual(t, container.VolumeMounts, []VolumeMount{
+		{Name: "data", MountPath: "/var/lib/postgresql/data"},
+		{Name: "secret-password", MountPath: "/run/secrets/password", SubPath: "password", ReadOnly: true},
+	})
+
+	web := result.Objects[6].(*Deployment)
+	assert.Equal(t, *web.Spec.Replicas, 2)
+	assert.Equal(t, web.Annotations[AnnotationDependsOn], "db")
+	pod := web.Spec.Template.Spec
+	assert.DeepEqual(t, pod.InitContainers[0].Command, []string{"sh", "-c", "until nc -z db 5432; do sleep 1; done"})
+	assert.DeepEqual(t, pod.Containers[0].Args, []string{"nginx", "-g", "daemon off;"})
+	assert.DeepEqual(t, pod.Containers[0].Resources, &ResourceRequirements{
+		Limits:   map[string]string{"cpu": "500m", "memory": "134217728"},
+		Requests: map[string]string{"memory": "67108864"},
+	})
+
+	svc := result.Objects[4].(*Service)
+	assert.DeepEqual(t, svc.Spec.Ports, []ServicePort{{Name: "8080-tcp", Protocol: "TCP", Port: 8080, TargetPort: 80}})
+
+	var warnings []string
+	for _, w := range result.Warnings {
+		warnings = append(warnings, w.String())
+	}
+	assert.DeepEqual(t, warnings, []string{
+		`services.web.ports.0.published: port range "8080-8081" is not supported, using port 8080`,
+		`services.db.restart: "on-failure" is not supported, pods are always restarted`,
+		"services.web.devices: not supported",
+	})
+}
+
+func TestConvertReplicatedStatefulSet(t *testing.T) {
+	project := fixtures.Project(t, `
+services:
+  db:
+    image: postgres
+    volumes:
+      - data:/var/lib/postgresql/data
+    deploy:
+      replicas: 3
+      restart_policy:
+        condition: on-failure
+        max_attempts: 3
+volumes:
+  data: {}
+`)
+	result, err := Convert(project, Options{})
+	assert.NilError(t, err)
+
+	var kinds []string
+	for _, o := range result.Objects {
+		kinds = append(kinds, o.GetKind()+"/"+o.GetName())
+	}
+	assert.DeepEqual(t, kinds, []string{"Service/db", "StatefulSet/db"})
+
+	db := result.Objects[1].(*StatefulSet)
+	assert.Equal(t, len(db.Spec.Template.Spec.Volumes), 0)
+	assert.Equal(t, len(db.Spec.VolumeClaimTemplates), 1)
+	assert.Equal(t, db.Spec.VolumeClaimTemplates[0].Name, "data")
+	assert.DeepEqual(t, db.Spec.VolumeClaimTemplates[0].Spec.AccessModes, []string{"ReadWriteOnce"})
+	assert.DeepEqual(t, db.Spec.Template.Spec.Containers[0].VolumeMounts, []VolumeMount{
+		{Name: "data", MountPath: "/var/lib/postgresql/data"},
+	})
+
+	var warnings []string
+	for _, w := range result.Warnings {
+		warnings = append(warnings, w.String())
+	}
+	assert.DeepEqual(t, warnings, []string{
+		`services.db.deploy.restart_policy.condition: "on-failure" is not supported, pods are always restarted`,
+		"services.db.deploy.restart_policy.max_attempts: not supported",
+		"services.db.volumes.0: volume data is not shared by the 3 replicas, each one gets its own PersistentVolumeClaim",
+	})
+}
+
+func TestConvertFromFS(t *testing.T) {
+	root := filepath.FromSlash("/srv/webapp")
+	fsys := vfs.FromFS(fstest.MapFS{
+		"compose.yaml": {Data: []byte(`
+services:
+  web:
+    image: nginx
+    configs: [site]
+configs:
+  site:
+    file: ./site.conf
+`)},
+		"site.conf": {Data: []byte("server {}")},
+	}, root)
+	project, err := loader.LoadWithContext(context.Background(), types.ConfigDetails{
+		WorkingDir:  root,
+		ConfigFiles: []types.ConfigFile{{Filename: filepath.Join(root, "compose.yaml")}},
+		Environment: map[string]string{},
+	}, func(options *loader.Options) {
+		options.SetProjectName("demo", true)
+		options.FS = fsys
+	})
+	assert.NilError(t, err)
+
+	result, err := Convert(project, Options{FS: fsys})
+	assert.NilError(t, err)
+	site := result.Objects[0].(*ConfigMap)
+	assert.DeepEqual(t, site.Data, map[string]string{"site": "server {}"})
+}
+
+func TestYAML(t *testing.T) {
+	project := fixtures.Project(t, `
+services:
+  app:
+    image: app
+    ports:
+      - "80"
+`)
+	result, err := Convert(project, Options{})
+	assert.NilError(t, err)
+	out, err := result.YAML()
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(out), `apiVersion: v1
+kind: Service
+metadata:
+    name: app
+    labels:
+        app.kubernetes.io/part-of: demo
+spec:
+    selector:
+        app.kubernetes.io/name: app
+        app.kubernetes.io/part-of: demo
+    ports:
+        - name: 80-tcp
+          protocol: TCP
+          port: 80
+          targetPort: 80
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    name: app
+    labels:
+        app.kubernetes.io/name: app
+        app.kubernetes.io/part-of: demo
+spec:
+    replicas: 1
+    selector:
+        matchLabels:
+            app.kubernetes.io/name: app
+            app.kubernetes.io/part-of: demo
+    template:
+        metadata:
+            name: ""
+            labels:
+                app.kubernetes.io/name: app
+                app.kubernetes.io/part-of: demo
+        spec:
+            containers:
+                - name: app
+                  image: app
+                  ports:
+                    - containerPort: 80
+            restartPolicy: Always
+`))
+}
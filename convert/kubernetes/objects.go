@@ -0,0 +1,268 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kubernetes
+
+// Subset of the Kubernetes API objects produced by the converter, declared locally so we don't depend on k8s.io modules.
+// see https://kubernetes.io/docs/reference/kubernetes-api/
+
+// Object is a Kubernetes API object
+type Object interface {
+	GetKind() string
+	GetName() string
+}
+
+type TypeMeta struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+}
+
+func (t TypeMeta) GetKind() string {
+	return t.Kind
+}
+
+type ObjectMeta struct {
+	Name        string            `yaml:"name"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+func (o ObjectMeta) GetName() string {
+	return o.Name
+}
+
+type LabelSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+type Deployment struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Spec       DeploymentSpec `yaml:"spec"`
+}
+
+type DeploymentSpec struct {
+	Replicas *int            `yaml:"replicas,omitempty"`
+	Selector LabelSelector   `yaml:"selector"`
+	Template PodTemplateSpec `yaml:"template"`
+}
+
+type StatefulSet struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Spec       StatefulSetSpec `yaml:"spec"`
+}
+
+type StatefulSetSpec struct {
+	Replicas             *int                    `yaml:"replicas,omitempty"`
+	ServiceName          string                  `yaml:"serviceName"`
+	Selector             LabelSelector           `yaml:"selector"`
+	Template             PodTemplateSpec         `yaml:"template"`
+	VolumeClaimTemplates []PersistentVolumeClaim `yaml:"volumeClaimTemplates,omitempty"`
+}
+
+type PodTemplateSpec struct {
+	ObjectMeta `yaml:"metadata"`
+	Spec       PodSpec `yaml:"spec"`
+}
+
+type PodSpec struct {
+	InitContainers                []Container         `yaml:"initContainers,omitempty"`
+	Containers                    []Container         `yaml:"containers"`
+	Volumes                       []Volume            `yaml:"volumes,omitempty"`
+	RestartPolicy                 string              `yaml:"restartPolicy,omitempty"`
+	Hostname                      string              `yaml:"hostname,omitempty"`
+	HostAliases                   []HostAlias         `yaml:"hostAliases,omitempty"`
+	DNSConfig                     *PodDNSConfig       `yaml:"dnsConfig,omitempty"`
+	SecurityContext               *PodSecurityContext `yaml:"securityContext,omitempty"`
+	TerminationGracePeriodSeconds *int64              `yaml:"terminationGracePeriodSeconds,omitempty"`
+	RuntimeClassName              string              `yaml:"runtimeClassName,omitempty"`
+}
+
+type HostAlias struct {
+	IP        string   `yaml:"ip"`
+	Hostnames []string `yaml:"hostnames"`
+}
+
+type PodDNSConfig struct {
+	Nameservers []string           `yaml:"nameservers,omitempty"`
+	Searches    []string           `yaml:"searches,omitempty"`
+	Options     []PodDNSConfigOpts `yaml:"options,omitempty"`
+}
+
+type PodDNSConfigOpts struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value,omitempty"`
+}
+
+type PodSecurityContext struct {
+	Sysctls []Sysctl `yaml:"sysctls,omitempty"`
+}
+
+type Sysctl struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type Container struct {
+	Name            string                `yaml:"name"`
+	Image           string                `yaml:"image"`
+	ImagePullPolicy string                `yaml:"imagePullPolicy,omitempty"`
+	Command         []string              `yaml:"command,omitempty"`
+	Args            []string              `yaml:"args,omitempty"`
+	WorkingDir      string                `yaml:"workingDir,omitempty"`
+	Ports           []ContainerPort       `yaml:"ports,omitempty"`
+	Env             []EnvVar              `yaml:"env,omitempty"`
+	Resources       *ResourceRequirements `yaml:"resources,omitempty"`
+	VolumeMounts    []VolumeMount         `yaml:"volumeMounts,omitempty"`
+	LivenessProbe   *Probe                `yaml:"livenessProbe,omitempty"`
+	ReadinessProbe  *Probe                `yaml:"readinessProbe,omitempty"`
+	SecurityContext *SecurityContext      `yaml:"securityContext,omitempty"`
+	Stdin           bool                  `yaml:"stdin,omitempty"`
+	TTY             bool                  `yaml:"tty,omitempty"`
+}
+
+type ContainerPort struct {
+	Name          string `yaml:"name,omitempty"`
+	ContainerPort uint32 `yaml:"containerPort"`
+	Protocol      string `yaml:"protocol,omitempty"`
+}
+
+type EnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type ResourceRequirements struct {
+	Limits   map[string]string `yaml:"limits,omitempty"`
+	Requests map[string]string `yaml:"requests,omitempty"`
+}
+
+type VolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	SubPath   string `yaml:"subPath,omitempty"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+type Probe struct {
+	Exec                *ExecAction `yaml:"exec"`
+	InitialDelaySeconds int64       `yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int64       `yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds      int64       `yaml:"timeoutSeconds,omitempty"`
+	FailureThreshold    uint64      `yaml:"failureThreshold,omitempty"`
+}
+
+type ExecAction struct {
+	Command []string `yaml:"command"`
+}
+
+type SecurityContext struct {
+	Privileged             *bool         `yaml:"privileged,omitempty"`
+	ReadOnlyRootFilesystem *bool         `yaml:"readOnlyRootFilesystem,omitempty"`
+	RunAsUser              *int64        `yaml:"runAsUser,omitempty"`
+	RunAsGroup             *int64        `yaml:"runAsGroup,omitempty"`
+	Capabilities           *Capabilities `yaml:"capabilities,omitempty"`
+}
+
+type Capabilities struct {
+	Add  []string `yaml:"add,omitempty"`
+	Drop []string `yaml:"drop,omitempty"`
+}
+
+type Volume struct {
+	Name                  string                             `yaml:"name"`
+	PersistentVolumeClaim *PersistentVolumeClaimVolumeSource `yaml:"persistentVolumeClaim,omitempty"`
+	HostPath              *HostPathVolumeSource              `yaml:"hostPath,omitempty"`
+	EmptyDir              *EmptyDirVolumeSource              `yaml:"emptyDir,omitempty"`
+	ConfigMap             *ConfigMapVolumeSource             `yaml:"configMap,omitempty"`
+	Secret                *SecretVolumeSource                `yaml:"secret,omitempty"`
+}
+
+type PersistentVolumeClaimVolumeSource struct {
+	ClaimName string `yaml:"claimName"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+type HostPathVolumeSource struct {
+	Path string `yaml:"path"`
+}
+
+type EmptyDirVolumeSource struct {
+	Medium    string `yaml:"medium,omitempty"`
+	SizeLimit string `yaml:"sizeLimit,omitempty"`
+}
+
+type KeyToPath struct {
+	Key  string  `yaml:"key"`
+	Path string  `yaml:"path"`
+	Mode *uint32 `yaml:"mode,omitempty"`
+}
+
+type ConfigMapVolumeSource struct {
+	Name  string      `yaml:"name"`
+	Items []KeyToPath `yaml:"items,omitempty"`
+}
+
+type SecretVolumeSource struct {
+	SecretName string      `yaml:"secretName"`
+	Items      []KeyToPath `yaml:"items,omitempty"`
+}
+
+type Service struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Spec       ServiceSpec `yaml:"spec"`
+}
+
+type ServiceSpec struct {
+	Type      string            `yaml:"type,omitempty"`
+	ClusterIP string            `yaml:"clusterIP,omitempty"`
+	Selector  map[string]string `yaml:"selector"`
+	Ports     []ServicePort     `yaml:"ports,omitempty"`
+}
+
+type ServicePort struct {
+	Name       string `yaml:"name"`
+	Protocol   string `yaml:"protocol,omitempty"`
+	Port       uint32 `yaml:"port"`
+	TargetPort uint32 `yaml:"targetPort"`
+}
+
+type PersistentVolumeClaim struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Spec       PersistentVolumeClaimSpec `yaml:"spec"`
+}
+
+type PersistentVolumeClaimSpec struct {
+	AccessModes      []string             `yaml:"accessModes"`
+	StorageClassName string               `yaml:"storageClassName,omitempty"`
+	Resources        ResourceRequirements `yaml:"resources"`
+}
+
+type ConfigMap struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Data       map[string]string `yaml:"data"`
+}
+
+type Secret struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	StringData map[string]string `yaml:"stringData"`
+}
@@ -0,0 +1,629 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package quadlet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/convert"
+	"github.com/compose-spec/compose-go/v2/dotenv"
+	"github.com/compose-spec/compose-go/v2/graph"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"golang.org/x/exp/slices"
+)
+
+const defaultWantedBy = "default.target"
+
+// Options customize conversion
+type Options struct {
+	// Pod groups all containers in a pod named after the project, which then owns ports and networks
+	Pod bool
+	// WantedBy is the target starting services with restart policy `always` or `unless-stopped`, default to default.target
+	WantedBy string
+}
+
+// Result of converting a compose project
+type Result struct {
+	// Units are networks, volumes, pod, then containers in dependency order
+	Units    []*Unit
+	Warnings convert.Warnings
+}
+
+// Write creates unit files in dir, typically ~/.config/containers/systemd
+func (r *Result) Write(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, u := range r.Units {
+		if err := os.WriteFile(filepath.Join(dir, u.Name), []byte(u.String()), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// supported lists the service attributes the converter knows how to map, at least partially
+var supported = []string{
+	"annotations", "cap_add", "cap_drop", "command", "configs", "container_name", "cpus", "depends_on",
+	"devices", "dns", "dns_opt", "dns_search", "entrypoint", "env_file", "environment", "expose",
+	"extra_hosts", "healthcheck", "hostname", "image", "init", "labels", "mem_limit", "network_mode",
+	"networks", "pids_limit", "ports", "privileged", "profiles", "pull_policy", "read_only", "restart",
+	"scale", "secrets", "stop_grace_period", "sysctls", "tmpfs", "ulimits", "user", "volumes", "working_dir",
+}
+
+type converter struct {
+	project  *types.Project
+	options  Options
+	warnings convert.Warnings
+	// waitedFor collects the conditions other services wait for, by service
+	waitedFor map[string][]string
+}
+
+// Convert produces Quadlet units for a compose project.
+// Attributes which can't be converted are reported as warnings.
+func Convert(ctx context.Context, project *types.Project, options Options) (*Result, error) {
+	if options.WantedBy == "" {
+		options.WantedBy = defaultWantedBy
+	}
+	c := converter{
+		project:   project,
+		options:   options,
+		waitedFor: map[string][]string{},
+	}
+	for _, service := range project.Services {
+		for dep, d := range service.DependsOn {
+			c.waitedFor[dep] = append(c.waitedFor[dep], d.Condition)
+		}
+	}
+
+	var units []*Unit
+	for _, name := range sortedKeys(project.Networks) {
+		if u := c.network(name, project.Networks[name]); u != nil {
+			units = append(units, u)
+		}
+	}
+	for _, name := range sortedKeys(project.Volumes) {
+		if u := c.volume(name, project.Volumes[name]); u != nil {
+			units = append(units, u)
+		}
+	}
+	if options.Pod {
+		units = append(units, c.pod())
+	}
+	// startup waves list services sorted by name, so units are collected in a stable dependency order
+	plan, err := graph.Plan(project)
+	if err != nil {
+		return nil, err
+	}
+	for _, wave := range plan.Waves {
+		for _, name := range wave {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			units = append(units, c.container(project.Services[name]))
+		}
+	}
+
+	sort.SliceStable(c.warnings, func(i, j int) bool {
+		return c.warnings[i].Path < c.warnings[j].Path
+	})
+	return &Result{
+		Units:    units,
+		Warnings: c.warnings,
+	}, nil
+}
+
+// unitName is the base name of units for a project resource, systemd services being named after it
+func (c *converter) unitName(name string) string {
+	return c.project.Name + "-" + name
+}
+
+func (c *converter) containerName(service types.ServiceConfig) string {
+	if service.ContainerName != "" {
+		return service.ContainerName
+	}
+	return c.unitName(service.Name)
+}
+
+func (c *converter) network(name string, network types.NetworkConfig) *Unit {
+	if network.External {
+		return nil
+	}
+	p := tree.NewPath("networks", name)
+	u := newUnit(c.unitName(name)+".network", "Network")
+	s := u.Section("Network")
+	s.add("NetworkName", network.Name)
+	if network.Driver != "" {
+		s.add("Driver", network.Driver)
+	}
+	for _, k := range sortedKeys(network.DriverOpts) {
+		s.add("Options", k+"="+network.DriverOpts[k])
+	}
+	if network.Internal {
+		s.add("Internal", "true")
+	}
+	if network.EnableIPv6 != nil && *network.EnableIPv6 {
+		s.add("IPv6", "true")
+	}
+	if network.Ipam.Driver != "" {
+		s.add("IPAMDriver", network.Ipam.Driver)
+	}
+	for i, pool := range network.Ipam.Config {
+		if pool.Subnet != "" {
+			s.add("Subnet", pool.Subnet)
+		}
+		if pool.Gateway != "" {
+			s.add("Gateway", pool.Gateway)
+		}
+		if pool.IPRange != "" {
+			s.add("IPRange", pool.IPRange)
+		}
+		if len(pool.AuxiliaryAddresses) > 0 {
+			c.warnings.Add(p.Next("ipam").Next("config").Next(strconv.Itoa(i)).Next("aux_addresses"), "not supported")
+		}
+	}
+	if network.Attachable {
+		c.warnings.Add(p.Next("attachable"), "not supported")
+	}
+	s.add("Label", labels(network.Labels)...)
+	return u
+}
+
+func (c *converter) volume(name string, volume types.VolumeConfig) *Unit {
+	if volume.External {
+		return nil
+	}
+	p := tree.NewPath("volumes", name)
+	u := newUnit(c.unitName(name)+".volume", "Volume")
+	s := u.Section("Volume")
+	s.add("VolumeName", volume.Name)
+	if volume.Driver != "" && volume.Driver != "local" {
+		s.add("Driver", volume.Driver)
+	}
+	for _, k := range sortedKeys(volume.DriverOpts) {
+		v := volume.DriverOpts[k]
+		switch k {
+		case "type":
+			s.add("Type", v)
+		case "device":
+			s.add("Device", v)
+		case "o":
+			s.add("Options", v)
+		default:
+			c.warnings.Add(p.Next("driver_opts").Next(k), "not supported")
+		}
+	}
+	s.add("Label", labels(volume.Labels)...)
+	return u
+}
+
+// pod groups all containers, declaring ports and networks for all services
+func (c *converter) pod() *Unit {
+	u := newUnit(c.project.Name+".pod", "Pod")
+	s := u.Section("Pod")
+	s.add("PodName", c.project.Name)
+	var networks []string
+	for _, name := range c.project.ServiceNames() {
+		service := c.project.Services[name]
+		s.add("PublishPort", c.ports(service)...)
+		for _, n := range c.networks(service) {
+			if !slices.Contains(networks, n) {
+				networks = append(networks, n)
+			}
+		}
+	}
+	s.add("Network", networks...)
+	return u
+}
+
+func (c *converter) container(service types.ServiceConfig) *Unit {
+	p := tree.NewPath("services", service.Name)
+	c.warnings.Unsupported(service, supported...)
+	if service.GetScale() > 1 {
+		c.warnings.Add(p.Next("scale"), "only a single container is created")
+	}
+
+	u := newUnit(c.unitName(service.Name)+".container", "Container")
+	c.dependencies(u.Section("Unit"), service)
+
+	s := u.Section("Container")
+	image := service.Image
+	if image == "" {
+		image = c.unitName(service.Name)
+		if service.Build != nil {
+			c.warnings.Add(p.Next("build"), "not supported, image %s must be built before starting service", image)
+		}
+	}
+	s.add("Image", image)
+	s.add("ContainerName", c.containerName(service))
+	if c.options.Pod {
+		s.add("Pod", c.project.Name+".pod")
+	}
+	if len(service.Entrypoint) > 0 {
+		s.add("Entrypoint", quoteAll(service.Entrypoint))
+	}
+	if len(service.Command) > 0 {
+		s.add("Exec", quoteAll(service.Command))
+	}
+	if service.WorkingDir != "" {
+		s.add("WorkingDir", service.WorkingDir)
+	}
+	if service.User != "" {
+		user, group, ok := strings.Cut(service.User, ":")
+		s.add("User", user)
+		if ok {
+			s.add("Group", group)
+		}
+	}
+	if service.Hostname != "" {
+		s.add("HostName", service.Hostname)
+	}
+
+	for _, k := range sortedKeys(service.Environment) {
+		if v := service.Environment[k]; v != nil {
+			s.add("Environment", quote(k+"="+*v))
+		}
+	}
+	for i, f := range service.EnvFiles {
+		if f.Format != "" || dotenv.FormatFromFilename(f.Path) != "" {
+			c.warnings.Add(p.Next("env_file").Next(strconv.Itoa(i)).Next("format"), "not supported")
+		}
+		if !f.Required {
+			c.warnings.Add(p.Next("env_file").Next(strconv.Itoa(i)).Next("required"), "podman requires env_file %s to exist", f.Path)
+		}
+		s.add("EnvironmentFile", f.Path)
+	}
+
+	if !c.options.Pod {
+		s.add("PublishPort", c.ports(service)...)
+		s.add("Network", c.networks(service)...)
+		for _, n := range service.NetworksByPriority() {
+			if config := service.Networks[n]; config != nil {
+				s.add("NetworkAlias", config.Aliases...)
+			}
+		}
+	} else if service.NetworkMode != "" {
+		c.warnings.Add(p.Next("network_mode"), "not supported when services are grouped in a pod")
+	}
+
+	c.mounts(s, service)
+	c.healthcheck(s, service)
+	if slices.Contains(c.waitedFor[service.Name], types.ServiceConditionHealthy) && service.HealthCheck != nil && !service.HealthCheck.Disable {
+		// systemd service is only considered started once container is healthy
+		s.add("Notify", "healthy")
+	}
+
+	s.add("Label", labels(service.Labels)...)
+	for _, k := range sortedKeys(service.Annotations) {
+		s.add("Annotation", quote(k+"="+service.Annotations[k]))
+	}
+	s.add("AddCapability", service.CapAdd...)
+	s.add("DropCapability", service.CapDrop...)
+	for _, d := range service.Devices {
+		device := d.Source
+		if d.Target != "" {
+			device += ":" + d.Target
+		}
+		if d.Permissions != "" {
+			device += ":" + d.Permissions
+		}
+		s.add("AddDevice", device)
+	}
+	s.add("DNS", service.DNS...)
+	s.add("DNSSearch", service.DNSSearch...)
+	s.add("DNSOption", service.DNSOpts...)
+	s.add("AddHost", service.ExtraHosts.AsList(":")...)
+	for _, k := range sortedKeys(service.Sysctls) {
+		s.add("Sysctl", k+"="+service.Sysctls[k])
+	}
+	for _, k := range sortedKeys(service.Ulimits) {
+		s.add("Ulimit", k+"="+ulimit(service.Ulimits[k]))
+	}
+	if service.ReadOnly {
+		s.add("ReadOnly", "true")
+	}
+	if service.Init != nil && *service.Init {
+		s.add("RunInit", "true")
+	}
+	if service.StopGracePeriod != nil {
+		s.add("StopTimeout", strconv.FormatInt(seconds(*service.StopGracePeriod), 10))
+	}
+	c.pullPolicy(s, service)
+
+	var args []string
+	if service.Privileged {
+		args = append(args, "--privileged")
+	}
+	if service.CPUS != 0 {
+		args = append(args, "--cpus="+strconv.FormatFloat(float64(service.CPUS), 'f', -1, 32))
+	}
+	if service.MemLimit != 0 {
+		args = append(args, "--memory="+strconv.FormatInt(int64(service.MemLimit), 10))
+	}
+	if service.PidsLimit != 0 {
+		args = append(args, "--pids-limit="+strconv.FormatInt(service.PidsLimit, 10))
+	}
+	if len(args) > 0 {
+		s.add("PodmanArgs", strings.Join(args, " "))
+	}
+
+	c.restart(u, service)
+	return u
+}
+
+// dependencies maps depends_on into systemd ordering and requirement dependencies
+func (c *converter) dependencies(s *Section, service types.ServiceConfig) {
+	s.add("Description", fmt.Sprintf("Service %s of compose project %s", service.Name, c.project.Name))
+	var after, requires, wants, partOf []string
+	for _, dep := range sortedKeys(service.DependsOn) {
+		d := service.DependsOn[dep]
+		unit := c.unitName(dep) + ".service"
+		after = append(after, unit)
+		if d.Required {
+			requires = append(requires, unit)
+		} else {
+			wants = append(wants, unit)
+		}
+		if d.Restart {
+			partOf = append(partOf, unit)
+		}
+		if d.Condition == types.ServiceConditionHealthy {
+			if target, ok := c.project.Services[dep]; ok && (target.HealthCheck == nil || target.HealthCheck.Disable) {
+				c.warnings.Add(tree.NewPath("services", service.Name, "depends_on", dep), "service %s has no healthcheck, condition is handled as service_started", dep)
+			}
+		}
+	}
+	s.add("Requires", requires...)
+	s.add("Wants", wants...)
+	s.add("After", after...)
+	s.add("PartOf", partOf...)
+}
+
+func (c *converter) ports(service types.ServiceConfig) []string {
+	var ports []string
+	for _, port := range service.Ports {
+		s := strconv.FormatUint(uint64(port.Target), 10)
+		switch {
+		case port.HostIP != "":
+			s = port.HostIP + ":" + port.Published + ":" + s
+		case port.Published != "":
+			s = port.Published + ":" + s
+		}
+		if port.Protocol != "" && port.Protocol != "tcp" {
+			s += "/" + port.Protocol
+		}
+		ports = append(ports, s)
+	}
+	return ports
+}
+
+// networks returns the value for Network= entries of a service
+func (c *converter) networks(service types.ServiceConfig) []string {
+	switch mode := service.NetworkMode; {
+	case mode == "":
+	case strings.HasPrefix(mode, types.NetworkModeServicePrefix):
+		dep := strings.TrimPrefix(mode, types.NetworkModeServicePrefix)
+		if target, ok := c.project.Services[dep]; ok {
+			return []string{types.NetworkModeContainerPrefix + c.containerName(target)}
+		}
+		return []string{types.NetworkModeContainerPrefix + c.unitName(dep)}
+	default:
+		return []string{mode}
+	}
+	var networks []string
+	for _, name := range service.NetworksByPriority() {
+		network := c.unitName(name) + ".network"
+		if config, ok := c.project.Networks[name]; ok && bool(config.External) {
+			network = config.Name
+		}
+		if config := service.Networks[name]; config != nil && config.Ipv4Address != "" {
+			network += ":ip=" + config.Ipv4Address
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func (c *converter) mounts(s *Section, service types.ServiceConfig) {
+	p := tree.NewPath("services", service.Name)
+	for i, v := range service.Volumes {
+		var options []string
+		if v.ReadOnly {
+			options = append(options, "ro")
+		}
+		source := v.Source
+		switch v.Type {
+		case types.VolumeTypeVolume:
+			if source == "" {
+				break
+			}
+			if config, ok := c.project.Volumes[source]; ok && bool(config.External) {
+				source = config.Name
+			} else {
+				source = c.unitName(source) + ".volume"
+			}
+		case types.VolumeTypeBind:
+			if v.Bind != nil && v.Bind.SELinux != "" {
+				options = append(options, v.Bind.SELinux)
+			}
+		case types.VolumeTypeTmpfs:
+			s.add("Tmpfs", v.Target)
+			continue
+		default:
+			c.warnings.Add(p.Next("volumes").Next(strconv.Itoa(i)), "volume type %q is not supported", v.Type)
+			continue
+		}
+		volume := v.Target
+		if source != "" {
+			volume = source + ":" + volume
+		}
+		if len(options) > 0 {
+			volume += ":" + strings.Join(options, ",")
+		}
+		s.add("Volume", volume)
+	}
+	s.add("Tmpfs", service.Tmpfs...)
+
+	for i, config := range service.Configs {
+		target := config.Target
+		if target == "" {
+			target = "/" + config.Source
+		}
+		file := c.project.Configs[config.Source].File
+		if file == "" {
+			c.warnings.Add(p.Next("configs").Next(strconv.Itoa(i)), "only file based configs are supported")
+			continue
+		}
+		s.add("Volume", file+":"+target+":ro")
+	}
+
+	for _, secret := range service.Secrets {
+		config := c.project.Secrets[secret.Source]
+		name := config.Name
+		if name == "" {
+			name = secret.Source
+		}
+		if !config.External {
+			c.warnings.Add(tree.NewPath("secrets", secret.Source), "podman secret %s must be created before starting services", name)
+		}
+		target := secret.Target
+		if target == "" {
+			target = secret.Source
+		}
+		value := name + ",type=mount,target=" + target
+		if secret.UID != "" {
+			value += ",uid=" + secret.UID
+		}
+		if secret.GID != "" {
+			value += ",gid=" + secret.GID
+		}
+		if secret.Mode != nil {
+			value += fmt.Sprintf(",mode=%#o", *secret.Mode)
+		}
+		s.add("Secret", value)
+	}
+}
+
+func (c *converter) healthcheck(s *Section, service types.ServiceConfig) {
+	h := service.HealthCheck
+	if h == nil {
+		return
+	}
+	if h.Disable || (len(h.Test) > 0 && h.Test[0] == "NONE") {
+		s.add("HealthCmd", "none")
+		return
+	}
+	if len(h.Test) > 0 {
+		switch h.Test[0] {
+		case "CMD":
+			b, _ := json.Marshal([]string(h.Test[1:]))
+			s.add("HealthCmd", string(b))
+		case "CMD-SHELL":
+			s.add("HealthCmd", strings.Join(h.Test[1:], " "))
+		default:
+			s.add("HealthCmd", strings.Join(h.Test, " "))
+		}
+	}
+	if h.Interval != nil {
+		s.add("HealthInterval", h.Interval.String())
+	}
+	if h.Timeout != nil {
+		s.add("HealthTimeout", h.Timeout.String())
+	}
+	if h.Retries != nil {
+		s.add("HealthRetries", strconv.FormatUint(*h.Retries, 10))
+	}
+	if h.StartPeriod != nil {
+		s.add("HealthStartPeriod", h.StartPeriod.String())
+	}
+	if h.StartInterval != nil {
+		c.warnings.Add(tree.NewPath("services", service.Name, "healthcheck", "start_interval"), "not supported")
+	}
+}
+
+func (c *converter) pullPolicy(s *Section, service types.ServiceConfig) {
+	switch service.PullPolicy {
+	case "":
+	case types.PullPolicyIfNotPresent:
+		s.add("Pull", types.PullPolicyMissing)
+	case types.PullPolicyAlways, types.PullPolicyMissing, types.PullPolicyNever:
+		s.add("Pull", service.PullPolicy)
+	default:
+		c.warnings.Add(tree.NewPath("services", service.Name, "pull_policy"), "%q is not supported", service.PullPolicy)
+	}
+}
+
+// restart maps restart policy into systemd service restart.
+// Only services restarted by docker engine on reboot are installed, and services other wait to complete run once.
+func (c *converter) restart(u *Unit, service types.ServiceConfig) {
+	s := u.Section("Service")
+	if slices.Contains(c.waitedFor[service.Name], types.ServiceConditionCompletedSuccessfully) {
+		s.add("Type", "oneshot")
+		s.add("RemainAfterExit", "yes")
+	}
+	policy, retries, _ := strings.Cut(service.Restart, ":")
+	switch policy {
+	case "", types.RestartPolicyNo:
+	case types.RestartPolicyAlways, types.RestartPolicyUnlessStopped:
+		s.add("Restart", "always")
+		u.Section("Install").add("WantedBy", c.options.WantedBy)
+	case types.RestartPolicyOnFailure:
+		s.add("Restart", "on-failure")
+		if retries != "" {
+			u.Section("Unit").add("StartLimitBurst", retries)
+		}
+	default:
+		c.warnings.Add(tree.NewPath("services", service.Name, "restart"), "%q is not supported", service.Restart)
+	}
+}
+
+func labels(l types.Labels) []string {
+	var values []string
+	for _, k := range sortedKeys(l) {
+		values = append(values, quote(k+"="+l[k]))
+	}
+	return values
+}
+
+func ulimit(u *types.UlimitsConfig) string {
+	if u.Single != 0 {
+		return strconv.Itoa(u.Single)
+	}
+	return fmt.Sprintf("%d:%d", u.Soft, u.Hard)
+}
+
+func seconds(d types.Duration) int64 {
+	return int64(time.Duration(d).Seconds())
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
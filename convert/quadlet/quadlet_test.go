@@ -0,0 +1,260 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package quadlet
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/convert/internal/fixtures"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+const demo = `
+services:
+  web:
+    image: nginx
+    command: ["nginx", "-g", "daemon off;"]
+    ports:
+      - "8080:80"
+      - "127.0.0.1:5353:53/udp"
+    environment:
+      MODE: prod
+      GREETING: hello world
+    env_file: web.env
+    labels:
+      com.example.tier: front
+    depends_on:
+      db:
+        condition: service_healthy
+        restart: true
+      migrate:
+        condition: service_completed_successfully
+    volumes:
+      - ./html:/usr/share/nginx/html:ro
+    restart: unless-stopped
+    devices:
+      - /dev/fuse
+    shm_size: 64m
+  migrate:
+    image: migrate
+    depends_on:
+      - db
+  db:
+    image: postgres
+    user: "999:999"
+    volumes:
+      - data:/var/lib/postgresql/data
+    secrets:
+      - password
+    healthcheck:
+      test: ["CMD", "pg_isready"]
+      interval: 10s
+      timeout: 5s
+      retries: 3
+      start_period: 30s
+    restart: "on-failure:3"
+volumes:
+  data:
+    labels:
+      backup: "true"
+secrets:
+  password:
+    file: ./password.txt
+`
+
+func TestConvert(t *testing.T) {
+	project := fixtures.Project(t, demo, func(options *loader.Options) {
+		// env files are referenced by units, not read
+		options.SkipResolveEnvironment = true
+	})
+	result, err := Convert(context.Background(), project, Options{})
+	assert.NilError(t, err)
+
+	var names []string
+	for _, u := range result.Units {
+		names = append(names, u.Name)
+	}
+	assert.DeepEqual(t, names, []string{
+		"demo-default.network",
+		"demo-data.volume",
+		"demo-db.container",
+		"demo-migrate.container",
+		"demo-web.container",
+	})
+
+	assert.Equal(t, result.Units[0].String(), `[Network]
+NetworkName=demo_default
+`)
+	assert.Equal(t, result.Units[1].String(), `[Volume]
+VolumeName=demo_data
+Label=backup=true
+`)
+	assert.Equal(t, result.Units[2].String(), `[Unit]
+Description=Service db of compose project demo
+StartLimitBurst=3
+
+[Container]
+Image=postgres
+ContainerName=demo-db
+User=999
+Group=999
+Network=demo-default.network
+Volume=demo-data.volume:/var/lib/postgresql/data
+Secret=demo_password,type=mount,target=/run/secrets/password
+HealthCmd=["pg_isready"]
+HealthInterval=10s
+HealthTimeout=5s
+HealthRetries=3
+HealthStartPeriod=30s
+Notify=healthy
+
+[Service]
+Restart=on-failure
+`)
+	assert.Equal(t, result.Units[3].String(), `[Unit]
+Description=Service migrate of compose project demo
+Requires=demo-db.service
+After=demo-db.service
+
+[Container]
+Image=migrate
+ContainerName=demo-migrate
+Network=demo-default.network
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+`)
+	assert.Equal(t, result.Units[4].String(), `[Unit]
+Description=Service web of compose project demo
+Requires=demo-db.service
+Requires=demo-migrate.service
+After=demo-db.service
+After=demo-migrate.service
+PartOf=demo-db.service
+
+[Container]
+Image=nginx
+ContainerName=demo-web
+Exec=nginx -g "daemon off;"
+Environment="GREETING=hello world"
+Environment=MODE=prod
+EnvironmentFile=/src/web.env
+PublishPort=8080:80
+PublishPort=127.0.0.1:5353:53/udp
+Network=demo-default.network
+Volume=/src/html:/usr/share/nginx/html:ro
+Label=com.example.tier=front
+AddDevice=/dev/fuse:/dev/fuse:rwm
+
+[Service]
+Restart=always
+
+[Install]
+WantedBy=default.target
+`)
+
+	var warnings []string
+	for _, w := range result.Warnings {
+		warnings = append(warnings, w.String())
+	}
+	assert.DeepEqual(t, warnings, []string{
+		"secrets.password: podman secret demo_password must be created before starting services",
+		"services.web.shm_size: not supported",
+	})
+}
+
+func TestPod(t *testing.T) {
+	project := fixtures.Project(t, `
+services:
+  app:
+    image: app
+    ports:
+      - "80:8080"
+  cache:
+    image: redis
+    network_mode: host
+`)
+	result, err := Convert(context.Background(), project, Options{Pod: true})
+	assert.NilError(t, err)
+	pod := result.Units[1]
+	assert.Equal(t, pod.Name, "demo.pod")
+	assert.DeepEqual(t, pod.Get("Pod", "PublishPort"), []string{"80:8080"})
+	assert.DeepEqual(t, pod.Get("Pod", "Network"), []string{"demo-default.network", "host"})
+	for _, u := range result.Units[2:] {
+		assert.DeepEqual(t, u.Get("Container", "Pod"), []string{"demo.pod"})
+		assert.Check(t, is.Len(u.Get("Container", "Network"), 0))
+	}
+	assert.Equal(t, result.Warnings[0].String(), "services.cache.network_mode: not supported when services are grouped in a pod")
+}
+
+func TestUnitsOrder(t *testing.T) {
+	project := fixtures.Project(t, `
+services:
+  web:
+    image: web
+    depends_on: [api]
+  api:
+    image: api
+    depends_on: [db]
+  worker:
+    image: worker
+  db:
+    image: postgres
+  cache:
+    image: redis
+  admin:
+    image: admin
+`)
+	for i := 0; i < 10; i++ {
+		result, err := Convert(context.Background(), project, Options{})
+		assert.NilError(t, err)
+		var names []string
+		for _, u := range result.Units {
+			names = append(names, u.Name)
+		}
+		assert.DeepEqual(t, names, []string{
+			"demo-default.network",
+			"demo-admin.container",
+			"demo-cache.container",
+			"demo-db.container",
+			"demo-worker.container",
+			"demo-api.container",
+			"demo-web.container",
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, quoteAll([]string{"sh", "-c", `echo "100%"`, ""}), `sh -c "echo \"100%\"" ""`)
+	assert.Equal(t, escape("100%"), "100%%")
+}
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "systemd")
+	result := &Result{Units: []*Unit{newUnit("test.volume", "Volume")}}
+	result.Units[0].Section("Volume").add("VolumeName", "test")
+	assert.NilError(t, result.Write(dir))
+	b, err := os.ReadFile(filepath.Join(dir, "test.volume"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "[Volume]\nVolumeName=test\n")
+}
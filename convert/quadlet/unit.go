@@ -0,0 +1,125 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package quadlet
+
+import (
+	"strings"
+)
+
+// Unit is a Quadlet unit file, see https://docs.podman.io/en/latest/markdown/podman-systemd.unit.5.html
+type Unit struct {
+	// Name is the unit file name, like `web.container`
+	Name     string
+	Sections []Section
+}
+
+// Section is a group of entries in a unit file, like `[Container]`
+type Section struct {
+	Name    string
+	Entries []Entry
+}
+
+// Entry is a key=value line in a unit file section
+type Entry struct {
+	Key   string
+	Value string
+}
+
+// newUnit creates a unit with sections in the conventional order, empty ones being omitted when rendered
+func newUnit(name string, kind string) *Unit {
+	return &Unit{
+		Name: name,
+		Sections: []Section{
+			{Name: "Unit"},
+			{Name: kind},
+			{Name: "Service"},
+			{Name: "Install"},
+		},
+	}
+}
+
+// Section returns the section with name, nil if unit doesn't have one
+func (u *Unit) Section(name string) *Section {
+	for i := range u.Sections {
+		if u.Sections[i].Name == name {
+			return &u.Sections[i]
+		}
+	}
+	return nil
+}
+
+// Get returns the values set for key in section
+func (u *Unit) Get(section string, key string) []string {
+	s := u.Section(section)
+	if s == nil {
+		return nil
+	}
+	var values []string
+	for _, e := range s.Entries {
+		if e.Key == key {
+			values = append(values, e.Value)
+		}
+	}
+	return values
+}
+
+func (s *Section) add(key string, values ...string) {
+	for _, v := range values {
+		s.Entries = append(s.Entries, Entry{Key: key, Value: escape(v)})
+	}
+}
+
+// String renders unit file content
+func (u Unit) String() string {
+	var b strings.Builder
+	for _, s := range u.Sections {
+		if len(s.Entries) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("[" + s.Name + "]\n")
+		for _, e := range s.Entries {
+			b.WriteString(e.Key + "=" + e.Value + "\n")
+		}
+	}
+	return b.String()
+}
+
+// escape prevents systemd from expanding specifiers in value
+func escape(value string) string {
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// quote makes s a single word for values systemd splits on whitespace, like Exec or Environment
+func quote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'\\") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// quoteAll renders args as a whitespace separated list of words
+func quoteAll(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = quote(a)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,95 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package convert
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"golang.org/x/exp/slices"
+)
+
+// Warning reports a compose attribute which can't be converted, or is only partially supported by target format
+type Warning struct {
+	// Path to the attribute, like `services.foo.devices`
+	Path    tree.Path
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Path, w.Message)
+}
+
+// Warnings collects warnings produced while converting a project
+type Warnings []Warning
+
+// Add registers a warning for attribute at path
+func (w *Warnings) Add(path tree.Path, format string, args ...any) {
+	*w = append(*w, Warning{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Unsupported registers warnings for all attributes set on service which are not listed as supported
+func (w *Warnings) Unsupported(service types.ServiceConfig, supported ...string) {
+	for _, attribute := range UnsupportedAttributes(service, supported...) {
+		w.Add(tree.NewPath("services", service.Name, attribute), "not supported")
+	}
+}
+
+// UnsupportedAttributes lists attributes set on service which are not listed as supported, in alphabetical order
+func UnsupportedAttributes(service types.ServiceConfig, supported ...string) []string {
+	var unsupported []string
+	for _, attribute := range Attributes(service) {
+		if !slices.Contains(supported, attribute) {
+			unsupported = append(unsupported, attribute)
+		}
+	}
+	return unsupported
+}
+
+// Attributes lists compose attributes set on a struct, based on yaml tags, in alphabetical order.
+// Extensions and the service name are ignored.
+func Attributes(v any) []string {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	var attributes []string
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "-" || name == "name" || strings.Contains(opts, "inline") {
+			continue
+		}
+		if value.Field(i).IsZero() {
+			continue
+		}
+		attributes = append(attributes, name)
+	}
+	sort.Strings(attributes)
+	return attributes
+}
@@ -21,9 +21,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/compose-spec/compose-go/v2/vfs"
 )
 
 func GetEnvFromFile(currentEnv map[string]string, filenames []string) (map[string]string, error) {
+	return GetEnvFromFileFS(vfs.OS, currentEnv, filenames)
+}
+
+// GetEnvFromFileFS reads env files from fsys, resolving variables based on currentEnv and previous files
+func GetEnvFromFileFS(fsys vfs.FS, currentEnv map[string]string, filenames []string) (map[string]string, error) {
 	envMap := make(map[string]string)
 
 	for _, dotEnvFile := range filenames {
@@ -33,7 +40,7 @@ func GetEnvFromFile(currentEnv map[string]string, filenames []string) (map[strin
 		}
 		dotEnvFile = abs
 
-		s, err := os.Stat(dotEnvFile)
+		s, err := fsys.Stat(dotEnvFile)
 		if os.IsNotExist(err) {
 			return envMap, fmt.Errorf("Couldn't find env file: %s", dotEnvFile)
 		}
@@ -48,7 +55,7 @@ func GetEnvFromFile(currentEnv map[string]string, filenames []string) (map[strin
 			return envMap, fmt.Errorf("%s is a directory", dotEnvFile)
 		}
 
-		b, err := os.ReadFile(dotEnvFile)
+		b, err := fsys.ReadFile(dotEnvFile)
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("Couldn't read env file: %s", dotEnvFile)
 		}
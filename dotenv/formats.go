@@ -0,0 +1,271 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dotenv
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Built-in env file formats
+const (
+	FormatJSON       = "json"
+	FormatYAML       = "yaml"
+	FormatProperties = "properties"
+)
+
+func init() {
+	RegisterFormat(FormatJSON, parseJSON)
+	RegisterFormat(FormatYAML, parseYAML)
+	RegisterFormat(FormatProperties, parseProperties)
+}
+
+// FormatFromFilename detects env file format by file extension, and returns an empty string for the default format
+func FormatFromFilename(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".properties":
+		return FormatProperties
+	}
+	return ""
+}
+
+// mapping collects variables in declaration order, expanding values with previously set variables and lookup
+type mapping struct {
+	filename string
+	lookup   LookupFn
+	vars     map[string]string
+}
+
+func newMapping(filename string, lookup LookupFn) *mapping {
+	if lookup == nil {
+		lookup = noLookupFn
+	}
+	return &mapping{filename: filename, lookup: lookup, vars: map[string]string{}}
+}
+
+func (m *mapping) set(key, value string) error {
+	if key == "" {
+		return fmt.Errorf("%s: empty variable name", m.filename)
+	}
+	v, err := expandVariables(value, m.vars, m.lookup)
+	if err != nil {
+		return fmt.Errorf("%s: invalid value for %s: %w", m.filename, key, err)
+	}
+	m.vars[key] = v
+	return nil
+}
+
+// inherit sets key from lookup, like a variable declared without a value
+func (m *mapping) inherit(key string) {
+	if v, ok := m.lookup(key); ok {
+		m.vars[key] = v
+	}
+}
+
+// parseJSON parses a flat JSON object. A null value inherits variable from lookup
+func parseJSON(r io.Reader, filename string, lookup func(key string) (string, bool)) (map[string]string, error) {
+	m := newMapping(filename, lookup)
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("%s: env file must be a JSON object", filename)
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		key := t.(string)
+		var value any
+		if err := dec.Decode(&value); err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		switch v := value.(type) {
+		case nil:
+			m.inherit(key)
+		case string:
+			err = m.set(key, v)
+		case json.Number:
+			err = m.set(key, v.String())
+		case bool:
+			err = m.set(key, strconv.FormatBool(v))
+		default:
+			return nil, fmt.Errorf("%s: value for %s must be a string, number or boolean", filename, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return m.vars, nil
+}
+
+// parseYAML parses a flat YAML mapping. A null value inherits variable from lookup
+func parseYAML(r io.Reader, filename string, lookup func(key string) (string, bool)) (map[string]string, error) {
+	m := newMapping(filename, lookup)
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return m.vars, nil
+		}
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: env file must be a YAML mapping", filename)
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s:%d: variable name must be a string", filename, key.Line)
+		}
+		switch {
+		case value.Kind == yaml.ScalarNode && value.Tag == "!!null":
+			m.inherit(key.Value)
+		case value.Kind == yaml.ScalarNode:
+			if err := m.set(key.Value, value.Value); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%s:%d: value for %s must be a scalar", filename, value.Line, key.Value)
+		}
+	}
+	return m.vars, nil
+}
+
+// parseProperties parses a Java properties file, supporting `=`, `:` or whitespace separators,
+// `#` and `!` comments, escape sequences and line continuation
+func parseProperties(r io.Reader, filename string, lookup func(key string) (string, bool)) (map[string]string, error) {
+	m := newMapping(filename, lookup)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		start := line
+		text := strings.TrimLeft(scanner.Text(), " \t\f")
+		if text == "" || text[0] == '#' || text[0] == '!' {
+			continue
+		}
+		for continued(text) && scanner.Scan() {
+			line++
+			text = text[:len(text)-1] + strings.TrimLeft(scanner.Text(), " \t\f")
+		}
+		text = strings.TrimSuffix(text, "\\")
+
+		key, value, err := splitProperty(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filename, start, err)
+		}
+		if err := m.set(key, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return m.vars, nil
+}
+
+// continued reports line ends with an odd number of backslashes
+func continued(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty splits a logical line into unescaped key and value
+func splitProperty(line string) (string, string, error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' {
+			i++
+			continue
+		}
+		if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			end = i
+			break
+		}
+	}
+	key, err := unescapeProperty(line[:end])
+	if err != nil {
+		return "", "", err
+	}
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	value, err := unescapeProperty(rest)
+	return key, value, err
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("invalid unicode escape sequence %q", s[i-1:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape sequence %q", s[i-1:i+5])
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			b.WriteRune(r)
+			i += size - 1
+		}
+	}
+	return b.String(), nil
+}
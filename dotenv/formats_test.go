@@ -0,0 +1,116 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dotenv
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func lookup(env map[string]string) LookupFn {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	assert.Equal(t, FormatFromFilename("app.json"), FormatJSON)
+	assert.Equal(t, FormatFromFilename("app.YML"), FormatYAML)
+	assert.Equal(t, FormatFromFilename("conf/app.yaml"), FormatYAML)
+	assert.Equal(t, FormatFromFilename("application.properties"), FormatProperties)
+	assert.Equal(t, FormatFromFilename(".env"), "")
+	assert.Equal(t, FormatFromFilename("app.env"), "")
+}
+
+func TestParseJSONFormat(t *testing.T) {
+	input := `{"HOST": "db", "PORT": 5432, "DEBUG": true, "URL": "pg://${HOST}:${PORT}/${NAME}", "USER": null, "PASSWORD": null}`
+	env, err := ParseWithFormat(strings.NewReader(input), "app.json", lookup(map[string]string{"NAME": "app", "USER": "admin"}), FormatJSON)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, env, map[string]string{
+		"HOST":  "db",
+		"PORT":  "5432",
+		"DEBUG": "true",
+		"URL":   "pg://db:5432/app",
+		"USER":  "admin",
+	})
+
+	_, err = ParseWithFormat(strings.NewReader(`{"NESTED": {"FOO": "bar"}}`), "app.json", nil, FormatJSON)
+	assert.Error(t, err, "app.json: value for NESTED must be a string, number or boolean")
+
+	_, err = ParseWithFormat(strings.NewReader(`["FOO"]`), "app.json", nil, FormatJSON)
+	assert.Error(t, err, "app.json: env file must be a JSON object")
+}
+
+func TestParseYAMLFormat(t *testing.T) {
+	input := `
+HOST: db
+PORT: 5432
+URL: pg://${HOST}:${PORT}/${NAME}
+USER:
+EMPTY: ""
+`
+	env, err := ParseWithFormat(strings.NewReader(input), "app.yaml", lookup(map[string]string{"NAME": "app", "USER": "admin"}), FormatYAML)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, env, map[string]string{
+		"HOST":  "db",
+		"PORT":  "5432",
+		"URL":   "pg://db:5432/app",
+		"USER":  "admin",
+		"EMPTY": "",
+	})
+
+	_, err = ParseWithFormat(strings.NewReader("FOO:\n  - bar\n"), "app.yaml", nil, FormatYAML)
+	assert.Error(t, err, "app.yaml:2: value for FOO must be a scalar")
+
+	env, err = ParseWithFormat(strings.NewReader(""), "app.yaml", nil, FormatYAML)
+	assert.NilError(t, err)
+	assert.Equal(t, len(env), 0)
+}
+
+func TestParsePropertiesFormat(t *testing.T) {
+	input := `# comment
+! another comment
+server.host = db
+server.port:5432
+server.url ${server_host}:${NAME}
+  indented=yes
+multi = first, \
+        second, \
+        third
+escaped\ key\:x = tab\there \u00e9
+empty
+trailing = value\\
+`
+	env, err := ParseWithFormat(strings.NewReader(input), "app.properties", lookup(map[string]string{"NAME": "app", "server_host": "local"}), FormatProperties)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, env, map[string]string{
+		"server.host":   "db",
+		"server.port":   "5432",
+		"server.url":    "local:app",
+		"indented":      "yes",
+		"multi":         "first, second, third",
+		"escaped key:x": "tab\there é",
+		"empty":         "",
+		"trailing":      "value\\",
+	})
+
+	_, err = ParseWithFormat(strings.NewReader("\nkey = \\u00\n"), "app.properties", nil, FormatProperties)
+	assert.Error(t, err, `app.properties:2: invalid unicode escape sequence "\\u00"`)
+}
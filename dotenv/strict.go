@@ -0,0 +1,319 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dotenv
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/compose-spec/compose-go/v2/vfs"
+)
+
+// Diagnostic is a problem found in an env file, with its position
+type Diagnostic struct {
+	Filename string
+	Line     int
+	Column   int
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Filename == "" {
+		return fmt.Sprintf("%d:%d: %s", d.Line, d.Column, d.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", d.Filename, d.Line, d.Column, d.Message)
+}
+
+// Diagnostics is the error returned by strict parsing, listing all problems found
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diag := range d {
+		lines[i] = diag.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseOptions configures ParseWithOptions
+type ParseOptions struct {
+	// Filename is used to report diagnostics
+	Filename string
+	// Lookup resolves variables used by values
+	Lookup LookupFn
+	// Strict makes any problem an error. Otherwise, problems are returned as warnings
+	// as long as the file can be parsed
+	Strict bool
+	// POSIXNames restricts variable names to [A-Za-z_][A-Za-z0-9_]*. Otherwise, names accepted by the parser,
+	// which also allows `.`, `-`, `[`, `]` and unicode letters and digits, are valid
+	POSIXNames bool
+}
+
+// ParseWithOptions reads an env file from r, checking for problems that the default parser silently accepts:
+// invalid variable names, duplicate keys, unexpected characters after a closing quote, unterminated quotes,
+// byte order mark and CRLF line endings. Problems are returned as a Diagnostics error in strict mode, or as
+// warnings otherwise.
+func ParseWithOptions(r io.Reader, opts ParseOptions) (map[string]string, []Diagnostic, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	diagnostics, fatal := check(string(data), opts)
+	if fatal || (opts.Strict && len(diagnostics) > 0) {
+		return nil, nil, Diagnostics(diagnostics)
+	}
+
+	env, err := UnmarshalBytesWithLookup(bytes.TrimPrefix(data, utf8BOM), opts.Lookup)
+	if err != nil {
+		if opts.Filename != "" {
+			err = fmt.Errorf("%s: %w", opts.Filename, err)
+		}
+		return nil, nil, err
+	}
+	return env, diagnostics, nil
+}
+
+// Check reports problems in env file content, without parsing values. opts.Lookup and opts.Strict are ignored
+func Check(r io.Reader, opts ParseOptions) ([]Diagnostic, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	diagnostics, _ := check(string(data), opts)
+	return diagnostics, nil
+}
+
+// CheckFile reports problems in env file filename read from fsys. Problems are returned as a Diagnostics
+// error in strict mode, or as warnings otherwise
+func CheckFile(fsys vfs.FS, filename string, opts ParseOptions) ([]Diagnostic, error) {
+	f, err := fsys.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+	if opts.Filename == "" {
+		opts.Filename = filename
+	}
+	diagnostics, err := Check(f, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Strict && len(diagnostics) > 0 {
+		return nil, Diagnostics(diagnostics)
+	}
+	return diagnostics, nil
+}
+
+// scanner reads env file content rune by rune, tracking position
+type scanner struct {
+	src      string
+	pos      int
+	line     int
+	column   int
+	filename string
+	crlf     bool
+	problems []Diagnostic
+}
+
+func (s *scanner) peek() rune {
+	if s.pos >= len(s.src) {
+		return -1
+	}
+	r, _ := utf8.DecodeRuneInString(s.src[s.pos:])
+	return r
+}
+
+func (s *scanner) next() rune {
+	if s.pos >= len(s.src) {
+		return -1
+	}
+	r, size := utf8.DecodeRuneInString(s.src[s.pos:])
+	if r == '\r' {
+		if strings.HasPrefix(s.src[s.pos+size:], "\n") {
+			if !s.crlf {
+				s.crlf = true
+				s.report(s.line, s.column, "line ends with CRLF, use LF line endings")
+			}
+		} else {
+			s.report(s.line, s.column, "unexpected carriage return")
+		}
+	}
+	s.pos += size
+	if r == '\n' {
+		s.line++
+		s.column = 1
+	} else {
+		s.column++
+	}
+	return r
+}
+
+func (s *scanner) report(line, column int, format string, args ...any) {
+	s.problems = append(s.problems, Diagnostic{
+		Filename: s.filename,
+		Line:     line,
+		Column:   column,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (s *scanner) skipSpaces() {
+	for r := s.peek(); r != -1 && r != '\n' && isSpace(r); r = s.peek() {
+		s.next()
+	}
+}
+
+// skipLine moves to next line
+func (s *scanner) skipLine() {
+	for r := s.next(); r != -1 && r != '\n'; r = s.next() {
+	}
+}
+
+// endOfLine checks nothing but spaces or a comment follows on current line
+func (s *scanner) endOfLine(context string) {
+	s.skipSpaces()
+	switch r := s.peek(); r {
+	case -1, '\n', charComment:
+	default:
+		s.report(s.line, s.column, "unexpected character %q after %s", r, context)
+	}
+	s.skipLine()
+}
+
+// check scans src for problems, and reports if content can't be parsed
+func check(src string, opts ParseOptions) ([]Diagnostic, bool) {
+	s := &scanner{src: src, line: 1, column: 1, filename: opts.Filename}
+	if strings.HasPrefix(src, string(utf8BOM)) {
+		s.report(1, 1, "file starts with a UTF-8 byte order mark")
+		s.pos = len(utf8BOM)
+	}
+	keys := map[string]int{}
+	for {
+		s.skipSpaces()
+		switch s.peek() {
+		case -1:
+			return s.problems, false
+		case '\n':
+			s.next()
+			continue
+		case charComment:
+			s.skipLine()
+			continue
+		}
+
+		if strings.HasPrefix(s.src[s.pos:], "export") {
+			rest := s.src[s.pos+len("export"):]
+			if r, _ := utf8.DecodeRuneInString(rest); rest != "" && isSpace(r) {
+				for i := 0; i < len("export"); i++ {
+					s.next()
+				}
+				s.skipSpaces()
+			}
+		}
+
+		line, column := s.line, s.column
+		start := s.pos
+		invalid := false
+		for r := s.peek(); r != -1 && r != '=' && r != ':' && r != '\n' && !isSpace(r); r = s.peek() {
+			switch {
+			case invalid:
+			case opts.POSIXNames && !isPOSIXNameRune(r, s.pos == start):
+				invalid = true
+				s.report(s.line, s.column, "invalid character %q in variable name, must match [A-Za-z_][A-Za-z0-9_]*", r)
+			case !opts.POSIXNames && !isNameRune(r):
+				invalid = true
+				s.report(s.line, s.column, "invalid character %q in variable name", r)
+			}
+			s.next()
+		}
+		key := s.src[start:s.pos]
+		if key == "" {
+			s.report(line, column, "missing variable name")
+		} else if first, ok := keys[key]; ok {
+			s.report(line, column, "duplicate variable %s, already set on line %d", key, first)
+		} else {
+			keys[key] = line
+		}
+
+		s.skipSpaces()
+		switch r := s.peek(); r {
+		case -1, '\n':
+			// variable inherited from environment
+			s.next()
+			continue
+		case '=', ':':
+			s.next()
+		default:
+			s.report(s.line, s.column, "unexpected character %q after variable name %s", r, key)
+			s.skipLine()
+			continue
+		}
+
+		s.skipSpaces()
+		quote := s.peek()
+		if quote != prefixDoubleQuote && quote != prefixSingleQuote {
+			s.skipLine()
+			continue
+		}
+		line, column = s.line, s.column
+		s.next()
+		if !s.skipQuoted(quote) {
+			s.report(line, column, "unterminated quoted value for variable %s", key)
+			return s.problems, true
+		}
+		s.endOfLine("closing quote")
+	}
+}
+
+// skipQuoted moves after closing quote, and reports if one was found
+func (s *scanner) skipQuoted(quote rune) bool {
+	escaped := false
+	for r := s.next(); r != -1; r = s.next() {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == quote:
+			return true
+		}
+	}
+	return false
+}
+
+// isNameRune matches characters the parser accepts in variable names, see parser.locateKeyName
+func isNameRune(r rune) bool {
+	switch r {
+	case '_', '.', '-', '[', ']':
+		return true
+	}
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+func isPOSIXNameRune(r rune, first bool) bool {
+	switch {
+	case r == '_', 'A' <= r && r <= 'Z', 'a' <= r && r <= 'z':
+		return true
+	case '0' <= r && r <= '9':
+		return !first
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dotenv
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:  "valid",
+			input: "# comment\nexport FOO=bar\nBAR: 'baz' # comment\nQUX=\"a \\\" b\"\nINHERITED\n",
+		},
+		{
+			name:     "byte order mark",
+			input:    "\uFEFFFOO=bar\n",
+			expected: []string{".env:1:1: file starts with a UTF-8 byte order mark"},
+		},
+		{
+			name:     "CRLF",
+			input:    "FOO=bar\r\nBAR=baz\r\n",
+			expected: []string{".env:1:8: line ends with CRLF, use LF line endings"},
+		},
+		{
+			name:     "carriage return",
+			input:    "FOO=bar\rBAR=baz\n",
+			expected: []string{".env:1:8: unexpected carriage return"},
+		},
+		{
+			name:  "invalid name",
+			input: "FOO=bar\nMY-VAR=baz\n1ST=qux\nMY$VAR=zot\nété.list[0]=ok\n",
+			expected: []string{
+				".env:4:3: invalid character '$' in variable name",
+			},
+		},
+		{
+			name:     "unexpected character after name",
+			input:    "FOO bar=baz\n",
+			expected: []string{".env:1:5: unexpected character 'b' after variable name FOO"},
+		},
+		{
+			name:     "missing name",
+			input:    "=bar\n",
+			expected: []string{".env:1:1: missing variable name"},
+		},
+		{
+			name:     "duplicate",
+			input:    "FOO=bar\nBAR=baz\nFOO=qux\n",
+			expected: []string{".env:3:1: duplicate variable FOO, already set on line 1"},
+		},
+		{
+			name:     "trailing garbage",
+			input:    "FOO=\"bar\"baz\nBAR='multi\nline' # ok\n",
+			expected: []string{".env:1:10: unexpected character 'b' after closing quote"},
+		},
+		{
+			name:     "unterminated quote",
+			input:    "FOO=bar\nBAR=  'baz\n",
+			expected: []string{".env:2:7: unterminated quoted value for variable BAR"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics, err := Check(strings.NewReader(tt.input), ParseOptions{Filename: ".env"})
+			assert.NilError(t, err)
+			var actual []string
+			for _, d := range diagnostics {
+				actual = append(actual, d.String())
+			}
+			assert.DeepEqual(t, actual, tt.expected)
+		})
+	}
+}
+
+func TestCheckPOSIXNames(t *testing.T) {
+	diagnostics, err := Check(strings.NewReader("FOO=bar\nMY-VAR=baz\n1ST=qux\n"), ParseOptions{Filename: ".env", POSIXNames: true})
+	assert.NilError(t, err)
+	assert.Equal(t, Diagnostics(diagnostics).Error(), `.env:2:3: invalid character '-' in variable name, must match [A-Za-z_][A-Za-z0-9_]*
+.env:3:1: invalid character '1' in variable name, must match [A-Za-z_][A-Za-z0-9_]*`)
+}
+
+func TestParseWithOptions(t *testing.T) {
+	input := "FOO=bar\nFOO=baz\n"
+
+	env, warnings, err := ParseWithOptions(strings.NewReader(input), ParseOptions{Filename: ".env"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, env, map[string]string{"FOO": "baz"})
+	assert.Equal(t, len(warnings), 1)
+
+	_, _, err = ParseWithOptions(strings.NewReader(input), ParseOptions{Filename: ".env", Strict: true})
+	assert.Error(t, err, ".env:2:1: duplicate variable FOO, already set on line 1")
+
+	_, _, err = ParseWithOptions(strings.NewReader("FOO='bar\nBAR=baz\n"), ParseOptions{Filename: ".env"})
+	assert.Error(t, err, ".env:1:5: unterminated quoted value for variable FOO")
+}
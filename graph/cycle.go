@@ -17,9 +17,12 @@
 package graph
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/compose-spec/compose-go/v2/tree"
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/compose-spec/compose-go/v2/utils"
 	"golang.org/x/exp/slices"
@@ -35,29 +38,181 @@ func CheckCycle(project *types.Project) error {
 }
 
 func (g *graph[T]) checkCycle() error {
-	// iterate on vertices in a name-order to render a predicable error message
-	// this is required by tests and enforce command reproducibility by user, which otherwise could be confusing
-	names := utils.MapKeys(g.vertices)
-	for _, name := range names {
-		err := searchCycle([]string{name}, g.vertices[name])
-		if err != nil {
-			return err
+	var errs []error
+	for _, cycle := range elementaryCycles(utils.MapKeys(g.vertices), func(name string) []string {
+		return utils.MapKeys(g.vertices[name].children)
+	}) {
+		errs = append(errs, fmt.Errorf("dependency cycle detected: %s -> %s", strings.Join(cycle, " -> "), cycle[0]))
+	}
+	return errors.Join(errs...)
+}
+
+// elementaryCycles lists all elementary cycles in a directed graph. Each cycle is reported once, starting
+// with its lowest vertex name, and vertices are visited in name-order to render predictable error messages
+func elementaryCycles(vertices []string, children func(string) []string) [][]string {
+	var cycles [][]string
+	var search func(path []string)
+	search = func(path []string) {
+		start, current := path[0], path[len(path)-1]
+		for _, next := range children(current) {
+			switch {
+			case next == start:
+				cycles = append(cycles, slices.Clone(path))
+			case next > start && !slices.Contains(path, next):
+				search(append(path, next))
+			}
+		}
+	}
+	for _, name := range vertices {
+		search([]string{name})
+	}
+	return cycles
+}
+
+// Origin is the attribute declaring a dependency between services
+type Origin struct {
+	// Attribute is one of depends_on, links, volumes_from, network_mode, ipc, pid, uts or cgroup
+	Attribute string
+	// Path is the location of the dependency in compose model
+	Path tree.Path
+	// Source is the location of the dependency in compose files, if known
+	Source string
+}
+
+func (o Origin) String() string {
+	if o.Source == "" {
+		return o.Attribute
+	}
+	return fmt.Sprintf("%s at %s", o.Attribute, o.Source)
+}
+
+// Edge is a dependency of service From on service To
+type Edge struct {
+	From    string
+	To      string
+	Origins []Origin
+}
+
+// Cycle is a closed chain of dependencies, last edge pointing back to the first service
+type Cycle []Edge
+
+func (c Cycle) String() string {
+	var b strings.Builder
+	for i, e := range c {
+		if i == 0 {
+			b.WriteString(e.From)
+		}
+		origins := make([]string, len(e.Origins))
+		for j, o := range e.Origins {
+			origins[j] = o.String()
+		}
+		fmt.Fprintf(&b, " -> %s (%s)", e.To, strings.Join(origins, ", "))
+	}
+	return b.String()
+}
+
+// Locator returns the location in compose files for a path in compose model, or an empty string
+type Locator func(path tree.Path) string
+
+// CyclesOptions configure cycles detection
+type CyclesOptions struct {
+	// Locator resolves source locations for dependency origins
+	Locator Locator
+}
+
+// WithLocator configure cycles detection to report dependency locations in compose files
+func WithLocator(locator Locator) func(*CyclesOptions) {
+	return func(o *CyclesOptions) {
+		o.Locator = locator
+	}
+}
+
+// Cycles lists all elementary cycles between services, considering both explicit depends_on
+// and dependencies implied by links, volumes_from and namespaces shared with another service.
+// As loader rejects cyclic dependencies, project must be loaded with consistency check disabled.
+func Cycles(project *types.Project, options ...func(*CyclesOptions)) []Cycle {
+	opts := CyclesOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	edges := map[string]map[string]Edge{}
+	for name, service := range project.Services {
+		edges[name] = map[string]Edge{}
+		for _, e := range serviceEdges(name, service, opts.Locator) {
+			if _, ok := project.Services[e.To]; ok {
+				edges[name][e.To] = e
+			}
 		}
 	}
-	return nil
+
+	var cycles []Cycle
+	for _, names := range elementaryCycles(utils.MapKeys(edges), func(name string) []string {
+		return utils.MapKeys(edges[name])
+	}) {
+		cycle := make(Cycle, len(names))
+		for i, name := range names {
+			cycle[i] = edges[name][names[(i+1)%len(names)]]
+		}
+		cycles = append(cycles, cycle)
+	}
+	return cycles
 }
 
-func searchCycle[T any](path []string, v *vertex[T]) error {
-	names := utils.MapKeys(v.children)
-	for _, name := range names {
-		if i := slices.Index(path, name); i >= 0 {
-			return fmt.Errorf("dependency cycle detected: %s -> %s", strings.Join(path[i:], " -> "), name)
+// serviceEdges lists dependencies declared by a service, grouping origins by target service
+func serviceEdges(name string, service types.ServiceConfig, locator Locator) []Edge {
+	p := tree.NewPath("services", name)
+	edges := map[string]*Edge{}
+	add := func(to, attribute string, path tree.Path) {
+		e, ok := edges[to]
+		if !ok {
+			e = &Edge{From: name, To: to}
+			edges[to] = e
+		}
+		origin := Origin{Attribute: attribute, Path: path}
+		if locator != nil {
+			origin.Source = locator(path)
+		}
+		e.Origins = append(e.Origins, origin)
+	}
+
+	for i, link := range service.Links {
+		target, _, _ := strings.Cut(link, ":")
+		add(target, "links", p.Next("links").Next(strconv.Itoa(i)))
+	}
+	for i, from := range service.VolumesFrom {
+		if strings.HasPrefix(from, types.ContainerPrefix) {
+			continue
 		}
-		ch := v.children[name]
-		err := searchCycle(append(path, name), ch)
-		if err != nil {
-			return err
+		target, _, _ := strings.Cut(from, ":")
+		add(target, "volumes_from", p.Next("volumes_from").Next(strconv.Itoa(i)))
+	}
+	namespaces := []struct{ attribute, value string }{
+		{"network_mode", service.NetworkMode},
+		{"ipc", service.Ipc},
+		{"pid", service.Pid},
+		{"uts", service.Uts},
+		{"cgroup", service.Cgroup},
+	}
+	for _, ns := range namespaces {
+		if strings.HasPrefix(ns.value, types.ServicePrefix) {
+			add(ns.value[len(types.ServicePrefix):], ns.attribute, p.Next(ns.attribute))
 		}
 	}
-	return nil
+
+	// depends_on also holds dependencies injected by normalization, so we only report
+	// an explicit declaration when it can be located, or has no other origin
+	for _, dep := range utils.MapKeys(service.DependsOn) {
+		path := p.Next("depends_on").Next(dep)
+		_, implied := edges[dep]
+		if !implied || (locator != nil && locator(path) != "") {
+			add(dep, "depends_on", path)
+		}
+	}
+
+	result := make([]Edge, 0, len(edges))
+	for _, to := range utils.MapKeys(edges) {
+		result = append(result, *edges[to])
+	}
+	return result
 }
@@ -0,0 +1,97 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+func TestCycles(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"a": {
+				Name:      "a",
+				DependsOn: types.DependsOnConfig{"b": {}, "c": {}},
+				Links:     []string{"c:alias"},
+			},
+			"b": {
+				Name:        "b",
+				VolumesFrom: []string{"a:ro", "container:other"},
+				DependsOn:   types.DependsOnConfig{"a": {}},
+			},
+			"c": {
+				Name:        "c",
+				NetworkMode: "service:b",
+				DependsOn:   types.DependsOnConfig{"b": {}},
+			},
+			"d": {
+				Name:      "d",
+				DependsOn: types.DependsOnConfig{"a": {}},
+			},
+		},
+	}
+
+	cycles := Cycles(project)
+	var rendered []string
+	for _, c := range cycles {
+		rendered = append(rendered, c.String())
+	}
+	assert.DeepEqual(t, rendered, []string{
+		"a -> b (depends_on) -> a (volumes_from)",
+		"a -> c (links) -> b (network_mode) -> a (volumes_from)",
+	})
+	assert.DeepEqual(t, cycles[0][1], Edge{
+		From:    "b",
+		To:      "a",
+		Origins: []Origin{{Attribute: "volumes_from", Path: "services.b.volumes_from.0"}},
+	})
+}
+
+func TestCyclesWithLocator(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"a": {
+				Name:      "a",
+				DependsOn: types.DependsOnConfig{"b": {}},
+				Links:     []string{"b"},
+			},
+			"b": {
+				Name:      "b",
+				DependsOn: types.DependsOnConfig{"a": {}},
+			},
+		},
+	}
+
+	locations := map[tree.Path]string{
+		"services.a.depends_on.b": "compose.yaml:4:7",
+		"services.a.links.0":      "compose.yaml:6:9",
+		"services.b.depends_on.a": "override.yaml:3:9",
+	}
+	cycles := Cycles(project, WithLocator(func(path tree.Path) string {
+		return locations[path]
+	}))
+	assert.Equal(t, len(cycles), 1)
+	assert.Equal(t, cycles[0].String(), "a -> b (links at compose.yaml:6:9, depends_on at compose.yaml:4:7) -> a (depends_on at override.yaml:3:9)")
+}
+
+func TestNoCycles(t *testing.T) {
+	assert.Equal(t, len(Cycles(exampleProject())), 0)
+}
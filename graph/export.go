@@ -0,0 +1,280 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/utils"
+)
+
+// ClusterBy selects the attribute used to group services when exporting the graph
+type ClusterBy string
+
+const (
+	// ClusterByNetwork groups services by their highest priority network
+	ClusterByNetwork ClusterBy = "network"
+	// ClusterByProfile groups services by their first profile
+	ClusterByProfile ClusterBy = "profile"
+)
+
+// ExportOptions configure rendering of the dependency graph
+type ExportOptions struct {
+	// ClusterBy groups services into subgraphs, none when empty
+	ClusterBy ClusterBy
+	// Volumes renders volumes shared by multiple services as secondary nodes
+	Volumes bool
+}
+
+// WithClusters configure export to group services by network or profile
+func WithClusters(by ClusterBy) func(*ExportOptions) {
+	return func(o *ExportOptions) {
+		o.ClusterBy = by
+	}
+}
+
+// WithVolumes configure export to render shared volumes
+func WithVolumes(o *ExportOptions) {
+	o.Volumes = true
+}
+
+// ToDOT renders the project's service dependency graph in Graphviz DOT format.
+// Edges point from a service to the services it depends on.
+func ToDOT(project *types.Project, options ...func(*ExportOptions)) (string, error) {
+	m, err := newExportModel(project, options)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(project.Name))
+	b.WriteString("  node [shape=box];\n")
+	for i, c := range m.clusters {
+		fmt.Fprintf(&b, "  subgraph %s {\n", dotQuote(fmt.Sprintf("cluster_%d", i)))
+		fmt.Fprintf(&b, "    label=%s;\n", dotQuote(c.name))
+		for _, s := range c.services {
+			fmt.Fprintf(&b, "    %s;\n", dotQuote(s))
+		}
+		b.WriteString("  }\n")
+	}
+	for _, s := range m.unclustered {
+		fmt.Fprintf(&b, "  %s;\n", dotQuote(s))
+	}
+	for _, e := range m.edges {
+		attrs := []string{"label=" + dotQuote(e.label)}
+		if e.optional {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(e.from), dotQuote(e.to), strings.Join(attrs, ", "))
+	}
+	for _, v := range m.volumes {
+		id := dotQuote("volume:" + v.name)
+		fmt.Fprintf(&b, "  %s [label=%s, shape=cylinder];\n", id, dotQuote(v.name))
+		for _, u := range v.users {
+			fmt.Fprintf(&b, "  %s -> %s [label=%s, arrowhead=none, style=dotted];\n", dotQuote(u.service), id, dotQuote(u.target))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// ToMermaid renders the project's service dependency graph as a Mermaid flowchart.
+// Edges point from a service to the services it depends on.
+func ToMermaid(project *types.Project, options ...func(*ExportOptions)) (string, error) {
+	m, err := newExportModel(project, options)
+	if err != nil {
+		return "", err
+	}
+
+	// mermaid identifiers are restricted, so we use generated ones and render names as labels
+	ids := map[string]string{}
+	for i, s := range m.services {
+		ids[s] = fmt.Sprintf("s%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	for i, c := range m.clusters {
+		fmt.Fprintf(&b, "  subgraph c%d [%s]\n", i, mermaidQuote(c.name))
+		for _, s := range c.services {
+			fmt.Fprintf(&b, "    %s[%s]\n", ids[s], mermaidQuote(s))
+		}
+		b.WriteString("  end\n")
+	}
+	for _, s := range m.unclustered {
+		fmt.Fprintf(&b, "  %s[%s]\n", ids[s], mermaidQuote(s))
+	}
+	for _, e := range m.edges {
+		arrow := "-->"
+		if e.optional {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", ids[e.from], arrow, mermaidQuote(e.label), ids[e.to])
+	}
+	for i, v := range m.volumes {
+		id := fmt.Sprintf("v%d", i)
+		fmt.Fprintf(&b, "  %s[(%s)]\n", id, mermaidQuote(v.name))
+		for _, u := range v.users {
+			fmt.Fprintf(&b, "  %s -.-|%s| %s\n", ids[u.service], mermaidQuote(u.target), id)
+		}
+	}
+	return b.String(), nil
+}
+
+type exportModel struct {
+	services    []string
+	clusters    []exportCluster
+	unclustered []string
+	edges       []exportEdge
+	volumes     []exportVolume
+}
+
+type exportCluster struct {
+	name     string
+	services []string
+}
+
+type exportEdge struct {
+	from, to string
+	label    string
+	optional bool
+}
+
+type exportVolume struct {
+	name  string
+	users []exportVolumeUser
+}
+
+type exportVolumeUser struct {
+	service string
+	target  string
+}
+
+// newExportModel collects graph elements in a predictable order, so rendering is reproducible
+func newExportModel(project *types.Project, options []func(*ExportOptions)) (*exportModel, error) {
+	opts := ExportOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	g, err := newGraph(project)
+	if g == nil {
+		return nil, err
+	}
+	// a dependency cycle is not an error here, rendering actually helps to diagnose it
+
+	m := &exportModel{
+		services: utils.MapKeys(g.vertices),
+	}
+
+	clusters := map[string][]string{}
+	for _, name := range m.services {
+		cluster := clusterOf(*g.vertices[name].service, opts.ClusterBy)
+		if cluster == "" {
+			m.unclustered = append(m.unclustered, name)
+			continue
+		}
+		clusters[cluster] = append(clusters[cluster], name)
+	}
+	for _, name := range utils.MapKeys(clusters) {
+		m.clusters = append(m.clusters, exportCluster{name: name, services: clusters[name]})
+	}
+
+	for _, name := range m.services {
+		v := g.vertices[name]
+		for _, dep := range utils.MapKeys(v.children) {
+			m.edges = append(m.edges, newExportEdge(name, dep, v.service.DependsOn[dep]))
+		}
+	}
+
+	if opts.Volumes {
+		m.volumes = sharedVolumes(project, m.services)
+	}
+	return m, nil
+}
+
+func clusterOf(service types.ServiceConfig, by ClusterBy) string {
+	switch by {
+	case ClusterByNetwork:
+		if networks := service.NetworksByPriority(); len(networks) > 0 {
+			return networks[0]
+		}
+		if service.NetworkMode != "" {
+			return service.NetworkMode
+		}
+	case ClusterByProfile:
+		if len(service.Profiles) > 0 {
+			return service.Profiles[0]
+		}
+	}
+	return ""
+}
+
+func newExportEdge(from, to string, dependency types.ServiceDependency) exportEdge {
+	label := []string{dependency.Condition}
+	if dependency.Condition == "" {
+		label[0] = types.ServiceConditionStarted
+	}
+	if dependency.Restart {
+		label = append(label, "restart")
+	}
+	if !dependency.Required {
+		label = append(label, "optional")
+	}
+	return exportEdge{
+		from:     from,
+		to:       to,
+		label:    strings.Join(label, ", "),
+		optional: !dependency.Required,
+	}
+}
+
+// sharedVolumes lists named volumes mounted by more than one service
+func sharedVolumes(project *types.Project, services []string) []exportVolume {
+	users := map[string][]exportVolumeUser{}
+	for _, name := range services {
+		for _, v := range project.Services[name].Volumes {
+			if v.Type != types.VolumeTypeVolume || v.Source == "" {
+				continue
+			}
+			target := v.Target
+			if v.ReadOnly {
+				target += ":ro"
+			}
+			users[v.Source] = append(users[v.Source], exportVolumeUser{service: name, target: target})
+		}
+	}
+	var volumes []exportVolume
+	for _, name := range utils.MapKeys(users) {
+		if len(users[name]) < 2 {
+			continue
+		}
+		volumes = append(volumes, exportVolume{name: name, users: users[name]})
+	}
+	return volumes
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+func exportProject() *types.Project {
+	return &types.Project{
+		Name: "demo",
+		Services: types.Services{
+			"web": {
+				Name:     "web",
+				Networks: map[string]*types.ServiceNetworkConfig{"front": nil},
+				DependsOn: types.DependsOnConfig{
+					"db":    {Condition: types.ServiceConditionHealthy, Restart: true, Required: true},
+					"cache": {Condition: types.ServiceConditionStarted},
+				},
+				Volumes: []types.ServiceVolumeConfig{
+					{Type: types.VolumeTypeVolume, Source: "data", Target: "/srv", ReadOnly: true},
+				},
+			},
+			"db": {
+				Name:     "db",
+				Networks: map[string]*types.ServiceNetworkConfig{"back": nil},
+				Profiles: []string{"storage"},
+				Volumes: []types.ServiceVolumeConfig{
+					{Type: types.VolumeTypeVolume, Source: "data", Target: "/var/lib/db"},
+					{Type: types.VolumeTypeVolume, Source: "logs", Target: "/var/log"},
+				},
+			},
+			"cache": {
+				Name:     "cache",
+				Networks: map[string]*types.ServiceNetworkConfig{"back": nil},
+			},
+		},
+	}
+}
+
+func TestToDOT(t *testing.T) {
+	dot, err := ToDOT(exportProject())
+	assert.NilError(t, err)
+	assert.Equal(t, dot, `digraph "demo" {
+  node [shape=box];
+  "cache";
+  "db";
+  "web";
+  "web" -> "cache" [label="service_started, optional", style=dashed];
+  "web" -> "db" [label="service_healthy, restart"];
+}
+`)
+}
+
+func TestToDOTClustersAndVolumes(t *testing.T) {
+	dot, err := ToDOT(exportProject(), WithClusters(ClusterByNetwork), WithVolumes)
+	assert.NilError(t, err)
+	assert.Equal(t, dot, `digraph "demo" {
+  node [shape=box];
+  subgraph "cluster_0" {
+    label="back";
+    "cache";
+    "db";
+  }
+  subgraph "cluster_1" {
+    label="front";
+    "web";
+  }
+  "web" -> "cache" [label="service_started, optional", style=dashed];
+  "web" -> "db" [label="service_healthy, restart"];
+  "volume:data" [label="data", shape=cylinder];
+  "db" -> "volume:data" [label="/var/lib/db", arrowhead=none, style=dotted];
+  "web" -> "volume:data" [label="/srv:ro", arrowhead=none, style=dotted];
+}
+`)
+}
+
+func TestToMermaid(t *testing.T) {
+	mermaid, err := ToMermaid(exportProject(), WithClusters(ClusterByProfile), WithVolumes)
+	assert.NilError(t, err)
+	assert.Equal(t, mermaid, `flowchart TD
+  subgraph c0 ["storage"]
+    s1["db"]
+  end
+  s0["cache"]
+  s2["web"]
+  s2 -.->|"service_started, optional"| s0
+  s2 -->|"service_healthy, restart"| s1
+  v0[("data")]
+  s1 -.-|"/var/lib/db"| v0
+  s2 -.-|"/srv:ro"| v0
+`)
+}
+
+func TestExportUnknownDependency(t *testing.T) {
+	project := exportProject()
+	project.Services["web"].DependsOn["missing"] = types.ServiceDependency{Required: true}
+	_, err := ToDOT(project)
+	assert.Error(t, err, `service "web" depends on unknown service "missing"`)
+}
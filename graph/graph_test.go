@@ -325,7 +325,8 @@ func Test_detectCycle(t *testing.T) {
 	graph := exampleGraph()
 	graph.addEdge("B", "D")
 	err := graph.checkCycle()
-	assert.Error(t, err, "dependency cycle detected: B -> D -> C -> B")
+	assert.Error(t, err, `dependency cycle detected: B -> D -> C -> B
+dependency cycle detected: B -> D -> E -> B`)
 }
 
 func TestWith_RootNodesAndUp(t *testing.T) {
@@ -0,0 +1,143 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package graph
+
+import (
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/utils"
+)
+
+// defaultHealthcheckInterval is the engine default interval between healthchecks
+const defaultHealthcheckInterval = 30 * time.Second
+
+// ExecutionPlan describes how services of a project are started
+type ExecutionPlan struct {
+	// Waves are ordered sets of services which can be started in parallel
+	Waves [][]string
+	// Start is the estimated delay for a service to be started, waiting for its dependencies
+	Start map[string]time.Duration
+	// CriticalPath is the chain of dependencies which delays the project the most, from first to last service started
+	CriticalPath []string
+	// Duration is the estimated delay for all services to be started
+	Duration time.Duration
+}
+
+// Plan computes the startup waves of a project and estimates its critical path.
+// Waiting for a dependency to be healthy is weighted by healthcheck start_period and interval,
+// while other conditions, which can't be estimated, don't add delay but still require a distinct wave.
+func Plan(project *types.Project) (*ExecutionPlan, error) {
+	g, err := newGraph(project)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &planner{
+		graph: g,
+		steps: map[string]*planStep{},
+	}
+	names := utils.MapKeys(g.vertices)
+	for _, name := range names {
+		p.visit(name)
+	}
+
+	plan := &ExecutionPlan{
+		Start: map[string]time.Duration{},
+	}
+	var last *planStep
+	for _, name := range names {
+		step := p.steps[name]
+		for len(plan.Waves) <= step.wave {
+			plan.Waves = append(plan.Waves, nil)
+		}
+		plan.Waves[step.wave] = append(plan.Waves[step.wave], name)
+		plan.Start[name] = step.start
+		if last == nil || step.after(last) {
+			last = step
+		}
+	}
+	if last == nil {
+		return plan, nil
+	}
+	plan.Duration = last.start
+	for step := last; step != nil; step = step.previous {
+		plan.CriticalPath = append([]string{step.name}, plan.CriticalPath...)
+	}
+	return plan, nil
+}
+
+type planner struct {
+	graph *graph[types.ServiceConfig]
+	steps map[string]*planStep
+}
+
+type planStep struct {
+	name  string
+	wave  int
+	start time.Duration
+	// previous is the dependency which delays this step the most
+	previous *planStep
+}
+
+// after tells if step starts later than other, using waves to break ties
+func (s *planStep) after(other *planStep) bool {
+	if s.start != other.start {
+		return s.start > other.start
+	}
+	return s.wave > other.wave
+}
+
+func (p *planner) visit(name string) *planStep {
+	if step, ok := p.steps[name]; ok {
+		return step
+	}
+	v := p.graph.vertices[name]
+	step := &planStep{name: name}
+	for _, dep := range utils.MapKeys(v.children) {
+		d := p.visit(dep)
+		if d.wave+1 > step.wave {
+			step.wave = d.wave + 1
+		}
+		start := d.start + readyDelay(*v.children[dep].service, v.service.DependsOn[dep].Condition)
+		if step.previous == nil || start > step.start || (start == step.start && d.wave > step.previous.wave) {
+			step.start = start
+			step.previous = d
+		}
+	}
+	p.steps[name] = step
+	return step
+}
+
+// readyDelay estimates the delay for a started service to satisfy a depends_on condition
+func readyDelay(service types.ServiceConfig, condition string) time.Duration {
+	if condition != types.ServiceConditionHealthy {
+		return 0
+	}
+	healthcheck := service.HealthCheck
+	if healthcheck == nil || healthcheck.Disable {
+		return 0
+	}
+	delay := defaultHealthcheckInterval
+	if healthcheck.Interval != nil {
+		delay = time.Duration(*healthcheck.Interval)
+	}
+	if healthcheck.StartPeriod != nil {
+		delay += time.Duration(*healthcheck.StartPeriod)
+	}
+	return delay
+}
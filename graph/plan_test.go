@@ -0,0 +1,106 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+func TestPlan(t *testing.T) {
+	duration := func(d time.Duration) *types.Duration {
+		v := types.Duration(d)
+		return &v
+	}
+	project := &types.Project{
+		Services: types.Services{
+			"db": {
+				Name: "db",
+				HealthCheck: &types.HealthCheckConfig{
+					Interval:    duration(5 * time.Second),
+					StartPeriod: duration(20 * time.Second),
+				},
+			},
+			"cache": {
+				Name:        "cache",
+				HealthCheck: &types.HealthCheckConfig{},
+			},
+			"migrate": {
+				Name: "migrate",
+				DependsOn: types.DependsOnConfig{
+					"db": {Condition: types.ServiceConditionHealthy, Required: true},
+				},
+			},
+			"api": {
+				Name: "api",
+				DependsOn: types.DependsOnConfig{
+					"migrate": {Condition: types.ServiceConditionCompletedSuccessfully, Required: true},
+					"cache":   {Condition: types.ServiceConditionHealthy, Required: true},
+				},
+			},
+			"web": {
+				Name: "web",
+				DependsOn: types.DependsOnConfig{
+					"api": {Condition: types.ServiceConditionStarted, Required: true},
+				},
+			},
+			"docs": {
+				Name: "docs",
+			},
+		},
+	}
+
+	plan, err := Plan(project)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, plan.Waves, [][]string{
+		{"cache", "db", "docs"},
+		{"migrate"},
+		{"api"},
+		{"web"},
+	})
+	assert.DeepEqual(t, plan.Start, map[string]time.Duration{
+		"cache":   0,
+		"db":      0,
+		"docs":    0,
+		"migrate": 25 * time.Second,
+		"api":     30 * time.Second,
+		"web":     30 * time.Second,
+	})
+	assert.DeepEqual(t, plan.CriticalPath, []string{"cache", "api", "web"})
+	assert.Equal(t, plan.Duration, 30*time.Second)
+}
+
+func TestPlanEmpty(t *testing.T) {
+	plan, err := Plan(&types.Project{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(plan.Waves), 0)
+	assert.Equal(t, len(plan.CriticalPath), 0)
+}
+
+func TestPlanCycle(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"a": {Name: "a", DependsOn: types.DependsOnConfig{"b": {Required: true}}},
+			"b": {Name: "b", DependsOn: types.DependsOnConfig{"a": {Required: true}}},
+		},
+	}
+	_, err := Plan(project)
+	assert.Error(t, err, "dependency cycle detected: a -> b -> a")
+}
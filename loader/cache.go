@@ -0,0 +1,316 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	interp "github.com/compose-spec/compose-go/v2/interpolation"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/vfs"
+	godigest "github.com/opencontainers/go-digest"
+)
+
+// loadCache keeps intermediate results computed while loading a project, so they can be reused
+// by a Session on subsequent loads. All methods accept a nil receiver, which disables caching.
+type loadCache struct {
+	// files maps a file path to the content last read
+	files map[string]cachedFile
+	// documents maps a content digest to the yaml documents parsed from this content
+	documents map[string][]yamlDocument
+	// interpolated maps a document key to the last interpolation result
+	interpolated map[string]interpolation
+	// includes maps an `include` definition to the last model it produced
+	includes map[string]includedModel
+	// recorders collect files read while loading included models
+	recorders []map[string]string
+	// used tracks cache entries involved in the current load, others get pruned
+	used map[string]bool
+}
+
+type cachedFile struct {
+	digest  string
+	content []byte
+}
+
+type lookupResult struct {
+	value string
+	found bool
+}
+
+type interpolation struct {
+	variables map[string]lookupResult
+	result    map[string]any
+}
+
+type includedModel struct {
+	environment  string
+	files        map[string]string
+	dependencies []types.Dependency
+	model        map[string]any
+}
+
+func newLoadCache() *loadCache {
+	return &loadCache{
+		files:        map[string]cachedFile{},
+		documents:    map[string][]yamlDocument{},
+		interpolated: map[string]interpolation{},
+		includes:     map[string]includedModel{},
+		used:         map[string]bool{},
+	}
+}
+
+// readFile returns content for path, only reading from disk if path was never read or has been invalidated
+func (c *loadCache) readFile(fsys vfs.FS, path string) ([]byte, error) {
+	if c == nil {
+		return fsys.ReadFile(path)
+	}
+	key := cacheKey(path)
+	f, ok := c.files[key]
+	if !ok {
+		content, err := fsys.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		f = cachedFile{
+			digest:  godigest.FromBytes(content).String(),
+			content: content,
+		}
+		c.files[key] = f
+	}
+	c.record(key, f.digest)
+	return f.content, nil
+}
+
+// cacheKey returns path as absolute, so cache entries don't depend on the way files are referenced
+func cacheKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// record marks a file as used by the current load, and by all the included models being loaded
+func (c *loadCache) record(path string, digest string) {
+	c.used[digest] = true
+	for _, r := range c.recorders {
+		r[path] = digest
+	}
+}
+
+// decodeDocuments parses yaml documents from content, or returns a copy of the documents parsed from the same content
+func (c *loadCache) decodeDocuments(content []byte) ([]yamlDocument, error) {
+	if c == nil {
+		return decodeYamlDocuments(content)
+	}
+	digest := godigest.FromBytes(content).String()
+	c.used[digest] = true
+	documents, ok := c.documents[digest]
+	if !ok {
+		decoded, err := decodeYamlDocuments(content)
+		if err != nil {
+			return nil, err
+		}
+		for i := range decoded {
+			decoded[i].key = fmt.Sprintf("%s#%d", digest, i)
+		}
+		c.documents[digest] = decoded
+		documents = decoded
+	}
+	copies := make([]yamlDocument, len(documents))
+	for i, d := range documents {
+		d.raw = cloneRaw(d.raw)
+		copies[i] = d
+	}
+	return copies, nil
+}
+
+// interpolate runs interpolation on a yaml document, or returns the previous result if the variables
+// involved have the same values
+func (c *loadCache) interpolate(key string, cfg map[string]any, opts interp.Options) (map[string]any, error) {
+	if c == nil || key == "" {
+		return interp.Interpolate(cfg, opts)
+	}
+	lookup := opts.LookupValue
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	if cached, ok := c.interpolated[key]; ok && sameValues(cached.variables, lookup) {
+		return cloneRaw(cached.result).(map[string]any), nil
+	}
+
+	variables := map[string]lookupResult{}
+	opts.LookupValue = func(name string) (string, bool) {
+		v, ok := lookup(name)
+		variables[name] = lookupResult{value: v, found: ok}
+		return v, ok
+	}
+	result, err := interp.Interpolate(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.interpolated[key] = interpolation{
+		variables: variables,
+		result:    cloneRaw(result).(map[string]any),
+	}
+	return result, nil
+}
+
+func sameValues(variables map[string]lookupResult, lookup interp.LookupValue) bool {
+	for name, expected := range variables {
+		v, ok := lookup(name)
+		if v != expected.value || ok != expected.found {
+			return false
+		}
+	}
+	return true
+}
+
+// loadInclude runs load to get an included model, or returns the previous model if none of the files it
+// depends on changed and environment is the same. Dependencies recorded by load are replayed into deps
+// when previous model is reused
+func (c *loadCache) loadInclude(fsys vfs.FS, deps *dependencies, key string, environment types.Mapping, load func() (map[string]any, error)) (map[string]any, error) {
+	if c == nil {
+		return load()
+	}
+	env := digestMapping(environment)
+	c.used[key] = true
+	if cached, ok := c.includes[key]; ok && cached.environment == env && c.unchanged(fsys, cached.files) {
+		for path, digest := range cached.files {
+			c.record(path, digest)
+		}
+		for _, d := range cached.dependencies {
+			deps.add(d.Path, d.Reason, d.Source)
+		}
+		return cloneRaw(cached.model).(map[string]any), nil
+	}
+
+	c.recorders = append(c.recorders, map[string]string{})
+	recorded := deps.len()
+	model, err := load()
+	files := c.recorders[len(c.recorders)-1]
+	c.recorders = c.recorders[:len(c.recorders)-1]
+	if err != nil {
+		return nil, err
+	}
+	for path, digest := range files {
+		// propagate to the enclosing include, if any
+		c.record(path, digest)
+	}
+	c.includes[key] = includedModel{
+		environment:  env,
+		files:        files,
+		dependencies: deps.since(recorded),
+		model:        cloneRaw(model).(map[string]any),
+	}
+	return model, nil
+}
+
+func (c *loadCache) unchanged(fsys vfs.FS, files map[string]string) bool {
+	for path, digest := range files {
+		f, ok := c.files[path]
+		if !ok {
+			if _, err := c.readFile(fsys, path); err != nil {
+				return false
+			}
+			f = c.files[path]
+		}
+		if f.digest != digest {
+			return false
+		}
+	}
+	return true
+}
+
+// invalidate forces files to be read again on next load
+func (c *loadCache) invalidate(paths ...string) {
+	for _, p := range paths {
+		delete(c.files, cacheKey(p))
+	}
+}
+
+// prune removes cache entries which have not been used by the last load
+func (c *loadCache) prune() {
+	for path, f := range c.files {
+		if !c.used[f.digest] {
+			delete(c.files, path)
+		}
+	}
+	for digest := range c.documents {
+		if !c.used[digest] {
+			delete(c.documents, digest)
+		}
+	}
+	for key := range c.interpolated {
+		digest, _, _ := strings.Cut(key, "#")
+		if !c.used[digest] {
+			delete(c.interpolated, key)
+		}
+	}
+	for key := range c.includes {
+		if !c.used[key] {
+			delete(c.includes, key)
+		}
+	}
+	c.used = map[string]bool{}
+}
+
+func digestMapping(m types.Mapping) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(m[k])
+		b.WriteByte(0)
+	}
+	return godigest.FromString(b.String()).String()
+}
+
+// cloneRaw creates a deep copy of a raw yaml tree, including maps with non-string keys
+func cloneRaw(value any) any {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		cp := make(map[interface{}]interface{}, len(v))
+		for k, e := range v {
+			cp[k] = cloneRaw(e)
+		}
+		return cp
+	case map[string]any:
+		cp := make(map[string]any, len(v))
+		for k, e := range v {
+			cp[k] = cloneRaw(e)
+		}
+		return cp
+	case []any:
+		cp := make([]any, len(v))
+		for i, e := range v {
+			cp[i] = cloneRaw(e)
+		}
+		return cp
+	default:
+		return value
+	}
+}
@@ -0,0 +1,118 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/utils"
+)
+
+// dependencies collects local files read while loading a project. A nil receiver records nothing
+type dependencies struct {
+	list []types.Dependency
+}
+
+func (d *dependencies) add(path string, reason types.DependencyReason, source string) {
+	if d == nil || path == "" || path == "-" {
+		return
+	}
+	d.list = append(d.list, types.Dependency{
+		Path:   path,
+		Reason: reason,
+		Source: source,
+	})
+}
+
+// since returns dependencies recorded after the first n ones
+func (d *dependencies) since(n int) []types.Dependency {
+	if d == nil || n >= len(d.list) {
+		return nil
+	}
+	return append([]types.Dependency{}, d.list[n:]...)
+}
+
+func (d *dependencies) len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.list)
+}
+
+// resolved returns recorded dependencies without duplicates, completed by the files and directories
+// declared by the project model for resources which are not read by the loader
+func (d *dependencies) resolved(project *types.Project) []types.Dependency {
+	var result []types.Dependency
+	seen := map[types.Dependency]bool{}
+	add := func(dep types.Dependency) {
+		if !filepath.IsAbs(dep.Path) {
+			dep.Path = filepath.Join(project.WorkingDir, dep.Path)
+		}
+		if !seen[dep] {
+			seen[dep] = true
+			result = append(result, dep)
+		}
+	}
+	if d != nil {
+		for _, dep := range d.list {
+			add(dep)
+		}
+	}
+
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		source := "services." + name
+		for _, f := range service.EnvFiles {
+			add(types.Dependency{Path: f.Path, Reason: types.DependencyEnvFile, Source: source})
+		}
+		for _, f := range service.LabelFiles {
+			add(types.Dependency{Path: f, Reason: types.DependencyLabelFile, Source: source})
+		}
+		if service.Build != nil && isLocalContext(service.Build.Context) {
+			add(types.Dependency{Path: service.Build.Context, Reason: types.DependencyBuildContext, Source: source})
+		}
+	}
+	for _, name := range utils.MapKeys(project.Configs) {
+		c := project.Configs[name]
+		if !c.External && c.File != "" {
+			add(types.Dependency{Path: c.File, Reason: types.DependencyConfig, Source: "configs." + name})
+		}
+	}
+	for _, name := range utils.MapKeys(project.Secrets) {
+		s := project.Secrets[name]
+		if !s.External && s.File != "" {
+			add(types.Dependency{Path: s.File, Reason: types.DependencySecret, Source: "secrets." + name})
+		}
+	}
+	return result
+}
+
+// isLocalContext returns true if build context is a path on local filesystem, not a git repository,
+// an URL or a builder specific context type
+func isLocalContext(context string) bool {
+	if context == "" || strings.Contains(context, "://") {
+		return false
+	}
+	for _, prefix := range []string{"github.com/", "git@"} {
+		if strings.HasPrefix(context, prefix) {
+			return false
+		}
+	}
+	return true
+}
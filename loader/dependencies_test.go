@@ -0,0 +1,115 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+func TestProjectDependencies(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"compose.yaml": `
+name: deps
+include:
+  - path: db/compose.yaml
+    env_file: db.env
+services:
+  app:
+    extends:
+      file: base.yaml
+      service: base
+    build: ./app
+    env_file:
+      - path: app.env
+        required: false
+    label_file: [app.labels]
+    configs: [site]
+    secrets: [key]
+configs:
+  site:
+    file: ./site.conf
+  remote:
+    external: true
+secrets:
+  key:
+    file: ./key
+`,
+		"compose.override.yaml": "services:\n  app:\n    build: https://github.com/example/app.git\n",
+		"base.yaml":             "services:\n  base:\n    image: app\n",
+		"app.labels":            "tier=front\n",
+		"site.conf":             "",
+		"key":                   "",
+		"db.env":                "PGDATA=/data\n",
+		"db/compose.yaml":       "services:\n  db:\n    image: postgres\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		assert.NilError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	path := func(name string) string {
+		return filepath.Join(dir, filepath.FromSlash(name))
+	}
+
+	details := types.ConfigDetails{
+		WorkingDir: dir,
+		ConfigFiles: []types.ConfigFile{
+			{Filename: path("compose.yaml")},
+			{Filename: path("compose.override.yaml")},
+		},
+		Environment: types.Mapping{},
+	}
+	p, err := LoadWithContext(context.TODO(), details)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, p.Dependencies, []types.Dependency{
+		{Path: path("compose.yaml"), Reason: types.DependencyComposeFile},
+		{Path: path("base.yaml"), Reason: types.DependencyExtends, Source: path("compose.yaml")},
+		{Path: path("db.env"), Reason: types.DependencyIncludeEnvFile, Source: path("compose.yaml")},
+		{Path: path("db/compose.yaml"), Reason: types.DependencyInclude, Source: path("compose.yaml")},
+		{Path: path("compose.override.yaml"), Reason: types.DependencyOverride},
+		{Path: path("app.env"), Reason: types.DependencyEnvFile, Source: "services.app"},
+		{Path: path("app.labels"), Reason: types.DependencyLabelFile, Source: "services.app"},
+		{Path: path("site.conf"), Reason: types.DependencyConfig, Source: "configs.site"},
+		{Path: path("key"), Reason: types.DependencySecret, Source: "secrets.key"},
+	})
+
+	details.ConfigFiles = details.ConfigFiles[:1]
+	session := NewSession(details)
+	for i := 0; i < 2; i++ {
+		// second load reuses the included model from cache
+		p, err = session.Load(context.TODO())
+		assert.NilError(t, err)
+		assert.DeepEqual(t, p.DependencyPaths(), []string{
+			path("compose.yaml"),
+			path("base.yaml"),
+			path("db.env"),
+			path("db/compose.yaml"),
+			path("app.env"),
+			path("app.labels"),
+			path("app"),
+			path("site.conf"),
+			path("key"),
+		})
+	}
+}
@@ -17,11 +17,51 @@
 package loader
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 
+	"github.com/compose-spec/compose-go/v2/dotenv"
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/sirupsen/logrus"
 )
 
+// checkEnvFiles checks env files according to opts.EnvFileCheck, logging problems as warnings unless strict
+func checkEnvFiles(opts *Options, files ...string) error {
+	if opts.EnvFileCheck == nil {
+		return nil
+	}
+	var errs []error
+	for _, f := range files {
+		diagnostics, err := dotenv.CheckFile(opts.filesystem(), f, *opts.EnvFileCheck)
+		if errors.Is(err, fs.ErrNotExist) {
+			// reported by env file parser, if required
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, d := range diagnostics {
+			logrus.Warn(d.String())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkServicesEnvFiles checks env files set by services using the default env file format
+func checkServicesEnvFiles(project *types.Project, opts *Options) error {
+	var files []string
+	for _, name := range project.ServiceNames() {
+		for _, f := range project.Services[name].EnvFiles {
+			if f.Format == "" && dotenv.FormatFromFilename(f.Path) == "" {
+				files = append(files, f.Path)
+			}
+		}
+	}
+	return checkEnvFiles(opts, files...)
+}
+
 // ResolveEnvironment update the environment variables for the format {- VAR} (without interpolation)
 func ResolveEnvironment(dict map[string]any, environment types.Mapping) {
 	resolveServicesEnvironment(dict, environment)
@@ -153,11 +153,13 @@ func getExtendsBaseFromFile(
 		}
 		localdir := filepath.Dir(local)
 		relworkingdir := loader.Dir(refPath)
+		opts.dependencies.add(local, types.DependencyExtends, path)
 
 		extendsOpts := opts.clone()
 		// replace localResourceLoader with a new flavour, using extended file base path
 		extendsOpts.ResourceLoaders = append(opts.RemoteResourceLoaders(), localResourceLoader{
 			WorkingDir: localdir,
+			fsys:       opts.FS,
 		})
 		extendsOpts.ResolvePaths = false // we do relative path resolution after file has been loaded
 		extendsOpts.SkipNormalization = true
@@ -194,7 +196,7 @@ func getExtendsBaseFromFile(
 		for _, loader := range opts.RemoteResourceLoaders() {
 			remotes = append(remotes, loader.Accept)
 		}
-		err = paths.ResolveRelativePaths(source, relworkingdir, remotes)
+		err = paths.ResolveRelativePathsForOS(opts.filesystem(), opts.TargetOS, source, relworkingdir, remotes)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -24,9 +24,9 @@ import (
 	"reflect"
 	"strings"
 
-	"github.com/compose-spec/compose-go/v2/dotenv"
 	interp "github.com/compose-spec/compose-go/v2/interpolation"
 	"github.com/compose-spec/compose-go/v2/types"
+	"golang.org/x/exp/slices"
 )
 
 // loadIncludeConfig parse the required config from raw yaml
@@ -107,19 +107,28 @@ func ApplyInclude(ctx context.Context, workingDir string, environment types.Mapp
 		loadOptions.SkipConsistencyCheck = true
 		loadOptions.ResourceLoaders = append(loadOptions.RemoteResourceLoaders(), localResourceLoader{
 			WorkingDir: r.ProjectDirectory,
+			fsys:       options.FS,
 		})
 
+		var includedBy string
+		if len(included) > 0 {
+			includedBy = included[len(included)-1]
+		}
+		// included project environment is the enclosing project environment, then env files
+		envLayer := types.EnvLayerEnvFile
 		if len(r.EnvFile) == 0 {
+			envLayer = types.EnvLayerDotEnv
 			f := filepath.Join(r.ProjectDirectory, ".env")
-			if s, err := os.Stat(f); err == nil && !s.IsDir() {
+			if s, err := options.filesystem().Stat(f); err == nil && !s.IsDir() {
 				r.EnvFile = types.StringList{f}
+				options.dependencies.add(f, types.DependencyDotEnv, includedBy)
 			}
 		} else {
 			envFile := []string{}
 			for _, f := range r.EnvFile {
 				if !filepath.IsAbs(f) {
 					f = filepath.Join(workingDir, f)
-					s, err := os.Stat(f)
+					s, err := options.filesystem().Stat(f)
 					if err != nil {
 						return err
 					}
@@ -128,26 +137,33 @@ func ApplyInclude(ctx context.Context, workingDir string, environment types.Mapp
 					}
 				}
 				envFile = append(envFile, f)
+				options.dependencies.add(f, types.DependencyIncludeEnvFile, includedBy)
 			}
 			r.EnvFile = envFile
 		}
 
-		envFromFile, err := dotenv.GetEnvFromFile(environment, r.EnvFile)
-		if err != nil {
+		if err := checkEnvFiles(options, r.EnvFile...); err != nil {
+			return err
+		}
+		scope := types.NewEnvSources().Add(types.EnvLayerProject, "", environment)
+		if err := scope.AddFiles(options.filesystem(), envLayer, r.EnvFile...); err != nil {
 			return err
 		}
 
 		config := types.ConfigDetails{
 			WorkingDir:  relworkingdir,
 			ConfigFiles: types.ToConfigFiles(r.Path),
-			Environment: environment.Clone().Merge(envFromFile),
+			Environment: scope.Mapping(),
 		}
 		loadOptions.Interpolate = &interp.Options{
 			Substitute:      options.Interpolate.Substitute,
 			LookupValue:     config.LookupEnv,
 			TypeCastMapping: options.Interpolate.TypeCastMapping,
 		}
-		imported, err := loadYamlModel(ctx, config, loadOptions, &cycleTracker{}, included)
+		key := strings.Join(append(slices.Clone(r.Path), r.ProjectDirectory, relworkingdir), string(os.PathListSeparator))
+		imported, err := options.cache.loadInclude(options.filesystem(), options.dependencies, key, config.Environment, func() (map[string]any, error) {
+			return loadYamlModel(ctx, config, loadOptions, &cycleTracker{}, included)
+		})
 		if err != nil {
 			return err
 		}
@@ -22,7 +22,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"reflect"
 	"regexp"
@@ -30,7 +29,9 @@ import (
 	"strings"
 
 	"github.com/compose-spec/compose-go/v2/consts"
+	"github.com/compose-spec/compose-go/v2/dotenv"
 	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/graph"
 	interp "github.com/compose-spec/compose-go/v2/interpolation"
 	"github.com/compose-spec/compose-go/v2/override"
 	"github.com/compose-spec/compose-go/v2/paths"
@@ -40,6 +41,7 @@ import (
 	"github.com/compose-spec/compose-go/v2/tree"
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/compose-spec/compose-go/v2/validation"
+	"github.com/compose-spec/compose-go/v2/vfs"
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/exp/slices"
@@ -56,8 +58,15 @@ type Options struct {
 	SkipNormalization bool
 	// Resolve path
 	ResolvePaths bool
+	// VerifyPaths checks local paths declared by the model exist, with the expected type
+	VerifyPaths bool
+	// FS is the filesystem used to read compose files and the files they reference, default to host filesystem
+	FS vfs.FS
 	// Convert Windows path
 	ConvertWindowsPaths bool
+	// TargetOS selects path semantics of the containers, linux or windows, to validate and normalize container
+	// paths and set configs and secrets default targets. Default is to apply linux rules without validation
+	TargetOS string
 	// Skip consistency check
 	SkipConsistencyCheck bool
 	// Skip extends
@@ -70,6 +79,9 @@ type Options struct {
 	SkipDefaultValues bool
 	// Interpolation options
 	Interpolate *interp.Options
+	// EnvFileCheck enables checks of env files set by services and includes, see dotenv.CheckFile.
+	// Problems are logged as warnings, or reported as an error in strict mode
+	EnvFileCheck *dotenv.ParseOptions
 	// Discard 'env_file' entries after resolving to 'environment' section
 	discardEnvFiles bool
 	// Set project projectName
@@ -82,8 +94,22 @@ type Options struct {
 	ResourceLoaders []ResourceLoader
 	// KnownExtensions manages x-* attribute we know and the corresponding go structs
 	KnownExtensions map[string]any
+	// SchemaVersion selects the compose-spec schema revision used for validation, default to schema.Latest
+	SchemaVersion schema.Version
+	// Strictness defines how attributes declared by Unsupported are reported
+	Strictness Strictness
+	// Unsupported lists attributes target runtime doesn't support, like `services.*.develop`.
+	// UnsupportedBy returns those for a Docker Compose release
+	Unsupported []tree.Path
+	// ExtensionSchemas declares JSON schema fragments used to validate x-* attributes.
+	// A nil fragment is derived from the go struct registered by KnownExtensions
+	ExtensionSchemas map[string]map[string]any
 	// Metada for telemetry
 	Listeners []Listener
+	// cache keeps intermediate load results when loading through a Session
+	cache *loadCache
+	// dependencies records local files read while loading project
+	dependencies *dependencies
 }
 
 var versionWarning []string
@@ -95,6 +121,11 @@ func (o *Options) warnObsoleteVersion(file string) {
 	versionWarning = append(versionWarning, file)
 }
 
+// filesystem returns the filesystem to read files from
+func (o *Options) filesystem() vfs.FS {
+	return vfs.Or(o.FS)
+}
+
 type Listener = func(event string, metadata map[string]any)
 
 // Invoke all listeners for an event
@@ -131,6 +162,7 @@ func (o Options) RemoteResourceLoaders() []ResourceLoader {
 
 type localResourceLoader struct {
 	WorkingDir string
+	fsys       vfs.FS
 }
 
 func (l localResourceLoader) abs(p string) string {
@@ -162,7 +194,7 @@ func (l localResourceLoader) Dir(originalPath string) string {
 }
 
 func (l localResourceLoader) isDir(path string) bool {
-	fileInfo, err := os.Stat(path)
+	fileInfo, err := vfs.Or(l.fsys).Stat(path)
 	if err != nil {
 		return false
 	}
@@ -175,19 +207,56 @@ func (o *Options) clone() *Options {
 		SkipInterpolation:          o.SkipInterpolation,
 		SkipNormalization:          o.SkipNormalization,
 		ResolvePaths:               o.ResolvePaths,
+		VerifyPaths:                o.VerifyPaths,
+		FS:                         o.FS,
 		ConvertWindowsPaths:        o.ConvertWindowsPaths,
+		TargetOS:                   o.TargetOS,
 		SkipConsistencyCheck:       o.SkipConsistencyCheck,
 		SkipExtends:                o.SkipExtends,
 		SkipInclude:                o.SkipInclude,
 		Interpolate:                o.Interpolate,
+		EnvFileCheck:               o.EnvFileCheck,
 		discardEnvFiles:            o.discardEnvFiles,
 		projectName:                o.projectName,
 		projectNameImperativelySet: o.projectNameImperativelySet,
 		Profiles:                   o.Profiles,
 		ResourceLoaders:            o.ResourceLoaders,
 		KnownExtensions:            o.KnownExtensions,
+		ExtensionSchemas:           o.ExtensionSchemas,
+		SchemaVersion:              o.SchemaVersion,
+		Strictness:                 o.Strictness,
+		Unsupported:                o.Unsupported,
 		Listeners:                  o.Listeners,
+		cache:                      o.cache,
+		dependencies:               o.dependencies,
+	}
+}
+
+// validateSchema validates dict using the selected compose-spec schema version, extended with registered ExtensionSchemas
+func (o *Options) validateSchema(dict map[string]any) error {
+	base, err := schema.ForVersion(o.SchemaVersion)
+	if err != nil {
+		return err
+	}
+	if len(o.ExtensionSchemas) == 0 {
+		return schema.ValidateWith(base, dict)
 	}
+	fragments := map[string]map[string]any{}
+	for name, fragment := range o.ExtensionSchemas {
+		if fragment == nil {
+			typ, ok := o.KnownExtensions[name]
+			if !ok {
+				return fmt.Errorf("no schema nor go struct registered for extension %s: %w", name, errdefs.ErrInvalid)
+			}
+			fragment = schema.Reflect(typ)
+		}
+		fragments[name] = fragment
+	}
+	s, err := schema.ExtendWith(base, fragments)
+	if err != nil {
+		return err
+	}
+	return schema.ValidateWith(s, dict)
 }
 
 func (o *Options) SetProjectName(name string, imperativelySet bool) {
@@ -212,22 +281,27 @@ type cycleTracker struct {
 
 func (ct *cycleTracker) Add(filename, service string) (*cycleTracker, error) {
 	toAdd := serviceRef{filename: filename, service: service}
-	for _, loaded := range ct.loaded {
+	for i, loaded := range ct.loaded {
 		if toAdd == loaded {
-			// Create an error message of the form:
-			// Circular reference:
-			//   service-a in docker-compose.yml
-			//   extends service-b in docker-compose.yml
-			//   extends service-a in docker-compose.yml
-			errLines := []string{
-				"Circular reference:",
-				fmt.Sprintf("  %s in %s", ct.loaded[0].service, ct.loaded[0].filename),
-			}
-			for _, service := range append(ct.loaded[1:], toAdd) {
-				errLines = append(errLines, fmt.Sprintf("  extends %s in %s", service.service, service.filename))
+			// report the chain of extends as a dependency cycle, like other dependencies between services
+			// each reference holds the file of the extended service, so the one declaring `extends` is the previous one
+			chain := ct.loaded[i:]
+			cycle := make(graph.Cycle, len(chain))
+			for j, ref := range chain {
+				next, declaring := toAdd, chain[len(chain)-1]
+				if j+1 < len(chain) {
+					next = chain[j+1]
+				}
+				if j > 0 {
+					declaring = chain[j-1]
+				}
+				cycle[j] = graph.Edge{From: ref.service, To: next.service, Origins: []graph.Origin{{
+					Attribute: "extends",
+					Path:      tree.NewPath("services", ref.service, "extends"),
+					Source:    declaring.filename,
+				}}}
 			}
-
-			return nil, errors.New(strings.Join(errLines, "\n"))
+			return nil, fmt.Errorf("dependency cycle detected: %s: %w", cycle, errdefs.ErrInvalid)
 		}
 	}
 
@@ -315,7 +389,7 @@ func LoadConfigFiles(ctx context.Context, configFiles []string, workingDir strin
 	for _, op := range options {
 		op(opts)
 	}
-	opts.ResourceLoaders = append(opts.ResourceLoaders, localResourceLoader{})
+	opts.ResourceLoaders = append(opts.ResourceLoaders, localResourceLoader{fsys: opts.FS})
 
 	for i, p := range configFiles {
 		if p == "-" {
@@ -380,13 +454,26 @@ func loadModelWithContext(ctx context.Context, configDetails *types.ConfigDetail
 	if len(configDetails.ConfigFiles) < 1 {
 		return nil, errors.New("No files specified")
 	}
+	if opts.TargetOS != "" && !paths.IsSupportedTarget(opts.TargetOS) {
+		return nil, fmt.Errorf("unsupported target OS %q, must be %s or %s", opts.TargetOS, paths.Linux, paths.Windows)
+	}
 
 	err := projectName(configDetails, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return load(ctx, *configDetails, opts, nil)
+	dict, err := load(ctx, *configDetails, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.VerifyPaths {
+		if err := verifyPaths(opts.filesystem(), dict, configDetails.WorkingDir, opts.Profiles); err != nil {
+			return nil, err
+		}
+	}
+	return dict, nil
 }
 
 func toOptions(configDetails *types.ConfigDetails, options []func(*Options)) *Options {
@@ -397,12 +484,13 @@ func toOptions(configDetails *types.ConfigDetails, options []func(*Options)) *Op
 			TypeCastMapping: interpolateTypeCastMapping,
 		},
 		ResolvePaths: true,
+		dependencies: &dependencies{},
 	}
 
 	for _, op := range options {
 		op(opts)
 	}
-	opts.ResourceLoaders = append(opts.ResourceLoaders, localResourceLoader{configDetails.WorkingDir})
+	opts.ResourceLoaders = append(opts.ResourceLoaders, localResourceLoader{WorkingDir: configDetails.WorkingDir, fsys: opts.FS})
 	return opts
 }
 
@@ -413,7 +501,17 @@ func loadYamlModel(ctx context.Context, config types.ConfigDetails, opts *Option
 	)
 	workingDir, environment := config.WorkingDir, config.Environment
 
-	for _, file := range config.ConfigFiles {
+	for i, file := range config.ConfigFiles {
+		switch {
+		case file.Content != nil || file.Config != nil:
+			// not read by loader, caller is responsible for tracking origin
+		case len(included) > 0:
+			opts.dependencies.add(file.Filename, types.DependencyInclude, included[len(included)-1])
+		case i == 0:
+			opts.dependencies.add(file.Filename, types.DependencyComposeFile, "")
+		default:
+			opts.dependencies.add(file.Filename, types.DependencyOverride, "")
+		}
 		dict, _, err = loadYamlFile(ctx, file, opts, workingDir, environment, ct, dict, included)
 		if err != nil {
 			return nil, err
@@ -421,6 +519,9 @@ func loadYamlModel(ctx context.Context, config types.ConfigDetails, opts *Option
 	}
 
 	if !opts.SkipDefaultValues {
+		if opts.TargetOS != "" {
+			setMountTargets(dict, opts.TargetOS)
+		}
 		dict, err = transform.SetDefaultValues(dict)
 		if err != nil {
 			return nil, err
@@ -438,7 +539,7 @@ func loadYamlModel(ctx context.Context, config types.ConfigDetails, opts *Option
 		for _, loader := range opts.RemoteResourceLoaders() {
 			remotes = append(remotes, loader.Accept)
 		}
-		err = paths.ResolveRelativePaths(dict, config.WorkingDir, remotes)
+		err = paths.ResolveRelativePathsForOS(opts.filesystem(), opts.TargetOS, dict, config.WorkingDir, remotes)
 		if err != nil {
 			return nil, err
 		}
@@ -451,14 +552,14 @@ func loadYamlModel(ctx context.Context, config types.ConfigDetails, opts *Option
 func loadYamlFile(ctx context.Context, file types.ConfigFile, opts *Options, workingDir string, environment types.Mapping, ct *cycleTracker, dict map[string]interface{}, included []string) (map[string]interface{}, PostProcessor, error) {
 	ctx = context.WithValue(ctx, consts.ComposeFileKey{}, file.Filename)
 	if file.Content == nil && file.Config == nil {
-		content, err := os.ReadFile(file.Filename)
+		content, err := opts.cache.readFile(opts.filesystem(), file.Filename)
 		if err != nil {
 			return nil, nil, err
 		}
 		file.Content = content
 	}
 
-	processRawYaml := func(raw interface{}, processors ...PostProcessor) error {
+	processRawYaml := func(raw interface{}, key string, processors ...PostProcessor) error {
 		converted, err := convertToStringKeysRecursive(raw, "")
 		if err != nil {
 			return err
@@ -469,7 +570,7 @@ func loadYamlFile(ctx context.Context, file types.ConfigFile, opts *Options, wor
 		}
 
 		if opts.Interpolate != nil && !opts.SkipInterpolation {
-			cfg, err = interp.Interpolate(cfg, *opts.Interpolate)
+			cfg, err = opts.cache.interpolate(key, cfg, *opts.Interpolate)
 			if err != nil {
 				return err
 			}
@@ -509,7 +610,7 @@ func loadYamlFile(ctx context.Context, file types.ConfigFile, opts *Options, wor
 		}
 
 		if !opts.SkipValidation {
-			if err := schema.Validate(dict); err != nil {
+			if err := opts.validateSchema(dict); err != nil {
 				return fmt.Errorf("validating %s: %w", file.Filename, err)
 			}
 			if _, ok := dict["version"]; ok {
@@ -518,6 +619,10 @@ func loadYamlFile(ctx context.Context, file types.ConfigFile, opts *Options, wor
 			}
 		}
 
+		if err := opts.checkUnsupported(cfg); err != nil {
+			return fmt.Errorf("validating %s: %w", file.Filename, err)
+		}
+
 		dict, err = transform.Canonical(dict, opts.SkipInterpolation)
 		if err != nil {
 			return err
@@ -532,31 +637,55 @@ func loadYamlFile(ctx context.Context, file types.ConfigFile, opts *Options, wor
 
 	var processor PostProcessor
 	if file.Config == nil {
-		r := bytes.NewReader(file.Content)
-		decoder := yaml.NewDecoder(r)
-		for {
-			var raw interface{}
-			reset := &ResetProcessor{target: &raw}
-			err := decoder.Decode(reset)
-			if err != nil && errors.Is(err, io.EOF) {
-				break
-			}
-			if err != nil {
-				return nil, nil, err
-			}
-			processor = reset
-			if err := processRawYaml(raw, processor); err != nil {
+		documents, err := opts.cache.decodeDocuments(file.Content)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, document := range documents {
+			processor = document.processor
+			if err := processRawYaml(document.raw, document.key, processor); err != nil {
 				return nil, nil, err
 			}
 		}
 	} else {
-		if err := processRawYaml(file.Config); err != nil {
+		if err := processRawYaml(file.Config, ""); err != nil {
 			return nil, nil, err
 		}
 	}
 	return dict, processor, nil
 }
 
+// yamlDocument is a raw yaml document parsed from a compose file
+type yamlDocument struct {
+	// key identifies the document by content, empty if caching is not enabled
+	key       string
+	raw       interface{}
+	processor PostProcessor
+}
+
+// decodeYamlDocuments parses all yaml documents from content
+func decodeYamlDocuments(content []byte) ([]yamlDocument, error) {
+	var documents []yamlDocument
+	r := bytes.NewReader(content)
+	decoder := yaml.NewDecoder(r)
+	for {
+		var raw interface{}
+		reset := &ResetProcessor{target: &raw}
+		err := decoder.Decode(reset)
+		if err != nil && errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, yamlDocument{
+			raw:       raw,
+			processor: reset,
+		})
+	}
+	return documents, nil
+}
+
 func load(ctx context.Context, configDetails types.ConfigDetails, opts *Options, loaded []string) (map[string]interface{}, error) {
 	mainFile := configDetails.ConfigFiles[0].Filename
 	for _, f := range loaded {
@@ -582,7 +711,7 @@ func load(ctx context.Context, configDetails types.ConfigDetails, opts *Options,
 
 	if !opts.SkipNormalization {
 		dict["name"] = opts.projectName
-		dict, err = Normalize(dict, configDetails.Environment)
+		dict, err = normalize(dict, configDetails.Environment, opts.TargetOS)
 		if err != nil {
 			return nil, err
 		}
@@ -611,7 +740,7 @@ func modelToProject(dict map[string]interface{}, opts *Options, configDetails ty
 		return nil, err
 	}
 
-	if opts.ConvertWindowsPaths {
+	if opts.ConvertWindowsPaths && opts.TargetOS != paths.Windows {
 		for i, service := range project.Services {
 			for j, volume := range service.Volumes {
 				service.Volumes[j] = convertVolumePath(volume)
@@ -625,20 +754,30 @@ func modelToProject(dict map[string]interface{}, opts *Options, configDetails ty
 	}
 
 	if !opts.SkipConsistencyCheck {
-		err := checkConsistency(project)
+		err := checkConsistency(project, graph.WithLocator(locator(configDetails, opts)))
 		if err != nil {
 			return nil, err
 		}
+		if opts.TargetOS != "" {
+			if err := checkTargetPaths(project, opts.TargetOS); err != nil {
+				return nil, err
+			}
+		}
 	}
 
+	project.Dependencies = opts.dependencies.resolved(project)
+
 	if !opts.SkipResolveEnvironment {
-		project, err = project.WithServicesEnvironmentResolved(opts.discardEnvFiles)
+		if err := checkServicesEnvFiles(project, opts); err != nil {
+			return nil, err
+		}
+		project, err = project.WithServicesEnvironmentResolvedFS(opts.filesystem(), opts.discardEnvFiles)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	project, err = project.WithServicesLabelsResolved(opts.discardEnvFiles)
+	project, err = project.WithServicesLabelsResolvedFS(opts.filesystem(), opts.discardEnvFiles)
 	if err != nil {
 		return nil, err
 	}
@@ -683,7 +822,7 @@ func projectName(details *types.ConfigDetails, opts *Options) error {
 		if content == nil {
 			// This can be hit when Filename is set but Content is not. One
 			// example is when using ToConfigFiles().
-			d, err := os.ReadFile(configFile.Filename)
+			d, err := opts.cache.readFile(opts.filesystem(), configFile.Filename)
 			if err != nil {
 				return fmt.Errorf("failed to read file %q: %w", configFile.Filename, err)
 			}
@@ -917,14 +1056,6 @@ func formatInvalidKeyError(keyPrefix string, key interface{}) error {
 // Windows path, c:\\my\\path\\shiny, need to be changed to be compatible with
 // the Engine. Volume path are expected to be linux style /c/my/path/shiny/
 func convertVolumePath(volume types.ServiceVolumeConfig) types.ServiceVolumeConfig {
-	volumeName := strings.ToLower(filepath.VolumeName(volume.Source))
-	if len(volumeName) != 2 {
-		return volume
-	}
-
-	convertedSource := fmt.Sprintf("/%c%s", volumeName[0], volume.Source[len(volumeName):])
-	convertedSource = strings.ReplaceAll(convertedSource, "\\", "/")
-
-	volume.Source = convertedSource
+	volume.Source = paths.ConvertWindowsPath(volume.Source)
 	return volume
 }
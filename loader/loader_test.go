@@ -19,11 +19,14 @@ package loader
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -32,6 +35,7 @@ import (
 	is "gotest.tools/v3/assert/cmp"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/vfs"
 )
 
 func buildConfigDetails(yaml string, env map[string]string) types.ConfigDetails {
@@ -2804,7 +2808,8 @@ func TestLoadDependsOnCycle(t *testing.T) {
 			},
 		},
 	})
-	assert.Error(t, err, "dependency cycle detected: service1 -> service2 -> service3 -> service1", err)
+	file := filepath.Join(workingDir, "testdata", "compose-depends-on-cycle.yaml")
+	assert.Error(t, err, fmt.Sprintf("dependency cycle detected: service1 -> service2 (depends_on at %[1]s:6:9) -> service3 (depends_on at %[1]s:10:9) -> service1 (depends_on at %[1]s:14:9): invalid compose project", file))
 }
 
 func TestLoadDependsOnSelf(t *testing.T) {
@@ -2818,7 +2823,8 @@ func TestLoadDependsOnSelf(t *testing.T) {
 			},
 		},
 	})
-	assert.Error(t, err, "dependency cycle detected: service1 -> service1", err)
+	file := filepath.Join(workingDir, "testdata", "compose-depends-on-self.yaml")
+	assert.Error(t, err, fmt.Sprintf("dependency cycle detected: service1 -> service1 (depends_on at %s:6:9): invalid compose project", file))
 }
 
 func TestLoadWithDependsOn(t *testing.T) {
@@ -2975,7 +2981,7 @@ services:
 			customLoader{prefix: "remote"},
 		}
 	})
-	assert.ErrorContains(t, err, "Circular reference")
+	assert.Error(t, err, "dependency cycle detected: foo -> bar (extends at remote:cycle/compose.yaml) -> foo (extends at remote:cycle/compose-cycle.yaml): invalid compose project")
 }
 
 func TestLoadMulmtiDocumentYaml(t *testing.T) {
@@ -3322,6 +3328,51 @@ services:
 	assert.Equal(t, magic.Foo, "bar")
 }
 
+func TestKnownExtensionsSchema(t *testing.T) {
+	yaml := `
+name: test-known-extensions-schema
+services:
+  test:
+    image: foo
+    x-monitoring:
+      port: %s
+x-team: %s
+`
+	type Monitoring struct {
+		Port int `yaml:"port"`
+	}
+	load := func(port, team string) error {
+		_, err := LoadWithContext(context.Background(), types.ConfigDetails{
+			ConfigFiles: []types.ConfigFile{
+				{
+					Filename: "compose.yaml",
+					Content:  []byte(fmt.Sprintf(yaml, port, team)),
+				},
+			},
+			Environment: map[string]string{"MONITORING_PORT": "9090"},
+		}, func(options *Options) {
+			options.KnownExtensions = map[string]any{
+				"x-monitoring": Monitoring{},
+			}
+			options.ExtensionSchemas = map[string]map[string]any{
+				"x-monitoring": nil,
+				"x-team": {
+					"type":    "string",
+					"pattern": "^[a-z]+$",
+				},
+			}
+		})
+		return err
+	}
+
+	assert.NilError(t, load("9090", "backend"))
+	assert.NilError(t, load("${MONITORING_PORT}", "backend"))
+	assert.ErrorContains(t, load("[9090]", "backend"),
+		"validating compose.yaml: services.test.x-monitoring.port must be a integer")
+	assert.ErrorContains(t, load("9090", "Backend"),
+		"validating compose.yaml: x-team Does not match pattern")
+}
+
 func TestLoadWithEmptyFile(t *testing.T) {
 	yaml := `
 name: test-with-empty-file
@@ -3636,3 +3687,96 @@ services:
 	assert.NilError(t, err)
 	assert.Equal(t, len(p.Services["test"].DNS), 0)
 }
+
+func TestLoadFromFS(t *testing.T) {
+	root := filepath.FromSlash("/project")
+	fsys := vfs.FromFS(fstest.MapFS{
+		"compose.yaml": {Data: []byte(`
+include:
+  - db/compose.yaml
+services:
+  app:
+    extends:
+      file: base.yaml
+      service: base
+    env_file: app.env
+    label_file: [app.labels]
+`)},
+		"base.yaml": {Data: []byte(`
+services:
+  base:
+    image: ${IMAGE}
+`)},
+		"app.env":    {Data: []byte("MODE=prod\n")},
+		"app.labels": {Data: []byte("team=core\n")},
+		"db/compose.yaml": {Data: []byte(`
+services:
+  db:
+    image: postgres
+    env_file: db.env
+`)},
+		"db/db.env": {Data: []byte("PGDATA=/data\n")},
+		"db/.env":   {Data: []byte("UNUSED=true\n")},
+	}, root)
+
+	p, err := LoadWithContext(context.Background(), types.ConfigDetails{
+		WorkingDir:  root,
+		ConfigFiles: []types.ConfigFile{{Filename: filepath.Join(root, "compose.yaml")}},
+		Environment: map[string]string{"IMAGE": "nginx"},
+	}, func(options *Options) {
+		options.SetProjectName("demo", true)
+		options.FS = fsys
+		options.VerifyPaths = true
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, p.Services["app"].Image, "nginx")
+	assert.DeepEqual(t, p.Services["app"].Environment, types.NewMappingWithEquals([]string{"MODE=prod"}))
+	assert.DeepEqual(t, p.Services["app"].Labels, types.Labels{"team": "core"})
+	assert.DeepEqual(t, p.Services["db"].Environment, types.NewMappingWithEquals([]string{"PGDATA=/data"}))
+
+	_, err = LoadWithContext(context.Background(), types.ConfigDetails{
+		WorkingDir:  root,
+		ConfigFiles: []types.ConfigFile{{Filename: filepath.Join(root, "missing.yaml")}},
+	}, func(options *Options) {
+		options.SetProjectName("demo", true)
+		options.FS = fsys
+	})
+	assert.Check(t, errors.Is(err, fs.ErrNotExist), err)
+}
+
+func TestLoadEnvFileFormats(t *testing.T) {
+	root := filepath.FromSlash("/srv/app")
+	fsys := vfs.FromFS(fstest.MapFS{
+		"compose.yaml": {Data: []byte(`
+services:
+  app:
+    image: app
+    env_file:
+      - app.json
+      - app.yaml
+      - app.properties
+      - path: app.conf
+        format: properties
+`)},
+		"app.json":       {Data: []byte(`{"JSON": "json ${HOST}"}`)},
+		"app.yaml":       {Data: []byte("YAML: yaml\n")},
+		"app.properties": {Data: []byte("db.url: jdbc:postgresql://${HOST}/app\n")},
+		"app.conf":       {Data: []byte("conf = true\n")},
+	}, root)
+
+	p, err := LoadWithContext(context.Background(), types.ConfigDetails{
+		WorkingDir:  root,
+		ConfigFiles: []types.ConfigFile{{Filename: filepath.Join(root, "compose.yaml")}},
+		Environment: map[string]string{"HOST": "db"},
+	}, func(options *Options) {
+		options.SetProjectName("demo", true)
+		options.FS = fsys
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, p.Services["app"].Environment, types.NewMappingWithEquals([]string{
+		"JSON=json db",
+		"YAML=yaml",
+		"db.url=jdbc:postgresql://db/app",
+		"conf=true",
+	}))
+}
@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/compose-spec/compose-go/v2/graph"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Locate returns a function to resolve the location of an attribute in compose files, as `file:line:column`.
+// When an attribute is declared by multiple files, the last one, which overrides the others, is reported.
+// Lists items can be selected by index, or by value for lists of strings.
+// Compose files without Content are read from the FS set by options, default to host filesystem.
+func Locate(configDetails types.ConfigDetails, options ...func(*Options)) (func(tree.Path) string, error) {
+	opts := &Options{}
+	for _, op := range options {
+		op(opts)
+	}
+	return locate(configDetails, opts)
+}
+
+// locator resolves locations on first use, as compose files only need to be parsed again to report errors
+func locator(configDetails types.ConfigDetails, opts *Options) graph.Locator {
+	var (
+		once    sync.Once
+		resolve func(tree.Path) string
+	)
+	return func(path tree.Path) string {
+		once.Do(func() {
+			resolve, _ = locate(configDetails, opts)
+		})
+		if resolve == nil {
+			return ""
+		}
+		return resolve(path)
+	}
+}
+
+func locate(configDetails types.ConfigDetails, opts *Options) (func(tree.Path) string, error) {
+	type document struct {
+		filename string
+		root     *yaml.Node
+	}
+	var documents []document
+	for _, file := range configDetails.ConfigFiles {
+		content := file.Content
+		if content == nil && file.Config == nil {
+			b, err := opts.filesystem().ReadFile(file.Filename)
+			if err != nil {
+				return nil, err
+			}
+			content = b
+		}
+		if content == nil {
+			// parsed model doesn't retain locations
+			continue
+		}
+		var root yaml.Node
+		if err := yaml.Unmarshal(content, &root); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file.Filename, err)
+		}
+		documents = append(documents, document{filename: file.Filename, root: &root})
+	}
+
+	return func(path tree.Path) string {
+		for i := len(documents) - 1; i >= 0; i-- {
+			if node := findNode(documents[i].root, path.Parts()); node != nil {
+				return fmt.Sprintf("%s:%d:%d", documents[i].filename, node.Line, node.Column)
+			}
+		}
+		return ""
+	}, nil
+}
+
+func findNode(node *yaml.Node, parts []string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if len(parts) == 0 {
+		return node
+	}
+	part := tree.Path(parts[0]).String()
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value != part {
+				continue
+			}
+			if len(parts) == 1 {
+				// report the key position
+				return node.Content[i]
+			}
+			return findNode(node.Content[i+1], parts[1:])
+		}
+	case yaml.SequenceNode:
+		if index, err := strconv.Atoi(part); err == nil && index >= 0 && index < len(node.Content) {
+			return findNode(node.Content[index], parts[1:])
+		}
+		for _, item := range node.Content {
+			if item.Kind == yaml.ScalarNode && item.Value == part {
+				return findNode(item, parts[1:])
+			}
+		}
+	}
+	return nil
+}
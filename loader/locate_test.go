@@ -0,0 +1,111 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/compose-spec/compose-go/v2/graph"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/vfs"
+	"gotest.tools/v3/assert"
+)
+
+func TestLocate(t *testing.T) {
+	details := types.ConfigDetails{
+		WorkingDir: t.TempDir(),
+		ConfigFiles: []types.ConfigFile{
+			{Filename: "compose.yaml", Content: []byte(`
+services:
+  web:
+    image: nginx
+    depends_on: [api]
+  api:
+    image: api
+    volumes_from:
+      - web
+`)},
+			{Filename: "override.yaml", Content: []byte(`
+services:
+  api:
+    links:
+      - web:frontend
+`)},
+		},
+		Environment: map[string]string{},
+	}
+
+	locate, err := Locate(details)
+	assert.NilError(t, err)
+	assert.Equal(t, locate("services.web.image"), "compose.yaml:4:5")
+	assert.Equal(t, locate("services.web.depends_on.api"), "compose.yaml:5:18")
+	assert.Equal(t, locate("services.api.links.0"), "override.yaml:5:9")
+	assert.Equal(t, locate("services.api"), "override.yaml:3:3")
+	assert.Equal(t, locate("services.api.ports"), "")
+
+	project, err := LoadWithContext(context.Background(), details, func(options *Options) {
+		options.SetProjectName("demo", true)
+		options.SkipConsistencyCheck = true
+	})
+	assert.NilError(t, err)
+	cycles := graph.Cycles(project, graph.WithLocator(locate))
+	assert.Equal(t, len(cycles), 1)
+	assert.Equal(t, cycles[0].String(),
+		"api -> web (links at override.yaml:5:9, volumes_from at compose.yaml:9:9) -> api (depends_on at compose.yaml:5:18)")
+}
+
+func TestLocateCyclesFromFS(t *testing.T) {
+	root := filepath.FromSlash("/project")
+	fsys := vfs.FromFS(fstest.MapFS{
+		"compose.yaml": {Data: []byte(`
+services:
+  a:
+    image: a
+    depends_on: [b]
+  b:
+    image: b
+    links: [a]
+    depends_on: [c]
+  c:
+    image: c
+    network_mode: service:b
+`)},
+	}, root)
+	file := filepath.Join(root, "compose.yaml")
+	details := types.ConfigDetails{
+		WorkingDir:  root,
+		ConfigFiles: []types.ConfigFile{{Filename: file}},
+		Environment: map[string]string{},
+	}
+
+	locate, err := Locate(details, func(options *Options) {
+		options.FS = fsys
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, locate("services.b.links.0"), file+":8:13")
+
+	_, err = LoadWithContext(context.Background(), details, func(options *Options) {
+		options.SetProjectName("demo", true)
+		options.FS = fsys
+	})
+	assert.Error(t, err, fmt.Sprintf(`dependency cycle detected: a -> b (depends_on at %[1]s:5:18) -> a (links at %[1]s:8:13): invalid compose project
+dependency cycle detected: b -> c (depends_on at %[1]s:9:18) -> b (network_mode at %[1]s:12:5): invalid compose project`, file))
+}
@@ -18,15 +18,20 @@ package loader
 
 import (
 	"fmt"
-	"path"
 	"strconv"
 	"strings"
 
+	"github.com/compose-spec/compose-go/v2/paths"
 	"github.com/compose-spec/compose-go/v2/types"
 )
 
 // Normalize compose project by moving deprecated attributes to their canonical position and injecting implicit defaults
 func Normalize(dict map[string]any, env types.Mapping) (map[string]any, error) {
+	return normalize(dict, env, "")
+}
+
+// normalize is Normalize, cleaning container paths according to targetOS
+func normalize(dict map[string]any, env types.Mapping, targetOS string) (map[string]any, error) {
 	normalizeNetworks(dict)
 
 	if d, ok := dict["services"]; ok {
@@ -103,12 +108,16 @@ func Normalize(dict map[string]any, env types.Mapping) (map[string]any, error) {
 				}
 			}
 
+			if wd, ok := service["working_dir"].(string); ok && targetOS != "" {
+				service["working_dir"] = paths.CleanTarget(targetOS, wd)
+			}
+
 			if v, ok := service["volumes"]; ok {
 				volumes := v.([]any)
 				for i, volume := range volumes {
 					vol := volume.(map[string]any)
 					target := vol["target"].(string)
-					vol["target"] = path.Clean(target)
+					vol["target"] = paths.CleanTarget(targetOS, target)
 					volumes[i] = vol
 				}
 				service["volumes"] = volumes
@@ -261,3 +270,33 @@ func isTrue(x any) bool {
 	parseBool, _ := strconv.ParseBool(fmt.Sprint(x))
 	return parseBool
 }
+
+// setMountTargets sets configs and secrets mounts target to the default location for targetOS, or makes
+// relative targets absolute
+func setMountTargets(dict map[string]any, targetOS string) {
+	services, ok := dict["services"].(map[string]any)
+	if !ok {
+		return
+	}
+	for _, s := range services {
+		service, ok := s.(map[string]any)
+		if !ok {
+			continue
+		}
+		for key, target := range map[string]func(string, string, string) string{
+			"configs": paths.ConfigTarget,
+			"secrets": paths.SecretTarget,
+		} {
+			mounts, _ := service[key].([]any)
+			for _, m := range mounts {
+				mount, ok := m.(map[string]any)
+				if !ok {
+					continue
+				}
+				source, _ := mount["source"].(string)
+				t, _ := mount["target"].(string)
+				mount["target"] = target(targetOS, source, t)
+			}
+		}
+	}
+}
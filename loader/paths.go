@@ -17,11 +17,14 @@
 package loader
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/compose-spec/compose-go/v2/paths"
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/vfs"
 )
 
 // ResolveRelativePaths resolves relative paths based on project WorkingDirectory
@@ -72,3 +75,39 @@ func resolvePaths(basePath string, in types.StringList) types.StringList {
 	}
 	return ret
 }
+
+// verifyPaths checks local paths declared by services enabled by profiles, and by top-level resources, do exist
+func verifyPaths(fsys vfs.FS, dict map[string]any, workingDir string, profiles []string) error {
+	model := map[string]any{}
+	for k, v := range dict {
+		model[k] = v
+	}
+	if s, ok := dict["services"].(map[string]any); ok {
+		services := map[string]any{}
+		for name, service := range s {
+			m, ok := service.(map[string]any)
+			if !ok {
+				// null or invalid service definitions are reported by validation
+				continue
+			}
+			var config types.ServiceConfig
+			if sp, ok := m["profiles"].([]any); ok {
+				for _, p := range sp {
+					if profile, ok := p.(string); ok {
+						config.Profiles = append(config.Profiles, profile)
+					}
+				}
+			}
+			if config.HasProfile(profiles) {
+				services[name] = service
+			}
+		}
+		model["services"] = services
+	}
+
+	var errs []error
+	for _, missing := range paths.VerifyPaths(fsys, model, workingDir) {
+		errs = append(errs, missing)
+	}
+	return errors.Join(errs...)
+}
@@ -17,12 +17,16 @@
 package loader
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/compose-spec/compose-go/v2/errdefs"
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/vfs"
 	"gotest.tools/v3/assert"
 )
 
@@ -106,3 +110,64 @@ services:
 	}
 	assert.DeepEqual(t, expected, *project.Services["test"].Build)
 }
+
+func TestVerifyPaths(t *testing.T) {
+	dir := t.TempDir()
+	assert.NilError(t, os.Mkdir(filepath.Join(dir, "app"), 0o700))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "app.env"), nil, 0o600))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "config.txt"), nil, 0o600))
+
+	yaml := `
+services:
+  app:
+    build: ./app
+    env_file:
+      - app.env
+      - path: optional.env
+        required: false
+      - missing.env
+    volumes:
+      - type: bind
+        source: ./data
+        target: /data
+      - ./created:/created
+  web:
+    build: ./web
+    configs:
+      - site
+  debug:
+    build: ./debug
+    profiles: [debug]
+configs:
+  site:
+    file: ./app
+secrets:
+  key:
+    file: ./config.txt
+`
+	_, err := LoadWithContext(context.Background(), types.ConfigDetails{
+		WorkingDir:  dir,
+		ConfigFiles: []types.ConfigFile{{Filename: "compose.yaml", Content: []byte(yaml)}},
+		Environment: map[string]string{},
+	}, func(options *Options) {
+		options.SetProjectName("test", true)
+		options.VerifyPaths = true
+	})
+	assert.Check(t, errors.Is(err, errdefs.ErrNotFound))
+	assert.Check(t, errors.Is(err, errdefs.ErrInvalid))
+	assert.Error(t, err, fmt.Sprintf(`configs.site.file: %[1]s/app is not a file
+services.app.env_file.2.path: file %[1]s/missing.env does not exist
+services.app.volumes.0.source: path %[1]s/data does not exist
+services.web.build.context: directory %[1]s/web does not exist`, dir))
+}
+
+func TestVerifyPathsSkipsNullService(t *testing.T) {
+	dict := map[string]any{
+		"services": map[string]any{
+			"null": nil,
+			"app":  map[string]any{"build": map[string]any{"context": "./app"}, "profiles": []any{nil}},
+		},
+	}
+	err := verifyPaths(vfs.Or(nil), dict, t.TempDir(), []string{"*"})
+	assert.Check(t, errors.Is(err, errdefs.ErrNotFound))
+}
@@ -0,0 +1,170 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+// Session loads a compose project and keeps parsed files, interpolation results and included models
+// in cache, so that long-running tools can reload the project after some files changed without
+// computing the whole model from scratch.
+type Session struct {
+	mu      sync.Mutex
+	details types.ConfigDetails
+	options []func(*Options)
+	cache   *loadCache
+	project *types.Project
+}
+
+// NewSession creates a Session to load the project defined by configDetails
+func NewSession(configDetails types.ConfigDetails, options ...func(*Options)) *Session {
+	return &Session{
+		details: configDetails,
+		options: options,
+		cache:   newLoadCache(),
+	}
+}
+
+// Project returns the project computed by last successful load, if any
+func (s *Session) Project() *types.Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.project
+}
+
+// Load loads the project, reusing any result already in cache
+func (s *Session) Load(ctx context.Context) (*types.Project, error) {
+	project, _, err := s.Reload(ctx)
+	return project, err
+}
+
+// Reload loads the project again after changedFiles have been modified, and returns the new project
+// with the changes compared to the previous one. Files which are not listed in changedFiles are
+// considered unchanged and are not read again.
+func (s *Session) Reload(ctx context.Context, changedFiles ...string) (*types.Project, ProjectDiff, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cache.invalidate(changedFiles...)
+	details := s.details
+	details.ConfigFiles = make([]types.ConfigFile, len(s.details.ConfigFiles))
+	for i, f := range s.details.ConfigFiles {
+		if isChanged(f.Filename, changedFiles) {
+			// content may have been set by caller, we need to read the actual file
+			f.Content = nil
+			f.Config = nil
+		}
+		details.ConfigFiles[i] = f
+	}
+	details.Environment = s.details.Environment.Clone()
+	s.details.ConfigFiles = details.ConfigFiles
+
+	options := append([]func(*Options){}, s.options...)
+	options = append(options, func(o *Options) {
+		o.cache = s.cache
+	})
+	project, err := LoadWithContext(ctx, details, options...)
+	if err != nil {
+		return nil, ProjectDiff{}, err
+	}
+	s.cache.prune()
+
+	diff := DiffProjects(s.project, project)
+	s.project = project
+	return project, diff, nil
+}
+
+func isChanged(filename string, changedFiles []string) bool {
+	filename = cacheKey(filename)
+	for _, c := range changedFiles {
+		if cacheKey(c) == filename {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectDiff describes changes between two versions of a project
+type ProjectDiff struct {
+	Services ResourceDiff
+	Networks ResourceDiff
+	Volumes  ResourceDiff
+	Secrets  ResourceDiff
+	Configs  ResourceDiff
+}
+
+// IsEmpty returns true if there's no change
+func (d ProjectDiff) IsEmpty() bool {
+	return d.Services.IsEmpty() && d.Networks.IsEmpty() && d.Volumes.IsEmpty() && d.Secrets.IsEmpty() && d.Configs.IsEmpty()
+}
+
+// ResourceDiff lists resources (sorted by name) which have been added, removed or changed
+type ResourceDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// IsEmpty returns true if there's no change
+func (d ResourceDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffProjects compares resources declared by two projects. A nil project is considered empty
+func DiffProjects(before, after *types.Project) ProjectDiff {
+	if before == nil {
+		before = &types.Project{}
+	}
+	if after == nil {
+		after = &types.Project{}
+	}
+	return ProjectDiff{
+		Services: diffResources(before.Services, after.Services),
+		Networks: diffResources(before.Networks, after.Networks),
+		Volumes:  diffResources(before.Volumes, after.Volumes),
+		Secrets:  diffResources(before.Secrets, after.Secrets),
+		Configs:  diffResources(before.Configs, after.Configs),
+	}
+}
+
+func diffResources[T any](before, after map[string]T) ResourceDiff {
+	var diff ResourceDiff
+	for name, a := range after {
+		b, ok := before[name]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, name)
+		case !reflect.DeepEqual(a, b):
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
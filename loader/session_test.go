@@ -0,0 +1,153 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+func TestSessionReload(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "compose.yaml")
+	included := filepath.Join(dir, "included.yaml")
+	assert.NilError(t, os.WriteFile(main, []byte(`
+name: session
+include:
+  - included.yaml
+services:
+  foo:
+    image: foo:${TAG}
+`), 0o600))
+	assert.NilError(t, os.WriteFile(included, []byte(`
+services:
+  bar:
+    image: bar
+`), 0o600))
+
+	session := NewSession(types.ConfigDetails{
+		WorkingDir:  dir,
+		ConfigFiles: []types.ConfigFile{{Filename: main}},
+		Environment: types.Mapping{"TAG": "1.0"},
+	})
+	ctx := context.Background()
+	_, diff, err := session.Reload(ctx)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diff.Services.Added, []string{"bar", "foo"})
+
+	p, diff, err := session.Reload(ctx)
+	assert.NilError(t, err)
+	assert.Check(t, diff.IsEmpty())
+	assert.Equal(t, p.Services["foo"].Image, "foo:1.0")
+
+	// files not reported as changed are not read again
+	assert.NilError(t, os.WriteFile(included, []byte(`
+services:
+  bar:
+    image: bar:2.0
+  zot:
+    image: zot
+`), 0o600))
+	p, diff, err = session.Reload(ctx)
+	assert.NilError(t, err)
+	assert.Check(t, diff.IsEmpty())
+	assert.Equal(t, p.Services["bar"].Image, "bar")
+
+	p, diff, err = session.Reload(ctx, included)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diff.Services, ResourceDiff{
+		Added:   []string{"zot"},
+		Changed: []string{"bar"},
+	})
+	assert.Equal(t, p.Services["bar"].Image, "bar:2.0")
+	assert.Equal(t, p.Services["foo"].Image, "foo:1.0")
+
+	assert.NilError(t, os.WriteFile(main, []byte(`
+name: session
+include:
+  - included.yaml
+services:
+  foo:
+    image: foo:${TAG}
+    command: echo
+`), 0o600))
+	p, diff, err = session.Reload(ctx, main)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diff.Services, ResourceDiff{
+		Changed: []string{"foo"},
+	})
+	assert.DeepEqual(t, p.Services["foo"].Command, types.ShellCommand{"echo"})
+	assert.Equal(t, p.Services["zot"].Image, "zot")
+}
+
+func TestSessionReloadRelativePath(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	assert.NilError(t, err)
+	assert.NilError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	session := NewSession(types.ConfigDetails{
+		WorkingDir: dir,
+		ConfigFiles: []types.ConfigFile{{
+			Filename: "compose.yaml",
+			Content:  []byte("name: session\nservices:\n  foo:\n    image: foo\n"),
+		}},
+	})
+	ctx := context.Background()
+	_, _, err = session.Reload(ctx)
+	assert.NilError(t, err)
+
+	main := filepath.Join(dir, "compose.yaml")
+	assert.NilError(t, os.WriteFile(main, []byte("name: session\nservices:\n  foo:\n    image: foo:2.0\n"), 0o600))
+	p, diff, err := session.Reload(ctx, main)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diff.Services.Changed, []string{"foo"})
+	assert.Equal(t, p.Services["foo"].Image, "foo:2.0")
+}
+
+func TestDiffProjects(t *testing.T) {
+	before := &types.Project{
+		Services: types.Services{
+			"foo": {Name: "foo", Image: "foo"},
+			"bar": {Name: "bar", Image: "bar"},
+		},
+		Volumes: types.Volumes{
+			"data": {Name: "data"},
+		},
+	}
+	after := &types.Project{
+		Services: types.Services{
+			"foo": {Name: "foo", Image: "foo:latest"},
+		},
+		Volumes: types.Volumes{
+			"data": {Name: "data"},
+		},
+	}
+	diff := DiffProjects(before, after)
+	assert.DeepEqual(t, diff, ProjectDiff{
+		Services: ResourceDiff{
+			Removed: []string{"bar"},
+			Changed: []string{"foo"},
+		},
+	})
+}
@@ -0,0 +1,137 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/schema"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/sirupsen/logrus"
+)
+
+// Strictness defines how loader handles attributes declared by Options.Unsupported
+type Strictness int
+
+const (
+	// StrictnessIgnore silently accepts attributes target runtime doesn't support
+	StrictnessIgnore Strictness = iota
+	// StrictnessWarn logs a warning for attributes target runtime doesn't support
+	StrictnessWarn
+	// StrictnessError rejects attributes target runtime doesn't support with errdefs.ErrUnsupported
+	StrictnessError
+)
+
+// UnsupportedError reports an attribute which is valid but not supported by target runtime
+type UnsupportedError struct {
+	// Path is the dotted path to attribute, sequence items being set by index
+	Path string
+}
+
+func (e UnsupportedError) Error() string {
+	return fmt.Sprintf("%s is not supported by target runtime", e.Path)
+}
+
+func (e UnsupportedError) Unwrap() error {
+	return errdefs.ErrUnsupported
+}
+
+// Field returns the dotted path to the unsupported attribute
+func (e UnsupportedError) Field() string {
+	return e.Path
+}
+
+// checkUnsupported looks for attributes matching one of the unsupported paths, and reports them according to strictness
+func (o *Options) checkUnsupported(dict map[string]any) error {
+	if o.Strictness == StrictnessIgnore || len(o.Unsupported) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, found := range findUnsupported(dict, tree.NewPath(), "", o.Unsupported) {
+		if o.Strictness == StrictnessError {
+			errs = append(errs, UnsupportedError{Path: found})
+			continue
+		}
+		logrus.Warnf("%s is not supported by target runtime and will be ignored", found)
+	}
+	return errors.Join(errs...)
+}
+
+// UnsupportedBy returns the attributes a Docker Compose release, like `2.21.0`, doesn't support,
+// to be set as Options.Unsupported when targeting an older runtime
+func UnsupportedBy(release string) ([]tree.Path, error) {
+	attributes, err := schema.UnsupportedBy(release)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]tree.Path, len(attributes))
+	for i, a := range attributes {
+		paths[i] = tree.Path(a)
+	}
+	return paths, nil
+}
+
+// findUnsupported walks value to collect paths matching unsupported patterns.
+// p is used to match patterns, with tree.PathMatchList for sequence items, while field reports the actual index
+func findUnsupported(value any, p tree.Path, field string, unsupported []tree.Path) []string {
+	var found []string
+	next := func(part string, key string) (tree.Path, string) {
+		if field == "" {
+			return p.Next(part), key
+		}
+		return p.Next(part), field + "." + key
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			np, nf := next(k, k)
+			if matchesAny(np, unsupported) {
+				found = append(found, nf)
+				continue
+			}
+			found = append(found, findUnsupported(v[k], np, nf, unsupported)...)
+		}
+	case []any:
+		for i, item := range v {
+			np, nf := next(tree.PathMatchList, strconv.Itoa(i))
+			if matchesAny(np, unsupported) {
+				found = append(found, nf)
+				continue
+			}
+			found = append(found, findUnsupported(item, np, nf, unsupported)...)
+		}
+	}
+	return found
+}
+
+func matchesAny(p tree.Path, patterns []tree.Path) bool {
+	for _, pattern := range patterns {
+		if p.Matches(pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/schema"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+func TestStrictness(t *testing.T) {
+	yaml := `
+name: test-strictness
+services:
+  test:
+    image: foo
+    gpus:
+      - driver: nvidia
+        count: 1
+    develop:
+      watch:
+        - path: ./src
+          action: sync
+          target: /src
+`
+	load := func(strictness Strictness, unsupported ...tree.Path) error {
+		_, err := LoadWithContext(context.Background(), types.ConfigDetails{
+			ConfigFiles: []types.ConfigFile{
+				{Filename: "compose.yaml", Content: []byte(yaml)},
+			},
+		}, func(options *Options) {
+			options.Strictness = strictness
+			options.Unsupported = unsupported
+		})
+		return err
+	}
+
+	assert.NilError(t, load(StrictnessIgnore, "services.*.develop", "services.*.gpus"))
+	assert.NilError(t, load(StrictnessWarn, "services.*.develop", "services.*.gpus"))
+	assert.NilError(t, load(StrictnessError))
+
+	err := load(StrictnessError, "services.*.gpus", "services.*.develop")
+	assert.Check(t, errors.Is(err, errdefs.ErrUnsupported))
+	assert.Error(t, err, "validating compose.yaml: services.test.develop is not supported by target runtime\n"+
+		"services.test.gpus is not supported by target runtime")
+
+	err = load(StrictnessError, "services.*.develop.watch.[].action")
+	var unsupported UnsupportedError
+	assert.Check(t, errors.As(err, &unsupported))
+	assert.Equal(t, unsupported.Field(), "services.test.develop.watch.0.action")
+}
+
+func TestUnsupportedBy(t *testing.T) {
+	paths, err := UnsupportedBy("2.22.1")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, paths, []tree.Path{
+		"services.*.gpus", "services.*.post_start", "services.*.pre_stop", "services.*.label_file",
+	})
+
+	paths, err = UnsupportedBy("2.32")
+	assert.NilError(t, err)
+	assert.Equal(t, len(paths), 0)
+
+	_, err = UnsupportedBy("latest")
+	assert.ErrorContains(t, err, `invalid Docker Compose release "latest"`)
+}
+
+func TestSchemaVersion(t *testing.T) {
+	yaml := `
+name: test-schema-version
+services:
+  test:
+    image: foo
+    log_driver: syslog
+`
+	load := func(version schema.Version) error {
+		_, err := LoadWithContext(context.Background(), types.ConfigDetails{
+			ConfigFiles: []types.ConfigFile{
+				{Filename: "compose.yaml", Content: []byte(yaml)},
+			},
+		}, func(options *Options) {
+			options.SchemaVersion = version
+		})
+		return err
+	}
+
+	assert.ErrorContains(t, load(""), "Additional property log_driver is not allowed")
+	assert.ErrorContains(t, load(schema.Latest), "Additional property log_driver is not allowed")
+	assert.NilError(t, load(schema.Legacy))
+	assert.ErrorContains(t, load(schema.Compose230), "Additional property log_driver is not allowed")
+	err := load("1.0")
+	assert.Check(t, errors.Is(err, errdefs.ErrUnsupported))
+}
@@ -0,0 +1,172 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package loader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+func TestConvertWindowsVolumePath(t *testing.T) {
+	var testcases = []struct {
+		windowsPath           string
+		expectedConvertedPath string
+	}{
+		{
+			windowsPath:           "c:\\hello\\docker",
+			expectedConvertedPath: "/c/hello/docker",
+		},
+		{
+			windowsPath:           "d:\\compose",
+			expectedConvertedPath: "/d/compose",
+		},
+		{
+			windowsPath:           "e:\\path with spaces\\compose",
+			expectedConvertedPath: "/e/path with spaces/compose",
+		},
+		{
+			windowsPath:           "/already/unix",
+			expectedConvertedPath: "/already/unix",
+		},
+	}
+	for _, testcase := range testcases {
+		volume := types.ServiceVolumeConfig{
+			Type:   "bind",
+			Source: testcase.windowsPath,
+			Target: "/test",
+		}
+
+		assert.Equal(t, testcase.expectedConvertedPath, convertVolumePath(volume).Source)
+	}
+}
+
+func loadForOS(t *testing.T, targetOS string, yaml string, options ...func(*Options)) (*types.Project, error) {
+	t.Helper()
+	return LoadWithContext(context.TODO(), types.ConfigDetails{
+		WorkingDir:  "/work",
+		ConfigFiles: []types.ConfigFile{{Filename: "compose.yaml", Content: []byte(yaml)}},
+		Environment: types.Mapping{},
+	}, append([]func(*Options){func(o *Options) {
+		o.SetProjectName("target", true)
+		o.TargetOS = targetOS
+	}}, options...)...)
+}
+
+func TestTargetOSWindows(t *testing.T) {
+	p, err := loadForOS(t, "windows", `
+services:
+  app:
+    image: mcr.microsoft.com/windows/nanoserver
+    working_dir: C:/app/bin/..
+    volumes:
+      - type: bind
+        source: 'C:\data'
+        target: 'C:\data\'
+      - type: npipe
+        source: '\\.\pipe\docker_engine'
+        target: '\\.\pipe\docker_engine'
+    configs:
+      - site
+      - source: site
+        target: conf\site.conf
+    secrets:
+      - key
+configs:
+  site:
+    content: x
+secrets:
+  key:
+    environment: KEY
+`, func(o *Options) {
+		o.ConvertWindowsPaths = true
+	})
+	assert.NilError(t, err)
+	app := p.Services["app"]
+	assert.Equal(t, app.WorkingDir, `C:\app`)
+	assert.Equal(t, app.Volumes[0].Source, `C:\data`)
+	assert.Equal(t, app.Volumes[0].Target, `C:\data`)
+	assert.Equal(t, app.Volumes[1].Target, `\\.\pipe\docker_engine`)
+	assert.Equal(t, app.Configs[0].Target, `C:\site`)
+	assert.Equal(t, app.Configs[1].Target, `C:\conf\site.conf`)
+	assert.Equal(t, app.Secrets[0].Target, `C:\ProgramData\Docker\secrets\key`)
+}
+
+func TestTargetOSLinux(t *testing.T) {
+	p, err := loadForOS(t, "linux", `
+services:
+  app:
+    image: alpine
+    volumes:
+      - type: bind
+        source: 'C:\data'
+        target: /data/
+    configs:
+      - site
+    secrets:
+      - source: key
+        target: db/key
+configs:
+  site:
+    content: x
+secrets:
+  key:
+    environment: KEY
+`, func(o *Options) {
+		o.ConvertWindowsPaths = true
+	})
+	assert.NilError(t, err)
+	app := p.Services["app"]
+	assert.Equal(t, app.Volumes[0].Source, "/c/data")
+	assert.Equal(t, app.Volumes[0].Target, "/data")
+	assert.Equal(t, app.Configs[0].Target, "/site")
+	assert.Equal(t, app.Secrets[0].Target, "/run/secrets/db/key")
+}
+
+func TestTargetOSInvalidPaths(t *testing.T) {
+	_, err := loadForOS(t, "linux", `
+services:
+  app:
+    image: alpine
+    working_dir: 'C:\app'
+    volumes:
+      - type: volume
+        source: data
+        target: 'C:\data'
+volumes:
+  data: {}
+`)
+	assert.Error(t, err, `services.app.working_dir: C:\app is not an absolute linux path: invalid compose project
+services.app.volumes[0].target: C:\data is not an absolute linux path: invalid compose project`)
+
+	_, err = loadForOS(t, "windows", `
+services:
+  app:
+    image: app
+    working_dir: /app
+`)
+	assert.Error(t, err, `services.app.working_dir: \app is not an absolute windows path: invalid compose project`)
+
+	_, err = loadForOS(t, "darwin", `
+services:
+  app:
+    image: app
+`)
+	assert.Error(t, err, `unsupported target OS "darwin", must be linux or windows`)
+}
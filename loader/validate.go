@@ -19,15 +19,21 @@ package loader
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
 	"strings"
 
 	"github.com/compose-spec/compose-go/v2/errdefs"
 	"github.com/compose-spec/compose-go/v2/graph"
+	"github.com/compose-spec/compose-go/v2/paths"
+	"github.com/compose-spec/compose-go/v2/transform"
+	"github.com/compose-spec/compose-go/v2/tree"
 	"github.com/compose-spec/compose-go/v2/types"
 )
 
-// checkConsistency validate a compose model is consistent
-func checkConsistency(project *types.Project) error {
+// checkConsistency validate a compose model is consistent. Options are used to locate dependency cycles in compose files
+func checkConsistency(project *types.Project, options ...func(*graph.CyclesOptions)) error {
 	for _, s := range project.Services {
 		if s.Build == nil && s.Image == "" {
 			return fmt.Errorf("service %q has neither an image nor a build context specified: %w", s.Name, errdefs.ErrInvalid)
@@ -172,5 +178,147 @@ func checkConsistency(project *types.Project) error {
 		}
 	}
 
-	return graph.CheckCycle(project)
+	if err := checkPortConflicts(project); err != nil {
+		return err
+	}
+
+	return checkCycles(project, options...)
+}
+
+// checkCycles reports all dependency cycles between services, edges being located in compose files
+// only once a cycle has been detected
+func checkCycles(project *types.Project, options ...func(*graph.CyclesOptions)) error {
+	if len(graph.Cycles(project)) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, cycle := range graph.Cycles(project, options...) {
+		errs = append(errs, fmt.Errorf("dependency cycle detected: %s: %w", cycle, errdefs.ErrInvalid))
+	}
+	return errors.Join(errs...)
+}
+
+// hostPort is a range of host ports published by a service
+type hostPort struct {
+	path       tree.Path
+	ip         string
+	protocol   string
+	start, end uint32
+}
+
+func (h hostPort) String() string {
+	ip := h.ip
+	if ip == "" {
+		ip = "0.0.0.0"
+	}
+	port := strconv.FormatUint(uint64(h.start), 10)
+	if h.start != h.end {
+		port = fmt.Sprintf("%d-%d", h.start, h.end)
+	}
+	return fmt.Sprintf("%s/%s", net.JoinHostPort(ip, port), h.protocol)
+}
+
+func (h hostPort) conflicts(other hostPort) bool {
+	if h.protocol != other.protocol {
+		return false
+	}
+	if !sameHostAddress(h.ip, other.ip) {
+		return false
+	}
+	return h.start <= other.end && other.start <= h.end
+}
+
+// sameHostAddress reports if ports bound on host IPs a and b may conflict. An empty host IP binds all
+// addresses, while unspecified addresses `0.0.0.0` and `::` only bind those of their own family
+func sameHostAddress(a, b string) bool {
+	if a == "" || b == "" || a == b {
+		return true
+	}
+	ipA, errA := netip.ParseAddr(a)
+	ipB, errB := netip.ParseAddr(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	ipA, ipB = ipA.Unmap(), ipB.Unmap()
+	if ipA.Is4() != ipB.Is4() {
+		return false
+	}
+	return ipA.IsUnspecified() || ipB.IsUnspecified() || ipA == ipB
+}
+
+// checkPortConflicts reports services publishing the same host port, including replicas of a scaled service
+func checkPortConflicts(project *types.Project) error {
+	var (
+		errs      []error
+		published []hostPort
+	)
+	for _, name := range project.ServiceNames() {
+		s := project.Services[name]
+		if s.NetworkMode == "host" {
+			// published ports are ignored by the engine
+			continue
+		}
+		for i, port := range s.Ports {
+			if port.Published == "" {
+				continue
+			}
+			path := tree.NewPath("services", name, "ports", strconv.Itoa(i))
+			start, end, err := transform.PublishedRange(port.Published)
+			if err != nil {
+				return fmt.Errorf("%s: invalid published port %q: %w", path, port.Published, errdefs.ErrInvalid)
+			}
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			current := hostPort{path: path, ip: port.HostIP, protocol: protocol, start: start, end: end}
+
+			// each replica binds a distinct port from the published range
+			if scale := s.GetScale(); scale > 1 && int(end-start)+1 < scale {
+				attr := "scale"
+				if s.Scale == nil {
+					attr = "deploy.replicas"
+				}
+				if start == end {
+					errs = append(errs, fmt.Errorf("%s: host port %s is bound by all %d replicas set by %s: %w",
+						path, current, scale, attr, errdefs.ErrInvalid))
+				} else {
+					errs = append(errs, fmt.Errorf("%s: host port range %s is too small for the %d replicas set by %s: %w",
+						path, current, scale, attr, errdefs.ErrInvalid))
+				}
+			}
+			for _, other := range published {
+				if current.conflicts(other) {
+					errs = append(errs, fmt.Errorf("%s: host port %s conflicts with %s published by %s: %w",
+						path, current, other, other.path, errdefs.ErrInvalid))
+				}
+			}
+			published = append(published, current)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkTargetPaths checks paths inside containers are absolute paths for targetOS
+func checkTargetPaths(project *types.Project, targetOS string) error {
+	var errs []error
+	check := func(path string, target string) {
+		if target != "" && !paths.IsAbsTarget(targetOS, target) {
+			errs = append(errs, fmt.Errorf("%s: %s is not an absolute %s path: %w", path, target, targetOS, errdefs.ErrInvalid))
+		}
+	}
+	for _, name := range project.ServiceNames() {
+		s := project.Services[name]
+		check(fmt.Sprintf("services.%s.working_dir", name), s.WorkingDir)
+		for i, v := range s.Volumes {
+			check(fmt.Sprintf("services.%s.volumes[%d].target", name, i), v.Target)
+		}
+		for i, c := range s.Configs {
+			check(fmt.Sprintf("services.%s.configs[%d].target", name, i), c.Target)
+		}
+		for i, c := range s.Secrets {
+			check(fmt.Sprintf("services.%s.secrets[%d].target", name, i), c.Target)
+		}
+	}
+	return errors.Join(errs...)
 }
@@ -408,3 +408,109 @@ func TestValidateWatch(t *testing.T) {
 		assert.ErrorContains(t, err, "depends on undefined service")
 	})
 }
+
+func TestValidatePortConflicts(t *testing.T) {
+	t.Run("distinct host ports", func(t *testing.T) {
+		project := types.Project{
+			Services: types.Services{
+				"web": {
+					Name:  "web",
+					Image: "scratch",
+					Ports: []types.ServicePortConfig{
+						{Target: 80, Published: "8080", HostIP: "127.0.0.3", Protocol: "tcp"},
+						{Target: 53, Published: "8080", Protocol: "udp"},
+					},
+				},
+				"api": {
+					Name:  "api",
+					Image: "scratch",
+					Ports: []types.ServicePortConfig{
+						{Target: 80, Published: "8080", HostIP: "127.0.0.2", Protocol: "tcp"},
+					},
+				},
+				"admin": {
+					Name:  "admin",
+					Image: "scratch",
+					Ports: []types.ServicePortConfig{
+						{Target: 80, Published: "8080", HostIP: "127.0.0.1", Protocol: "tcp"},
+					},
+				},
+			},
+		}
+		err := checkConsistency(&project)
+		assert.NilError(t, err)
+	})
+
+	t.Run("conflicting host ports", func(t *testing.T) {
+		project := types.Project{
+			Services: types.Services{
+				"web": {
+					Name:  "web",
+					Image: "scratch",
+					Ports: []types.ServicePortConfig{
+						{Target: 80, Published: "8000-8010", Protocol: "tcp"},
+					},
+				},
+				"api": {
+					Name:  "api",
+					Image: "scratch",
+					Ports: []types.ServicePortConfig{
+						{Target: 80, Published: "8005", HostIP: "127.0.0.1", Protocol: "tcp"},
+					},
+				},
+			},
+		}
+		err := checkConsistency(&project)
+		assert.Error(t, err, "services.web.ports.0: host port 0.0.0.0:8000-8010/tcp conflicts with 127.0.0.1:8005/tcp published by services.api.ports.0: invalid compose project")
+	})
+
+	t.Run("wildcard host IP of another family", func(t *testing.T) {
+		project := types.Project{
+			Services: types.Services{
+				"web": {
+					Name:  "web",
+					Image: "scratch",
+					Ports: []types.ServicePortConfig{
+						{Target: 80, Published: "8080", HostIP: "0.0.0.0", Protocol: "tcp"},
+						{Target: 81, Published: "8081", HostIP: "::", Protocol: "tcp"},
+					},
+				},
+				"api": {
+					Name:  "api",
+					Image: "scratch",
+					Ports: []types.ServicePortConfig{
+						{Target: 80, Published: "8080", HostIP: "::1", Protocol: "tcp"},
+						{Target: 81, Published: "8081", HostIP: "127.0.0.1", Protocol: "tcp"},
+					},
+				},
+			},
+		}
+		assert.NilError(t, checkConsistency(&project))
+
+		project.Services["web"].Ports[1].HostIP = "2001:db8::1"
+		project.Services["api"].Ports[1].HostIP = ""
+		err := checkConsistency(&project)
+		assert.Error(t, err, "services.web.ports.1: host port [2001:db8::1]:8081/tcp conflicts with 0.0.0.0:8081/tcp published by services.api.ports.1: invalid compose project")
+	})
+
+	t.Run("fixed host port with replicas", func(t *testing.T) {
+		replicas := 3
+		project := types.Project{
+			Services: types.Services{
+				"web": {
+					Name:   "web",
+					Image:  "scratch",
+					Deploy: &types.DeployConfig{Replicas: &replicas},
+					Ports: []types.ServicePortConfig{
+						{Target: 80, Published: "8080", Protocol: "tcp"},
+						{Target: 81, Published: "9000-9010", Protocol: "tcp"},
+						{Target: 82, Published: "9100-9101", Protocol: "tcp"},
+					},
+				},
+			},
+		}
+		err := checkConsistency(&project)
+		assert.Error(t, err, `services.web.ports.0: host port 0.0.0.0:8080/tcp is bound by all 3 replicas set by deploy.replicas: invalid compose project
+services.web.ports.2: host port range 0.0.0.0:9100-9101/tcp is too small for the 3 replicas set by deploy.replicas: invalid compose project`)
+	})
+}
@@ -0,0 +1,163 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package lsp
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/tree"
+)
+
+// references maps attributes to the kind of top-level resource they refer to by name
+var references = map[tree.Path]string{
+	"services.*.depends_on":              "services",
+	"services.*.depends_on.[]":           "services",
+	"services.*.links.[]":                "services",
+	"services.*.volumes_from.[]":         "services",
+	"services.*.extends.service":         "services",
+	"services.*.networks":                "networks",
+	"services.*.networks.[]":             "networks",
+	"services.*.volumes.[]":              "volumes",
+	"services.*.volumes.[].source":       "volumes",
+	"services.*.secrets.[]":              "secrets",
+	"services.*.secrets.[].source":       "secrets",
+	"services.*.configs.[]":              "configs",
+	"services.*.configs.[].source":       "configs",
+	"services.*.build.secrets.[]":        "secrets",
+	"services.*.build.secrets.[].source": "secrets",
+}
+
+// referenceAt returns the kind of resource referred to by attribute at path
+func referenceAt(p tree.Path) (string, bool) {
+	for pattern, kind := range references {
+		if p.Matches(pattern) {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+func (s *Server) completion(d *document, pos Position) CompletionList {
+	ctx := contextAt(d.text, pos)
+	var items []CompletionItem
+	switch {
+	case ctx.key != "":
+		p := ctx.path.Next(ctx.key)
+		items = append(items, s.values(d, p)...)
+	case ctx.item:
+		p := ctx.path.Next(tree.PathMatchList)
+		items = append(items, s.values(d, p)...)
+	default:
+		items = append(items, keys(ctx.path)...)
+		if kind, ok := referenceAt(ctx.path); ok {
+			items = append(items, s.names(d, kind, ctx.path)...)
+		}
+	}
+
+	filtered := []CompletionItem{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		if seen[item.Label] || !strings.HasPrefix(item.Label, ctx.prefix) {
+			continue
+		}
+		seen[item.Label] = true
+		filtered = append(filtered, item)
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Label < filtered[j].Label
+	})
+	return CompletionList{Items: filtered}
+}
+
+// keys suggests attributes declared by schema for a mapping at path
+func keys(p tree.Path) []CompletionItem {
+	var items []CompletionItem
+	for name, s := range propertiesAt(p) {
+		items = append(items, CompletionItem{
+			Label:         name,
+			Kind:          CompletionItemKindProperty,
+			Documentation: describe(expand(s)),
+			InsertText:    name + ": ",
+		})
+	}
+	return items
+}
+
+// values suggests values for attribute at path, based on schema enum or declared resources
+func (s *Server) values(d *document, p tree.Path) []CompletionItem {
+	var items []CompletionItem
+	for _, v := range enumAt(p) {
+		items = append(items, CompletionItem{
+			Label: v,
+			Kind:  CompletionItemKindEnum,
+		})
+	}
+	if kind, ok := referenceAt(p); ok {
+		items = append(items, s.names(d, kind, p)...)
+	}
+	if p.Matches("services.*.network_mode") {
+		for _, item := range s.names(d, "services", p) {
+			item.Label = "service:" + item.Label
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// names lists resources of a kind declared by document, or by the project it was last loaded as
+func (s *Server) names(d *document, kind string, p tree.Path) []CompletionItem {
+	names := map[string]bool{}
+	for _, name := range mappingKeys(d.root, kind) {
+		names[name] = true
+	}
+	if project, ok := s.projects[d.uri]; ok {
+		var declared []string
+		switch kind {
+		case "services":
+			declared = project.ServiceNames()
+			declared = append(declared, project.DisabledServiceNames()...)
+		case "networks":
+			declared = project.NetworkNames()
+		case "volumes":
+			declared = project.VolumeNames()
+		case "secrets":
+			declared = project.SecretNames()
+		case "configs":
+			declared = project.ConfigNames()
+		}
+		for _, name := range declared {
+			names[name] = true
+		}
+	}
+	if kind == "services" {
+		// a service can't refer to itself
+		parts := p.Parts()
+		if len(parts) > 1 && parts[0] == "services" {
+			delete(names, parts[1])
+		}
+	}
+	var items []CompletionItem
+	for name := range names {
+		items = append(items, CompletionItem{
+			Label:  name,
+			Kind:   CompletionItemKindValue,
+			Detail: strings.TrimSuffix(kind, "s"),
+		})
+	}
+	return items
+}
@@ -0,0 +1,178 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package lsp
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/paths"
+	"gopkg.in/yaml.v3"
+)
+
+// definition locates the declaration of the resource or file referred to at position, nil if there's none
+func (s *Server) definition(d *document, pos Position) *Location {
+	node, p, isKey := nodeAt(d.root, pos)
+	if node == nil {
+		return nil
+	}
+	parts := p.Parts()
+
+	switch {
+	case isKey && p.Matches("services.*.depends_on.*"):
+		return s.declaration(d, "services", node.Value)
+	case isKey && p.Matches("services.*.networks.*"):
+		return s.declaration(d, "networks", node.Value)
+	case isKey:
+		return nil
+	case p.Matches("services.*.extends.service"):
+		key, file := lookup(d.root, []string{"services", parts[1], "extends", "file"})
+		if key == nil || key.Value != "file" {
+			return s.declaration(d, "services", node.Value)
+		}
+		return s.externalDeclaration(d.resolve(file.Value), "services", node.Value)
+	case p.Matches("services.*.extends.file"),
+		p.Matches("include.[]"),
+		p.Matches("include.[].path"),
+		p.Matches("include.[].path.[]"),
+		p.Matches("include.[].env_file"),
+		p.Matches("include.[].env_file.[]"):
+		return &Location{URI: pathToURI(d.resolve(node.Value))}
+	case p.Matches("services.*.links.[]"), p.Matches("services.*.volumes.[]"):
+		name, _, _ := strings.Cut(node.Value, ":")
+		kind, _ := referenceAt(p)
+		return s.declaration(d, kind, name)
+	case p.Matches("services.*.volumes_from.[]"):
+		name, _, _ := strings.Cut(node.Value, ":")
+		if name == "container" {
+			return nil
+		}
+		return s.declaration(d, "services", name)
+	case p.Matches("services.*.network_mode"):
+		name, ok := strings.CutPrefix(node.Value, "service:")
+		if !ok {
+			return nil
+		}
+		return s.declaration(d, "services", name)
+	}
+	if kind, ok := referenceAt(p); ok {
+		return s.declaration(d, kind, node.Value)
+	}
+	return nil
+}
+
+// declaration returns location for a top-level resource declared by document or by the files it includes
+func (s *Server) declaration(d *document, kind string, name string) *Location {
+	return s.includedDeclaration(d.path(), d.root, kind, name, map[string]bool{})
+}
+
+// includedDeclaration looks for a top-level resource declared by root, then by files it includes
+func (s *Server) includedDeclaration(file string, root *yaml.Node, kind string, name string, visited map[string]bool) *Location {
+	if visited[file] {
+		return nil
+	}
+	visited[file] = true
+	if l := localDeclaration(pathToURI(file), root, kind, name); l != nil {
+		return l
+	}
+	for _, included := range includedFiles(root) {
+		included = resolvePath(file, included)
+		if r := s.parse(included); r != nil {
+			if l := s.includedDeclaration(included, r, kind, name, visited); l != nil {
+				return l
+			}
+		}
+	}
+	return nil
+}
+
+// includedFiles lists files declared by `include`, as set in compose file
+func includedFiles(root *yaml.Node) []string {
+	_, include := lookup(root, []string{"include"})
+	if include == nil || include.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var files []string
+	for _, item := range include.Content {
+		switch item.Kind {
+		case yaml.ScalarNode:
+			files = append(files, item.Value)
+		case yaml.MappingNode:
+			key, p := lookup(item, []string{"path"})
+			if key == nil || key.Value != "path" {
+				continue
+			}
+			if p.Kind == yaml.ScalarNode {
+				files = append(files, p.Value)
+				continue
+			}
+			// additional files are overrides, which also can declare resources
+			for _, f := range p.Content {
+				files = append(files, f.Value)
+			}
+		}
+	}
+	return files
+}
+
+// localDeclaration returns location for a top-level resource declared by root, nil if not declared
+func localDeclaration(uri string, root *yaml.Node, kind string, name string) *Location {
+	key, _ := lookup(root, []string{kind, name})
+	if key == nil || key.Value != name {
+		return nil
+	}
+	return &Location{URI: uri, Range: rangeOf(key)}
+}
+
+// externalDeclaration returns location for a top-level resource declared by another compose file
+func (s *Server) externalDeclaration(file string, kind string, name string) *Location {
+	root := s.parse(file)
+	if root == nil {
+		return nil
+	}
+	return localDeclaration(pathToURI(file), root, kind, name)
+}
+
+// parse returns the root node of a compose file, using the opened document if any
+func (s *Server) parse(file string) *yaml.Node {
+	if d, ok := s.documents[pathToURI(file)]; ok {
+		return d.root
+	}
+	content, err := s.fsys.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	root, err := parseRoot(string(content))
+	if err != nil {
+		return nil
+	}
+	return root
+}
+
+// resolve computes absolute path for a file referenced by document
+func (d *document) resolve(file string) string {
+	return resolvePath(d.path(), file)
+}
+
+// resolvePath computes absolute path for a file referenced by compose file from
+func resolvePath(from string, file string) string {
+	file = paths.ExpandUser(file)
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(filepath.Dir(from), file)
+}
@@ -0,0 +1,103 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package lsp
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/types"
+	"gopkg.in/yaml.v3"
+)
+
+const diagnosticSource = "compose"
+
+// diagnose loads document as a compose project and reports errors as diagnostics
+func (s *Server) diagnose(ctx context.Context, d *document) ([]Diagnostic, *types.Project) {
+	diagnostics := []Diagnostic{}
+	if d.err != nil {
+		line, _ := errorLine(d.err)
+		return append(diagnostics, Diagnostic{
+			Range:    Range{Start: Position{Line: line}, End: Position{Line: line + 1}},
+			Severity: SeverityError,
+			Source:   diagnosticSource,
+			Message:  d.err.Error(),
+		}), nil
+	}
+
+	file := d.path()
+	workingDir := filepath.Dir(file)
+	project, err := loader.LoadWithContext(ctx, types.ConfigDetails{
+		WorkingDir: workingDir,
+		ConfigFiles: []types.ConfigFile{
+			{Filename: file, Content: []byte(d.text)},
+		},
+		Environment: s.environment,
+	}, func(options *loader.Options) {
+		name := loader.NormalizeProjectName(filepath.Base(workingDir))
+		if name == "" {
+			name = "default"
+		}
+		options.SetProjectName(name, false)
+		options.FS = s.fsys
+	})
+	if err != nil {
+		return append(diagnostics, Diagnostic{
+			Range:    errorRange(d.root, err),
+			Severity: SeverityError,
+			Source:   diagnosticSource,
+			Message:  err.Error(),
+		}), nil
+	}
+	return diagnostics, project
+}
+
+var (
+	serviceNameInError  = regexp.MustCompile(`service "([^"]+)"`)
+	resourcePathInError = regexp.MustCompile(`\b((?:services|networks|volumes|secrets|configs)\.[a-zA-Z0-9._-]+)`)
+)
+
+// errorRange guesses the position of the attribute an error relates to
+func errorRange(root *yaml.Node, err error) Range {
+	var field interface{ Field() string }
+	if errors.As(err, &field) && field.Field() != "" {
+		return nodeRange(lookup(root, strings.Split(field.Field(), ".")))
+	}
+	msg := err.Error()
+	if m := serviceNameInError.FindStringSubmatch(msg); m != nil {
+		return nodeRange(lookup(root, []string{"services", m[1]}))
+	}
+	if m := resourcePathInError.FindStringSubmatch(msg); m != nil {
+		return nodeRange(lookup(root, strings.Split(strings.TrimRight(m[1], "."), ".")))
+	}
+	return rangeOf(nil)
+}
+
+// nodeRange selects the key node, which is more relevant to report, if available
+func nodeRange(key, value *yaml.Node) Range {
+	if key != nil {
+		return rangeOf(key)
+	}
+	if value != nil && value.Kind == yaml.ScalarNode {
+		return rangeOf(value)
+	}
+	return rangeOf(nil)
+}
@@ -0,0 +1,312 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/tree"
+	"gopkg.in/yaml.v3"
+)
+
+// document is a compose file opened by the client
+type document struct {
+	uri     string
+	version int
+	text    string
+	// root is the top-level mapping, nil if the document can't be parsed
+	root *yaml.Node
+	err  error
+}
+
+func newDocument(uri string, version int, text string) *document {
+	d := &document{
+		uri:     uri,
+		version: version,
+		text:    text,
+	}
+	d.root, d.err = parseRoot(text)
+	if d.err != nil {
+		// document is typically being edited, try to get a model ignoring the line in error
+		if line, ok := errorLine(d.err); ok {
+			lines := strings.Split(text, "\n")
+			if line < len(lines) {
+				lines[line] = ""
+				d.root, _ = parseRoot(strings.Join(lines, "\n"))
+			}
+		}
+	}
+	return d
+}
+
+func parseRoot(text string) (*yaml.Node, error) {
+	var n yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &n); err != nil {
+		return nil, err
+	}
+	if len(n.Content) == 0 || n.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	return n.Content[0], nil
+}
+
+// errorLine extracts the zero-based line number from a yaml error message
+func errorLine(err error) (int, bool) {
+	msg := err.Error()
+	_, after, found := strings.Cut(msg, "line ")
+	if !found {
+		return 0, false
+	}
+	end := strings.IndexFunc(after, func(r rune) bool { return r < '0' || r > '9' })
+	if end > 0 {
+		after = after[:end]
+	}
+	line, err := strconv.Atoi(after)
+	if err != nil || line < 1 {
+		return 0, false
+	}
+	return line - 1, true
+}
+
+// path returns the local file path for document
+func (d *document) path() string {
+	return uriToPath(d.uri)
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// lookup returns key and value nodes matching a dotted path, numerical parts being used as sequence index.
+// When the exact path can't be found, the closest parent is returned.
+func lookup(root *yaml.Node, parts []string) (*yaml.Node, *yaml.Node) {
+	var key *yaml.Node
+	value := root
+	for _, part := range parts {
+		if value == nil {
+			break
+		}
+		switch value.Kind {
+		case yaml.MappingNode:
+			found := false
+			for i := 0; i+1 < len(value.Content); i += 2 {
+				if value.Content[i].Value == part {
+					key, value = value.Content[i], value.Content[i+1]
+					found = true
+					break
+				}
+			}
+			if !found {
+				return key, value
+			}
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(part)
+			if err != nil || i >= len(value.Content) {
+				return key, value
+			}
+			key, value = value.Content[i], value.Content[i]
+		default:
+			return key, value
+		}
+	}
+	return key, value
+}
+
+// nodeAt returns the scalar node found at position, its path in the yaml tree and if this is a mapping key
+func nodeAt(root *yaml.Node, pos Position) (*yaml.Node, tree.Path, bool) {
+	if root == nil {
+		return nil, "", false
+	}
+	return findNode(root, pos, tree.NewPath())
+}
+
+func findNode(node *yaml.Node, pos Position, p tree.Path) (*yaml.Node, tree.Path, bool) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			next := p.Next(key.Value)
+			if contains(key, pos) {
+				return key, next, true
+			}
+			if n, path, isKey := findNode(value, pos, next); n != nil {
+				return n, path, isKey
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if n, path, isKey := findNode(item, pos, p.Next(tree.PathMatchList)); n != nil {
+				return n, path, isKey
+			}
+		}
+	case yaml.ScalarNode:
+		if contains(node, pos) {
+			return node, p, false
+		}
+	}
+	return nil, "", false
+}
+
+// contains checks position is within a scalar node
+func contains(node *yaml.Node, pos Position) bool {
+	if node.Kind != yaml.ScalarNode || node.Line-1 != pos.Line {
+		return false
+	}
+	length := len(node.Value)
+	if node.Style == yaml.DoubleQuotedStyle || node.Style == yaml.SingleQuotedStyle {
+		length += 2
+	}
+	start := node.Column - 1
+	return pos.Character >= start && pos.Character <= start+length
+}
+
+// rangeOf computes the range covered by a node, or the first line of the document if node is nil
+func rangeOf(node *yaml.Node) Range {
+	if node == nil {
+		return Range{End: Position{Character: 1}}
+	}
+	start := Position{Line: node.Line - 1, Character: node.Column - 1}
+	end := start
+	if node.Kind == yaml.ScalarNode {
+		end.Character += len(node.Value)
+	} else {
+		end.Character++
+	}
+	return Range{Start: start, End: end}
+}
+
+// mappingKeys returns the keys declared by a mapping node at path
+func mappingKeys(root *yaml.Node, parts ...string) []string {
+	if root == nil {
+		return nil
+	}
+	key, value := lookup(root, parts)
+	if value == nil || value.Kind != yaml.MappingNode || (len(parts) > 0 && (key == nil || key.Value != parts[len(parts)-1])) {
+		return nil
+	}
+	var keys []string
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		keys = append(keys, value.Content[i].Value)
+	}
+	return keys
+}
+
+// cursorContext describes where the cursor is in a document being edited
+type cursorContext struct {
+	// path to the mapping or sequence the cursor is in
+	path tree.Path
+	// key is set when the cursor is on a value for a mapping key
+	key string
+	// item is true when the cursor is on a sequence item
+	item bool
+	// prefix is the text already typed
+	prefix string
+}
+
+// contextAt computes cursorContext based on indentation, as a document being edited is generally not valid yaml
+func contextAt(text string, pos Position) cursorContext {
+	lines := strings.Split(text, "\n")
+	if pos.Line >= len(lines) {
+		return cursorContext{}
+	}
+	current := lines[pos.Line]
+	if pos.Character < len(current) {
+		current = current[:pos.Character]
+	}
+	indent := indentation(current)
+	content := strings.TrimSpace(current)
+	if content == "" && pos.Character > indent {
+		// some clients strip trailing whitespace, rely on cursor position
+		indent = pos.Character
+	}
+
+	var (
+		ctx     cursorContext
+		parents []string
+	)
+	if strings.HasPrefix(content, "-") {
+		content = strings.TrimSpace(content[1:])
+		parents = ancestors(lines, pos.Line-1, indent, true)
+		if k, v, ok := strings.Cut(content, ":"); ok {
+			// first key of a mapping declared as a sequence item
+			parents = append(parents, tree.PathMatchList)
+			ctx.key, ctx.prefix = strings.TrimSpace(k), strings.TrimSpace(v)
+		} else {
+			ctx.item = true
+			ctx.prefix = content
+		}
+	} else {
+		parents = ancestors(lines, pos.Line-1, indent, false)
+		if k, v, ok := strings.Cut(content, ":"); ok {
+			ctx.key, ctx.prefix = strings.TrimSpace(k), strings.TrimSpace(v)
+		} else {
+			ctx.prefix = content
+		}
+	}
+	ctx.path = tree.NewPath(parents...)
+	return ctx
+}
+
+// ancestors searches lines, starting at `from` and going up, for the keys a node starting at column `col` belongs to.
+// dash is set when node is a sequence item, which can be declared at the same indentation as the parent key.
+func ancestors(lines []string, from int, col int, dash bool) []string {
+	for i := from; i >= 0 && col > 0; i-- {
+		line := strings.TrimRight(lines[i], " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		li := indentation(line)
+		if strings.HasPrefix(trimmed, "-") {
+			item := strings.TrimSpace(trimmed[1:])
+			k, v, isMapping := strings.Cut(item, ":")
+			if !isMapping || li >= col {
+				continue
+			}
+			// node is part of the mapping declared as a sequence item
+			p := append(ancestors(lines, i-1, li, true), tree.PathMatchList)
+			if strings.TrimSpace(v) == "" && col > li+2 {
+				p = append(p, strings.TrimSpace(k))
+			}
+			return p
+		}
+		if li < col || (dash && li == col) {
+			k, _, ok := strings.Cut(trimmed, ":")
+			if !ok {
+				continue
+			}
+			return append(ancestors(lines, i-1, li, false), strings.TrimSpace(k))
+		}
+	}
+	return nil
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
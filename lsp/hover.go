@@ -0,0 +1,37 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package lsp
+
+// hover returns documentation from the compose-spec schema for the attribute at position, nil if none is available
+func hover(d *document, pos Position) *Hover {
+	node, p, isKey := nodeAt(d.root, pos)
+	if node == nil || !isKey {
+		return nil
+	}
+	doc := describe(schemasAt(p))
+	if doc == "" {
+		return nil
+	}
+	r := rangeOf(node)
+	return &Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: doc,
+		},
+		Range: &r,
+	}
+}
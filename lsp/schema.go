@@ -0,0 +1,217 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/compose-spec/compose-go/v2/schema"
+	"github.com/compose-spec/compose-go/v2/tree"
+)
+
+var (
+	specOnce sync.Once
+	spec     map[string]any
+)
+
+// composeSchema returns the parsed compose-spec JSON schema
+func composeSchema() map[string]any {
+	specOnce.Do(func() {
+		if err := json.Unmarshal([]byte(schema.Schema), &spec); err != nil {
+			panic(fmt.Errorf("invalid embedded compose-spec schema: %w", err))
+		}
+	})
+	return spec
+}
+
+// expand resolves `$ref` and flattens `oneOf`, `anyOf` and `allOf` alternatives
+func expand(s map[string]any) []map[string]any {
+	if s == nil {
+		return nil
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		return expand(definition(ref))
+	}
+	res := []map[string]any{s}
+	for _, k := range []string{"oneOf", "anyOf", "allOf"} {
+		if alternatives, ok := s[k].([]any); ok {
+			for _, a := range alternatives {
+				if m, ok := a.(map[string]any); ok {
+					res = append(res, expand(m)...)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// definition resolves a local reference like `#/definitions/service`
+func definition(ref string) map[string]any {
+	var current any = composeSchema()
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+	m, _ := current.(map[string]any)
+	return m
+}
+
+// child returns schemas for an attribute, or for sequence items if part is tree.PathMatchList
+func child(s map[string]any, part string) []map[string]any {
+	var res []map[string]any
+	for _, e := range expand(s) {
+		if part == tree.PathMatchList {
+			if items, ok := e["items"].(map[string]any); ok {
+				res = append(res, expand(items)...)
+			}
+			continue
+		}
+		if props, ok := e["properties"].(map[string]any); ok {
+			if p, ok := props[part].(map[string]any); ok {
+				res = append(res, expand(p)...)
+				continue
+			}
+		}
+		if patterns, ok := e["patternProperties"].(map[string]any); ok {
+			for pattern, p := range patterns {
+				if r, err := regexp.Compile(pattern); err == nil && r.MatchString(part) {
+					if m, ok := p.(map[string]any); ok {
+						res = append(res, expand(m)...)
+					}
+				}
+			}
+		}
+	}
+	return res
+}
+
+// schemasAt returns all the schemas which can apply to a path in the compose model
+func schemasAt(p tree.Path) []map[string]any {
+	current := expand(composeSchema())
+	if p == "" {
+		return current
+	}
+	for _, part := range p.Parts() {
+		var next []map[string]any
+		for _, s := range current {
+			next = append(next, child(s, part)...)
+		}
+		current = next
+	}
+	return current
+}
+
+// propertiesAt returns the attributes declared by schema for a mapping at path, with their schema
+func propertiesAt(p tree.Path) map[string]map[string]any {
+	props := map[string]map[string]any{}
+	for _, s := range schemasAt(p) {
+		if m, ok := s["properties"].(map[string]any); ok {
+			for name, v := range m {
+				if ps, ok := v.(map[string]any); ok {
+					props[name] = ps
+				}
+			}
+		}
+	}
+	return props
+}
+
+// enumAt returns the allowed values for an attribute
+func enumAt(p tree.Path) []string {
+	var values []string
+	for _, s := range schemasAt(p) {
+		if enum, ok := s["enum"].([]any); ok {
+			for _, e := range enum {
+				values = append(values, fmt.Sprint(e))
+			}
+		}
+	}
+	return values
+}
+
+// describe renders documentation for an attribute based on its schema
+func describe(schemas []map[string]any) string {
+	var (
+		descriptions []string
+		kinds        []string
+		enum         []string
+	)
+	seen := map[string]bool{}
+	add := func(list *[]string, v string) {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			*list = append(*list, v)
+		}
+	}
+	for _, s := range schemas {
+		if d, ok := s["description"].(string); ok {
+			add(&descriptions, d)
+		}
+		switch t := s["type"].(type) {
+		case string:
+			add(&kinds, humanReadableType(t))
+		case []any:
+			for _, e := range t {
+				add(&kinds, humanReadableType(fmt.Sprint(e)))
+			}
+		}
+		if values, ok := s["enum"].([]any); ok {
+			for _, v := range values {
+				add(&enum, fmt.Sprint(v))
+			}
+		}
+		if s["deprecated"] == true {
+			add(&descriptions, "**Deprecated**")
+		}
+	}
+	var b strings.Builder
+	for _, d := range descriptions {
+		b.WriteString(d)
+		b.WriteString("\n\n")
+	}
+	if len(kinds) > 0 {
+		sort.Strings(kinds)
+		b.WriteString("Type: ")
+		b.WriteString(strings.Join(kinds, " | "))
+		b.WriteString("\n\n")
+	}
+	if len(enum) > 0 {
+		b.WriteString("Allowed values: `")
+		b.WriteString(strings.Join(enum, "`, `"))
+		b.WriteString("`\n\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func humanReadableType(t string) string {
+	switch t {
+	case "object":
+		return "mapping"
+	case "array":
+		return "list"
+	default:
+		return t
+	}
+}
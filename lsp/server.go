@@ -0,0 +1,195 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/vfs"
+)
+
+// Server is a Language Server for compose files, relying on the loader to report diagnostics
+type Server struct {
+	conn        *conn
+	fsys        vfs.FS
+	environment types.Mapping
+	documents   map[string]*document
+	// projects keeps the last project successfully loaded for a document, so that
+	// resources declared by included files can be suggested
+	projects map[string]*types.Project
+	shutdown bool
+}
+
+// NewServer creates a Server reading requests from in and writing responses to out.
+// environment is used to interpolate compose files while computing diagnostics
+func NewServer(in io.Reader, out io.Writer, environment types.Mapping) *Server {
+	return NewServerFS(vfs.OS, in, out, environment)
+}
+
+// NewServerFS creates a Server reading compose files which are not opened by client from fsys
+func NewServerFS(fsys vfs.FS, in io.Reader, out io.Writer, environment types.Mapping) *Server {
+	return &Server{
+		conn:        newConn(in, out),
+		fsys:        fsys,
+		environment: environment,
+		documents:   map[string]*document{},
+		projects:    map[string]*types.Project{},
+	}
+}
+
+// Serve handles requests until client sends `exit` notification or input is closed
+func (s *Server) Serve(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		req, err := s.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var rerr *responseError
+		if errors.As(err, &rerr) {
+			if err := s.conn.reply(nil, nil, rerr); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		result, err := s.handle(ctx, req)
+		if req.ID == nil {
+			// notifications don't get a response
+			continue
+		}
+		if err := s.conn.reply(req.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, req *request) (any, error) {
+	if s.shutdown {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "server is shutting down"}
+	}
+	switch req.Method {
+	case "initialize":
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync: TextDocumentSyncFull,
+				CompletionProvider: &CompletionOptions{
+					TriggerCharacters: []string{":", "-", " "},
+				},
+				HoverProvider:      true,
+				DefinitionProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "compose-language-server"},
+		}, nil
+	case "initialized", "$/cancelRequest", "$/setTrace", "workspace/didChangeConfiguration":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		item := params.TextDocument
+		return nil, s.update(ctx, newDocument(item.URI, item.Version, item.Text))
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// with full sync, last change holds the whole document
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return nil, s.update(ctx, newDocument(params.TextDocument.URI, params.TextDocument.Version, text))
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		delete(s.projects, params.TextDocument.URI)
+		return nil, s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+	case "textDocument/completion":
+		d, params, err := s.position(req)
+		if err != nil || d == nil {
+			return nil, err
+		}
+		return s.completion(d, params.Position), nil
+	case "textDocument/hover":
+		d, params, err := s.position(req)
+		if err != nil || d == nil {
+			return nil, err
+		}
+		return hover(d, params.Position), nil
+	case "textDocument/definition":
+		d, params, err := s.position(req)
+		if err != nil || d == nil {
+			return nil, err
+		}
+		return s.definition(d, params.Position), nil
+	default:
+		return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", req.Method)}
+	}
+}
+
+// position decodes TextDocumentPositionParams and returns the target document, nil if unknown
+func (s *Server) position(req *request) (*document, TextDocumentPositionParams, error) {
+	var params TextDocumentPositionParams
+	if err := unmarshalParams(req, &params); err != nil {
+		return nil, params, err
+	}
+	return s.documents[params.TextDocument.URI], params, nil
+}
+
+// update registers a new version of a document and publishes diagnostics
+func (s *Server) update(ctx context.Context, d *document) error {
+	s.documents[d.uri] = d
+	diagnostics, project := s.diagnose(ctx, d)
+	if project != nil {
+		s.projects[d.uri] = project
+	}
+	return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         d.uri,
+		Version:     d.version,
+		Diagnostics: diagnostics,
+	})
+}
+
+func unmarshalParams(req *request, v any) error {
+	if err := json.Unmarshal(req.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/vfs"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// client drives a Server over in-memory pipes
+type client struct {
+	t    *testing.T
+	conn *conn
+	id   int
+}
+
+type message struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+func newClient(t *testing.T) *client {
+	return newClientFS(t, vfs.OS)
+}
+
+func newClientFS(t *testing.T, fsys vfs.FS) *client {
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	server := NewServerFS(fsys, serverIn, serverOut, map[string]string{"TAG": "1.0"})
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(context.Background())
+	}()
+	c := &client{
+		t:    t,
+		conn: newConn(clientIn, clientOut),
+	}
+	t.Cleanup(func() {
+		var result any
+		c.call("shutdown", nil, &result)
+		assert.NilError(t, c.conn.notify("exit", nil))
+		assert.NilError(t, <-done)
+	})
+	return c
+}
+
+func (c *client) next() message {
+	body, err := c.conn.readMessage()
+	assert.NilError(c.t, err)
+	var msg message
+	assert.NilError(c.t, json.Unmarshal(body, &msg))
+	return msg
+}
+
+// call sends a request and decodes the result
+func (c *client) call(method string, params any, result any) {
+	c.id++
+	assert.NilError(c.t, c.conn.write(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      c.id,
+		"method":  method,
+		"params":  params,
+	}))
+	msg := c.next()
+	assert.Check(c.t, msg.Error == nil)
+	assert.Equal(c.t, *msg.ID, c.id)
+	assert.NilError(c.t, json.Unmarshal(msg.Result, result))
+}
+
+// open sends a document to server and returns the diagnostics it published
+func (c *client) open(uri string, text string) []Diagnostic {
+	assert.NilError(c.t, c.conn.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "dockercompose", Version: 1, Text: text},
+	}))
+	msg := c.next()
+	assert.Equal(c.t, msg.Method, "textDocument/publishDiagnostics")
+	var params PublishDiagnosticsParams
+	assert.NilError(c.t, json.Unmarshal(msg.Params, &params))
+	assert.Equal(c.t, params.URI, uri)
+	return params.Diagnostics
+}
+
+func (c *client) at(uri string, line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+func labels(list CompletionList) []string {
+	var l []string
+	for _, item := range list.Items {
+		l = append(l, item.Label)
+	}
+	return l
+}
+
+func TestInitialize(t *testing.T) {
+	c := newClient(t)
+	var result InitializeResult
+	c.call("initialize", map[string]any{}, &result)
+	assert.Equal(t, result.Capabilities.TextDocumentSync, TextDocumentSyncFull)
+	assert.Check(t, result.Capabilities.HoverProvider)
+	assert.Check(t, result.Capabilities.DefinitionProvider)
+	assert.Check(t, result.Capabilities.CompletionProvider != nil)
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+	uri := pathToURI(filepath.Join(t.TempDir(), "compose.yaml"))
+
+	diagnostics := c.open(uri, `
+services:
+  foo:
+    image: foo:${TAG}
+`)
+	assert.Check(t, is.Len(diagnostics, 0))
+
+	diagnostics = c.open(uri, `
+services:
+  foo:
+    image: foo
+    helicopter: true
+`)
+	assert.Assert(t, is.Len(diagnostics, 1))
+	assert.Check(t, is.Contains(diagnostics[0].Message, "helicopter"))
+	assert.Equal(t, diagnostics[0].Range.Start, Position{Line: 4, Character: 4})
+
+	diagnostics = c.open(uri, `
+services:
+  foo:
+    image: foo
+    depends_on: [bar]
+`)
+	assert.Assert(t, is.Len(diagnostics, 1))
+	assert.Check(t, is.Contains(diagnostics[0].Message, `depends on undefined service "bar"`))
+	assert.Equal(t, diagnostics[0].Range.Start, Position{Line: 2, Character: 2})
+
+	diagnostics = c.open(uri, `
+services:
+  foo:
+    image: [foo
+`)
+	assert.Assert(t, is.Len(diagnostics, 1))
+	assert.Equal(t, diagnostics[0].Severity, SeverityError)
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t)
+	uri := pathToURI(filepath.Join(t.TempDir(), "compose.yaml"))
+	c.open(uri, `
+services:
+  foo:
+    image: foo
+    pull_policy:
+    depends_on:
+      -
+    netw
+  bar:
+    image: bar
+networks:
+  front:
+`)
+	var list CompletionList
+	c.call("textDocument/completion", c.at(uri, 4, 17), &list)
+	assert.Check(t, is.Contains(labels(list), "always"))
+	assert.Check(t, is.Contains(labels(list), "never"))
+
+	c.call("textDocument/completion", c.at(uri, 6, 8), &list)
+	assert.DeepEqual(t, labels(list), []string{"bar"})
+
+	c.call("textDocument/completion", c.at(uri, 7, 8), &list)
+	assert.DeepEqual(t, labels(list), []string{"network_mode", "networks"})
+}
+
+func TestHover(t *testing.T) {
+	c := newClient(t)
+	uri := pathToURI(filepath.Join(t.TempDir(), "compose.yaml"))
+	c.open(uri, `name: test
+services:
+  foo:
+    image: foo
+`)
+	var hover Hover
+	c.call("textDocument/hover", c.at(uri, 0, 2), &hover)
+	assert.Check(t, is.Contains(hover.Contents.Value, "define the Compose project name"))
+	assert.Check(t, is.Contains(hover.Contents.Value, "Type: string"))
+}
+
+func TestDefinition(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	assert.NilError(t, os.WriteFile(base, []byte(`
+services:
+  common:
+    image: base
+`), 0o600))
+
+	c := newClient(t)
+	uri := pathToURI(filepath.Join(dir, "compose.yaml"))
+	c.open(uri, `
+include:
+  - base.yaml
+services:
+  foo:
+    extends:
+      file: base.yaml
+      service: common
+    depends_on:
+      - bar
+  bar:
+    image: bar
+`)
+
+	var location Location
+	c.call("textDocument/definition", c.at(uri, 9, 9), &location)
+	assert.DeepEqual(t, location, Location{
+		URI:   uri,
+		Range: Range{Start: Position{Line: 10, Character: 2}, End: Position{Line: 10, Character: 5}},
+	})
+
+	c.call("textDocument/definition", c.at(uri, 7, 16), &location)
+	assert.DeepEqual(t, location, Location{
+		URI:   pathToURI(base),
+		Range: Range{Start: Position{Line: 2, Character: 2}, End: Position{Line: 2, Character: 8}},
+	})
+
+	c.call("textDocument/definition", c.at(uri, 2, 5), &location)
+	assert.Equal(t, location.URI, pathToURI(base))
+}
+
+func TestDefinitionInIncludedFile(t *testing.T) {
+	root := filepath.FromSlash("/srv/app")
+	fsys := vfs.FromFS(fstest.MapFS{
+		"db/compose.yaml": {Data: []byte(`
+include:
+  - network.yaml
+services:
+  db:
+    image: postgres
+`)},
+		"db/network.yaml": {Data: []byte(`
+networks:
+  back: {}
+`)},
+	}, root)
+
+	c := newClientFS(t, fsys)
+	uri := pathToURI(filepath.Join(root, "compose.yaml"))
+	c.open(uri, `
+include:
+  - path: db/compose.yaml
+services:
+  app:
+    image: app
+    depends_on: [db]
+    networks: [back]
+`)
+
+	var location Location
+	c.call("textDocument/definition", c.at(uri, 6, 17), &location)
+	assert.DeepEqual(t, location, Location{
+		URI:   pathToURI(filepath.Join(root, "db", "compose.yaml")),
+		Range: Range{Start: Position{Line: 4, Character: 2}, End: Position{Line: 4, Character: 4}},
+	})
+
+	c.call("textDocument/definition", c.at(uri, 7, 16), &location)
+	assert.DeepEqual(t, location, Location{
+		URI:   pathToURI(filepath.Join(root, "db", "network.yaml")),
+		Range: Range{Start: Position{Line: 2, Character: 2}, End: Position{Line: 2, Character: 6}},
+	})
+}
+
+func TestContextAt(t *testing.T) {
+	text := `services:
+  foo:
+    image: fo
+    ports:
+      -
+    secrets:
+      - source:
+
+
+`
+	tests := []struct {
+		name string
+		pos  Position
+		want cursorContext
+	}{
+		{
+			name: "value",
+			pos:  Position{Line: 2, Character: 13},
+			want: cursorContext{path: "services.foo", key: "image", prefix: "fo"},
+		},
+		{
+			name: "sequence item",
+			pos:  Position{Line: 4, Character: 8},
+			want: cursorContext{path: "services.foo.ports", item: true},
+		},
+		{
+			name: "mapping in sequence",
+			pos:  Position{Line: 6, Character: 16},
+			want: cursorContext{path: tree.NewPath("services", "foo", "secrets", tree.PathMatchList), key: "source"},
+		},
+		{
+			name: "service attribute",
+			pos:  Position{Line: 7, Character: 4},
+			want: cursorContext{path: "services.foo"},
+		},
+		{
+			name: "service",
+			pos:  Position{Line: 8, Character: 2},
+			want: cursorContext{path: "services"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := contextAt(text, tt.pos)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
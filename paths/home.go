@@ -35,3 +35,25 @@ func ExpandUser(p string) string {
 	}
 	return p
 }
+
+// ExpandUserFor expands a leading `~` into user home directory, for a compose file written for targetOS.
+// Unlike ExpandUser, `~` must be followed by a path separator, which can also be `\` for windows
+func ExpandUserFor(targetOS string, p string) string {
+	rest, ok := strings.CutPrefix(p, "~")
+	if !ok {
+		return p
+	}
+	if targetOS == Windows {
+		rest = strings.ReplaceAll(rest, `\`, "/")
+	}
+	if rest != "" && !strings.HasPrefix(rest, "/") {
+		// `~user` syntax is not supported
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		logrus.Warn("cannot expand '~', because the environment lacks HOME")
+		return p
+	}
+	return filepath.Join(home, filepath.FromSlash(rest))
+}
@@ -23,15 +23,29 @@ import (
 
 	"github.com/compose-spec/compose-go/v2/tree"
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/vfs"
 )
 
 type resolver func(any) (any, error)
 
 // ResolveRelativePaths make relative paths absolute
 func ResolveRelativePaths(project map[string]any, base string, remotes []RemoteResource) error {
+	return ResolveRelativePathsFS(vfs.OS, project, base, remotes)
+}
+
+// ResolveRelativePathsFS make relative paths absolute, resolving symbolic links from fsys
+func ResolveRelativePathsFS(fsys vfs.FS, project map[string]any, base string, remotes []RemoteResource) error {
+	return ResolveRelativePathsForOS(fsys, "", project, base, remotes)
+}
+
+// ResolveRelativePathsForOS make relative paths absolute, resolving symbolic links from fsys, for a model
+// written for targetOS. Empty targetOS keeps legacy `~` expansion
+func ResolveRelativePathsForOS(fsys vfs.FS, targetOS string, project map[string]any, base string, remotes []RemoteResource) error {
 	r := relativePathsResolver{
 		workingDir: base,
 		remotes:    remotes,
+		fsys:       fsys,
+		targetOS:   targetOS,
 	}
 	r.resolvers = map[tree.Path]resolver{
 		"services.*.build.context":               r.absContextPath,
@@ -57,9 +71,18 @@ type RemoteResource func(path string) bool
 type relativePathsResolver struct {
 	workingDir string
 	remotes    []RemoteResource
+	fsys       vfs.FS
+	targetOS   string
 	resolvers  map[tree.Path]resolver
 }
 
+func (r *relativePathsResolver) expandUser(p string) string {
+	if r.targetOS == "" {
+		return ExpandUser(p)
+	}
+	return ExpandUserFor(r.targetOS, p)
+}
+
 func (r *relativePathsResolver) isRemoteResource(path string) bool {
 	for _, remote := range r.remotes {
 		if remote(path) {
@@ -108,7 +131,7 @@ func (r *relativePathsResolver) absPath(value any) (any, error) {
 		}
 		return v, nil
 	case string:
-		v = ExpandUser(v)
+		v = r.expandUser(v)
 		if filepath.IsAbs(v) {
 			return v, nil
 		}
@@ -0,0 +1,109 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package paths
+
+import (
+	"path"
+	"strings"
+)
+
+// Operating systems containers can run on, used to select container path semantics
+const (
+	Linux   = "linux"
+	Windows = "windows"
+)
+
+// default directories for configs and secrets mounts, by target OS
+var (
+	configsDir = map[string]string{Linux: "/", Windows: `C:\`}
+	secretsDir = map[string]string{Linux: "/run/secrets", Windows: `C:\ProgramData\Docker\secrets`}
+)
+
+// IsSupportedTarget checks targetOS is a known operating system
+func IsSupportedTarget(targetOS string) bool {
+	return targetOS == Linux || targetOS == Windows
+}
+
+// IsAbsTarget reports whether p is an absolute path inside a container running targetOS
+func IsAbsTarget(targetOS string, p string) bool {
+	if targetOS == Windows {
+		return isWindowsDevicePath(p) || isWindowsAbs(p)
+	}
+	return path.IsAbs(p)
+}
+
+// CleanTarget returns the shortest path equivalent to p inside a container running targetOS
+func CleanTarget(targetOS string, p string) string {
+	if p == "" {
+		return ""
+	}
+	if targetOS != Windows {
+		return path.Clean(p)
+	}
+	if isWindowsDevicePath(p) {
+		return p
+	}
+	p = strings.ReplaceAll(p, "/", `\`)
+	n := volumeNameLen(p)
+	volume, rest := p[:n], p[n:]
+	if rest == "" {
+		return volume
+	}
+	cleaned := path.Clean(strings.ReplaceAll(rest, `\`, "/"))
+	return volume + strings.ReplaceAll(cleaned, "/", `\`)
+}
+
+// ConfigTarget returns the path a config is mounted to inside a container running targetOS.
+// Target can be empty to use the config name, or relative to the default configs directory
+func ConfigTarget(targetOS string, name string, target string) string {
+	return mountTarget(targetOS, configsDir[targetOS], name, target)
+}
+
+// SecretTarget returns the path a secret is mounted to inside a container running targetOS.
+// Target can be empty to use the secret name, or relative to the default secrets directory
+func SecretTarget(targetOS string, name string, target string) string {
+	return mountTarget(targetOS, secretsDir[targetOS], name, target)
+}
+
+func mountTarget(targetOS string, dir string, name string, target string) string {
+	if target == "" {
+		target = name
+	}
+	if IsAbsTarget(targetOS, target) {
+		return CleanTarget(targetOS, target)
+	}
+	separator := "/"
+	if targetOS == Windows {
+		separator = `\`
+	}
+	return CleanTarget(targetOS, strings.TrimSuffix(dir, separator)+separator+target)
+}
+
+// ConvertWindowsPath converts a Windows absolute path, like `C:\data`, into the `/c/data` syntax Docker
+// Desktop uses to expose host drives to Linux containers. Other paths are returned unchanged
+func ConvertWindowsPath(p string) string {
+	n := volumeNameLen(p)
+	if n != 2 {
+		return p
+	}
+	return "/" + strings.ToLower(p[:1]) + strings.ReplaceAll(p[n:], `\`, "/")
+}
+
+// isWindowsDevicePath reports whether p uses the `\\.\` device namespace, typically for named pipes
+func isWindowsDevicePath(p string) bool {
+	return strings.HasPrefix(p, `\\.\`) || strings.HasPrefix(p, "//./")
+}
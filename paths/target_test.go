@@ -0,0 +1,68 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCleanTarget(t *testing.T) {
+	for _, tc := range []struct {
+		os, path, expected string
+	}{
+		{Linux, "/data/../app/", "/app"},
+		{Linux, "", ""},
+		{Windows, `C:\data\`, `C:\data`},
+		{Windows, `c:/app/bin/../conf`, `c:\app\conf`},
+		{Windows, `C:`, `C:`},
+		{Windows, `\\.\pipe\docker_engine`, `\\.\pipe\docker_engine`},
+		{Windows, `\\host\share\dir\..`, `\\host\share\`},
+	} {
+		assert.Equal(t, CleanTarget(tc.os, tc.path), tc.expected, "%s %s", tc.os, tc.path)
+	}
+}
+
+func TestIsAbsTarget(t *testing.T) {
+	assert.Check(t, IsAbsTarget(Linux, "/data"))
+	assert.Check(t, !IsAbsTarget(Linux, `C:\data`))
+	assert.Check(t, IsAbsTarget(Windows, `C:\data`))
+	assert.Check(t, IsAbsTarget(Windows, `\\.\pipe\docker_engine`))
+	assert.Check(t, !IsAbsTarget(Windows, "/data"))
+}
+
+func TestMountTargets(t *testing.T) {
+	assert.Equal(t, SecretTarget(Linux, "key", ""), "/run/secrets/key")
+	assert.Equal(t, SecretTarget(Linux, "key", "/etc/key"), "/etc/key")
+	assert.Equal(t, SecretTarget(Windows, "key", `db\key`), `C:\ProgramData\Docker\secrets\db\key`)
+	assert.Equal(t, ConfigTarget(Linux, "site", ""), "/site")
+	assert.Equal(t, ConfigTarget(Windows, "site", ""), `C:\site`)
+	assert.Equal(t, ConfigTarget(Windows, "site", `D:/conf/site`), `D:\conf\site`)
+}
+
+func TestExpandUserFor(t *testing.T) {
+	home, err := os.UserHomeDir()
+	assert.NilError(t, err)
+	assert.Equal(t, ExpandUserFor(Linux, "~/data"), filepath.Join(home, "data"))
+	assert.Equal(t, ExpandUserFor(Linux, "~"), home)
+	assert.Equal(t, ExpandUserFor(Linux, `~\data`), `~\data`)
+	assert.Equal(t, ExpandUserFor(Linux, "~user/data"), "~user/data")
+	assert.Equal(t, ExpandUserFor(Windows, `~\data\db`), filepath.Join(home, "data", "db"))
+}
@@ -25,7 +25,7 @@ import (
 
 func (r *relativePathsResolver) maybeUnixPath(a any) (any, error) {
 	p := a.(string)
-	p = ExpandUser(p)
+	p = r.expandUser(p)
 	// Check if source is an absolute path (either Unix or Windows), to
 	// handle a Windows client with a Unix daemon or vice-versa.
 	//
@@ -50,5 +50,5 @@ func (r *relativePathsResolver) absSymbolicLink(value any) (any, error) {
 	if !ok {
 		return abs, nil
 	}
-	return utils.ResolveSymbolicLink(str)
+	return utils.ResolveSymbolicLinkFS(r.fsys, str)
 }
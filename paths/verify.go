@@ -0,0 +1,182 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package paths
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/compose-spec/compose-go/v2/utils"
+	"github.com/compose-spec/compose-go/v2/vfs"
+)
+
+// PathKind is the type of filesystem entry expected for a path
+type PathKind string
+
+const (
+	// KindFile requires path to be a regular file
+	KindFile PathKind = "file"
+	// KindDirectory requires path to be a directory
+	KindDirectory PathKind = "directory"
+	// KindAny only requires path to exist
+	KindAny PathKind = "path"
+)
+
+// MissingPath reports a path declared by compose model which doesn't exist, or has an unexpected type
+type MissingPath struct {
+	// Path is the location of the attribute in compose model
+	Path tree.Path
+	// Kind is the type of filesystem entry expected
+	Kind PathKind
+	// Value is the path on filesystem
+	Value string
+	// Err is errdefs.ErrNotFound for a missing path, errdefs.ErrInvalid for an unexpected type
+	Err error
+}
+
+func (m MissingPath) Error() string {
+	if m.Err == errdefs.ErrNotFound {
+		return fmt.Sprintf("%s: %s %s does not exist", m.Path, m.Kind, m.Value)
+	}
+	return fmt.Sprintf("%s: %s is not a %s", m.Path, m.Value, m.Kind)
+}
+
+func (m MissingPath) Unwrap() error {
+	return m.Err
+}
+
+type verifier func(value any, p tree.Path) []MissingPath
+
+// VerifyPaths checks local paths declared by compose model exist and have the expected type.
+// It walks the same attributes as ResolveRelativePaths, relative paths being resolved against base.
+func VerifyPaths(fsys vfs.FS, project map[string]any, base string) []MissingPath {
+	v := pathsVerifier{workingDir: base, fsys: fsys}
+	v.verifiers = map[tree.Path]verifier{
+		"services.*.build.context":               v.context,
+		"services.*.build.additional_contexts.*": v.context,
+		"services.*.env_file.*":                  v.envFile,
+		"services.*.label_file.*":                v.expect(KindFile),
+		"services.*.develop.watch.*.path":        v.expect(KindAny),
+		"services.*.volumes.*":                   v.volumeMount,
+		"configs.*.file":                         v.expect(KindFile),
+		"secrets.*.file":                         v.expect(KindFile),
+		"volumes.*":                              v.volumeDriverOpts,
+	}
+	return v.verify(project, tree.NewPath())
+}
+
+type pathsVerifier struct {
+	workingDir string
+	fsys       vfs.FS
+	verifiers  map[tree.Path]verifier
+}
+
+func (v *pathsVerifier) verify(value any, p tree.Path) []MissingPath {
+	for pattern, verifier := range v.verifiers {
+		if p.Matches(pattern) {
+			return verifier(value, p)
+		}
+	}
+	var missing []MissingPath
+	switch m := value.(type) {
+	case map[string]any:
+		for _, k := range utils.MapKeys(m) {
+			missing = append(missing, v.verify(m[k], p.Next(k))...)
+		}
+	case []any:
+		for i, e := range m {
+			missing = append(missing, v.verify(e, p.Next(strconv.Itoa(i)))...)
+		}
+	}
+	return missing
+}
+
+func (v *pathsVerifier) check(path string, kind PathKind, p tree.Path) []MissingPath {
+	path = ExpandUser(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(v.workingDir, path)
+	}
+	fi, err := v.fsys.Stat(path)
+	switch {
+	case err != nil:
+		return []MissingPath{{Path: p, Kind: kind, Value: path, Err: errdefs.ErrNotFound}}
+	case kind == KindFile && fi.IsDir(), kind == KindDirectory && !fi.IsDir():
+		return []MissingPath{{Path: p, Kind: kind, Value: path, Err: errdefs.ErrInvalid}}
+	}
+	return nil
+}
+
+func (v *pathsVerifier) expect(kind PathKind) verifier {
+	return func(value any, p tree.Path) []MissingPath {
+		path, ok := value.(string)
+		if !ok || path == "" {
+			return nil
+		}
+		return v.check(path, kind, p)
+	}
+}
+
+func (v *pathsVerifier) context(value any, p tree.Path) []MissingPath {
+	path, ok := value.(string)
+	if !ok || path == "" || strings.Contains(path, "://") || isRemoteContext(path) ||
+		strings.HasPrefix(path, types.ServicePrefix) {
+		return nil
+	}
+	return v.check(path, KindDirectory, p)
+}
+
+func (v *pathsVerifier) envFile(value any, p tree.Path) []MissingPath {
+	switch e := value.(type) {
+	case string:
+		return v.check(e, KindFile, p)
+	case map[string]any:
+		if required, ok := e["required"].(bool); ok && !required {
+			return nil
+		}
+		return v.expect(KindFile)(e["path"], p.Next("path"))
+	}
+	return nil
+}
+
+func (v *pathsVerifier) volumeMount(value any, p tree.Path) []MissingPath {
+	vol, ok := value.(map[string]any)
+	if !ok || vol["type"] != types.VolumeTypeBind {
+		return nil
+	}
+	if bind, ok := vol["bind"].(map[string]any); ok && bind["create_host_path"] == true {
+		// engine creates missing host path
+		return nil
+	}
+	return v.expect(KindAny)(vol["source"], p.Next("source"))
+}
+
+func (v *pathsVerifier) volumeDriverOpts(value any, p tree.Path) []MissingPath {
+	vol, ok := value.(map[string]any)
+	if !ok || vol["driver"] != "local" {
+		return nil
+	}
+	opts, ok := vol["driver_opts"].(map[string]any)
+	if !ok || opts["o"] != "bind" {
+		return nil
+	}
+	return v.expect(KindDirectory)(opts["device"], p.Next("driver_opts").Next("device"))
+}
@@ -0,0 +1,219 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/tree"
+)
+
+// Difference is an attribute declared by compose-spec schema or by Go types, but not by both
+type Difference struct {
+	Path tree.Path
+	// InSchema is true when attribute is declared by compose-spec schema but missing from Go types
+	InSchema bool
+}
+
+func (d Difference) String() string {
+	if d.InSchema {
+		return fmt.Sprintf("%s is declared by compose-spec schema but missing from Go types", d.Path)
+	}
+	return fmt.Sprintf("%s is declared by Go types but missing from compose-spec schema", d.Path)
+}
+
+// Drift compares the embedded compose-spec schema with the one generated from Go types
+func Drift() ([]Difference, error) {
+	var spec map[string]any
+	if err := json.Unmarshal([]byte(Schema), &spec); err != nil {
+		return nil, err
+	}
+	return Compare(spec, Generate()), nil
+}
+
+// Compare lists attributes declared by one schema but not the other.
+// Extension attributes (x-*) are ignored, as well as loosely typed attributes.
+func Compare(schema, generated map[string]any) []Difference {
+	c := comparator{
+		left:    document{root: schema},
+		right:   document{root: generated},
+		visited: map[string]bool{},
+	}
+	c.compare(tree.NewPath(), schema, generated)
+	sort.Slice(c.differences, func(i, j int) bool {
+		return c.differences[i].Path < c.differences[j].Path
+	})
+	return c.differences
+}
+
+type comparator struct {
+	left, right document
+	visited     map[string]bool
+	differences []Difference
+}
+
+func (c *comparator) compare(p tree.Path, left, right map[string]any) {
+	lefts, rights := c.left.expand(left), c.right.expand(right)
+
+	// protect against recursive definitions
+	key := fmt.Sprintf("%s|%s", refs(left), refs(right))
+	if key != "|" {
+		if c.visited[key] {
+			return
+		}
+		c.visited[key] = true
+		defer delete(c.visited, key)
+	}
+
+	leftProps, rightProps := properties(lefts), properties(rights)
+	if leftProps != nil && rightProps != nil {
+		for name, l := range leftProps {
+			r, ok := rightProps[name]
+			if !ok {
+				c.differences = append(c.differences, Difference{Path: p.Next(name), InSchema: true})
+				continue
+			}
+			c.compare(p.Next(name), l, r)
+		}
+		for name := range rightProps {
+			if _, ok := leftProps[name]; !ok {
+				c.differences = append(c.differences, Difference{Path: p.Next(name)})
+			}
+		}
+	}
+
+	if l, r := values(lefts), values(rights); l != nil && r != nil {
+		c.compare(p.Next(tree.PathMatchAll), l, r)
+	}
+	if l, r := items(lefts), items(rights); l != nil && r != nil {
+		c.compare(p.Next(tree.PathMatchList), l, r)
+	}
+}
+
+// document is a JSON schema, used to resolve local references
+type document struct {
+	root map[string]any
+}
+
+// expand resolves `$ref` and flattens `oneOf`, `anyOf` and `allOf` alternatives
+func (d document) expand(s map[string]any) []map[string]any {
+	if s == nil {
+		return nil
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		return d.expand(d.resolve(ref))
+	}
+	res := []map[string]any{s}
+	for _, k := range []string{"oneOf", "anyOf", "allOf"} {
+		if alternatives, ok := s[k].([]any); ok {
+			for _, a := range alternatives {
+				if m, ok := a.(map[string]any); ok {
+					res = append(res, d.expand(m)...)
+				}
+			}
+		}
+	}
+	return res
+}
+
+func (d document) resolve(ref string) map[string]any {
+	var current any = d.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+	m, _ := current.(map[string]any)
+	return m
+}
+
+// refs returns the references a schema is defined by, if any
+func refs(s map[string]any) string {
+	var r []string
+	if ref, ok := s["$ref"].(string); ok {
+		r = append(r, ref)
+	}
+	for _, k := range []string{"oneOf", "anyOf", "allOf"} {
+		if alternatives, ok := s[k].([]any); ok {
+			for _, a := range alternatives {
+				if m, ok := a.(map[string]any); ok {
+					if ref, ok := m["$ref"].(string); ok {
+						r = append(r, ref)
+					}
+				}
+			}
+		}
+	}
+	return strings.Join(r, ",")
+}
+
+// properties collects properties declared by schema alternatives, nil if none declare properties
+func properties(schemas []map[string]any) map[string]map[string]any {
+	var props map[string]map[string]any
+	for _, s := range schemas {
+		p, ok := s["properties"].(map[string]any)
+		if !ok {
+			continue
+		}
+		if props == nil {
+			props = map[string]map[string]any{}
+		}
+		for name, v := range p {
+			if strings.HasPrefix(name, "x-") {
+				continue
+			}
+			if m, ok := v.(map[string]any); ok {
+				props[name] = m
+			}
+		}
+	}
+	return props
+}
+
+// values returns the schema for values of a mapping with arbitrary keys
+func values(schemas []map[string]any) map[string]any {
+	for _, s := range schemas {
+		if m, ok := s["additionalProperties"].(map[string]any); ok {
+			return m
+		}
+		if patterns, ok := s["patternProperties"].(map[string]any); ok {
+			for pattern, v := range patterns {
+				if pattern == extensionPattern {
+					continue
+				}
+				if m, ok := v.(map[string]any); ok {
+					return m
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func items(schemas []map[string]any) map[string]any {
+	for _, s := range schemas {
+		if m, ok := s["items"].(map[string]any); ok {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+const extensionPattern = "^x-"
+
+// decoder is implemented by types which support alternate syntaxes, see loader.Transform
+type decoder interface {
+	DecodeMapstructure(value interface{}) error
+}
+
+var decoderType = reflect.TypeOf((*decoder)(nil)).Elem()
+
+// Generate produces a JSON schema for the compose file model, reflecting on yaml tags set by types.Config
+func Generate() map[string]any {
+	return Reflect(types.Config{})
+}
+
+// Reflect produces a JSON schema for the Go type of v, based on its yaml tags.
+// Named struct types are declared as `definitions`.
+func Reflect(v any) map[string]any {
+	g := generator{definitions: map[string]any{}}
+	s := g.schemaFor(reflect.TypeOf(v))
+	if ref, ok := s["$ref"].(string); ok {
+		// expose root type as top-level schema
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		s = g.definitions[name].(map[string]any)
+		delete(g.definitions, name)
+		rewriteRef(s, ref, "#")
+	}
+	if len(g.definitions) > 0 {
+		s["definitions"] = g.definitions
+	}
+	return s
+}
+
+// rewriteRef replaces references to a definition
+func rewriteRef(node any, from, to string) {
+	switch n := node.(type) {
+	case map[string]any:
+		if n["$ref"] == from {
+			n["$ref"] = to
+		}
+		for _, v := range n {
+			rewriteRef(v, from, to)
+		}
+	case []any:
+		for _, v := range n {
+			rewriteRef(v, from, to)
+		}
+	}
+}
+
+// Extend returns the compose-spec schema with extensions declared as attributes wherever `x-*` attributes are allowed.
+// extensions maps `x-*` names to a Go value, as set by loader.Options.KnownExtensions, which schema is produced by Reflect.
+func Extend(extensions map[string]any) (string, error) {
+	fragments := map[string]map[string]any{}
+	for name, v := range extensions {
+		fragments[name] = Reflect(v)
+	}
+	return ExtendWith(Schema, fragments)
+}
+
+// ExtendWith returns base schema with extensions declared as attributes wherever `x-*` attributes are allowed.
+// fragments maps `x-*` names to a self-contained JSON schema, which can declare its own `definitions`.
+func ExtendWith(base string, fragments map[string]map[string]any) (string, error) {
+	var spec map[string]any
+	if err := json.Unmarshal([]byte(base), &spec); err != nil {
+		return "", err
+	}
+	definitions, ok := spec["definitions"].(map[string]any)
+	if !ok {
+		definitions = map[string]any{}
+		spec["definitions"] = definitions
+	}
+	declared := map[string]any{}
+	for name, fragment := range fragments {
+		// fragment is modified to rebase references, work on a copy
+		var copied map[string]any
+		b, err := json.Marshal(fragment)
+		if err != nil {
+			return "", err
+		}
+		if err := json.Unmarshal(b, &copied); err != nil {
+			return "", err
+		}
+		if defs, ok := copied["definitions"].(map[string]any); ok {
+			delete(copied, "definitions")
+			for def, v := range defs {
+				rebase(v, name)
+				definitions[name+"."+def] = v
+			}
+		}
+		rebase(copied, name)
+		definitions[name] = copied
+		declared[name] = map[string]any{"$ref": "#/definitions/" + name}
+	}
+	addExtensions(spec, declared)
+	b, err := json.Marshal(spec)
+	return string(b), err
+}
+
+// rebase rewrites local references in a fragment declared as definition `name`
+func rebase(node any, name string) {
+	switch n := node.(type) {
+	case map[string]any:
+		if ref, ok := n["$ref"].(string); ok {
+			switch {
+			case ref == "#":
+				n["$ref"] = "#/definitions/" + name
+			case strings.HasPrefix(ref, "#/definitions/"):
+				n["$ref"] = "#/definitions/" + name + "." + strings.TrimPrefix(ref, "#/definitions/")
+			}
+		}
+		for _, v := range n {
+			rebase(v, name)
+		}
+	case []any:
+		for _, v := range n {
+			rebase(v, name)
+		}
+	}
+}
+
+// addExtensions declares extensions as properties for all objects accepting `x-*` attributes
+func addExtensions(node any, extensions map[string]any) {
+	switch n := node.(type) {
+	case map[string]any:
+		for _, v := range n {
+			addExtensions(v, extensions)
+		}
+		patterns, ok := n["patternProperties"].(map[string]any)
+		if !ok {
+			return
+		}
+		if _, ok := patterns[extensionPattern]; !ok {
+			return
+		}
+		props, ok := n["properties"].(map[string]any)
+		if !ok {
+			props = map[string]any{}
+			n["properties"] = props
+		}
+		for name, s := range extensions {
+			props[name] = s
+		}
+	case []any:
+		for _, v := range n {
+			addExtensions(v, extensions)
+		}
+	}
+}
+
+type generator struct {
+	definitions map[string]any
+}
+
+func (g generator) schemaFor(t reflect.Type) map[string]any {
+	if t == nil {
+		return map[string]any{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	custom := reflect.PointerTo(t).Implements(decoderType)
+	if custom && t.Kind() != reflect.Struct {
+		// accepts alternate syntaxes we can't infer by reflection
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := g.definitions[name]; !ok {
+			// register placeholder first, so recursive types don't loop
+			g.definitions[name] = map[string]any{}
+			g.definitions[name] = g.structSchema(t)
+		}
+		ref := map[string]any{"$ref": "#/definitions/" + name}
+		if custom {
+			return map[string]any{"oneOf": []any{map[string]any{"type": "string"}, ref}}
+		}
+		return ref
+	case reflect.Map:
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": g.schemaFor(t.Elem()),
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string"}
+		}
+		return map[string]any{
+			"type":  "array",
+			"items": g.schemaFor(t.Elem()),
+		}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	// like compose-spec, scalars also accept strings as values are validated before interpolated ones are cast
+	case reflect.Bool:
+		return map[string]any{"type": []any{"boolean", "string"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": []any{"integer", "string"}}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": []any{"number", "string"}}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (g generator) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	s := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	g.addFields(s, properties, t)
+	return s
+}
+
+func (g generator) addFields(s map[string]any, properties map[string]any, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			switch ft.Kind() {
+			case reflect.Struct:
+				g.addFields(s, properties, ft)
+			case reflect.Map:
+				// inlined map is used to collect extensions
+				s["patternProperties"] = map[string]any{extensionPattern: map[string]any{}}
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		properties[name] = g.schemaFor(field.Type)
+	}
+}
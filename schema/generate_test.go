@@ -0,0 +1,211 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+	"golang.org/x/exp/slices"
+	"gotest.tools/v3/assert"
+)
+
+func TestReflect(t *testing.T) {
+	type child struct {
+		Name   string         `yaml:"name"`
+		Ignore string         `yaml:"-"`
+		Tags   []string       `yaml:"tags,omitempty"`
+		Extras map[string]any `yaml:"#extensions,inline"`
+	}
+	type parent struct {
+		Count    int              `yaml:"count"`
+		Enabled  bool             `yaml:"enabled"`
+		Children map[string]child `yaml:"children"`
+		Self     *parent          `yaml:"self"`
+	}
+
+	s := Reflect(parent{})
+	assert.DeepEqual(t, s, map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"count":   map[string]any{"type": []any{"integer", "string"}},
+			"enabled": map[string]any{"type": []any{"boolean", "string"}},
+			"children": map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"$ref": "#/definitions/child"},
+			},
+			"self": map[string]any{"$ref": "#"},
+		},
+		"definitions": map[string]any{
+			"child": map[string]any{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": map[string]any{
+					"name": map[string]any{"type": "string"},
+					"tags": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				},
+				"patternProperties": map[string]any{"^x-": map[string]any{}},
+			},
+		},
+	})
+}
+
+// knownDrift lists differences between compose-spec.json and Go types we accept.
+// When this test fails, either update compose-spec.json or types, or register the difference here.
+var knownDrift = []string{
+	// legacy attributes supported by Go types for backward compatibility
+	"configs.*.driver",
+	"configs.*.driver_opts",
+	"secrets.*.content",
+	"services.*.build.ulimits.*.single",
+	"services.*.deploy.resources.limits.devices",
+	"services.*.deploy.resources.limits.generic_resources",
+	"services.*.deploy.resources.reservations.pids",
+	"services.*.dockerfile",
+	"services.*.log_driver",
+	"services.*.log_opt",
+	"services.*.name",
+	"services.*.net",
+	"services.*.ulimits.*.single",
+	"services.*.volume_driver",
+	// attributes declared by compose-spec which Go types ignore
+	"networks.*.ipam.options",
+	"version",
+}
+
+func TestDrift(t *testing.T) {
+	differences, err := Drift()
+	assert.NilError(t, err)
+	found := map[string]bool{}
+	for _, d := range differences {
+		found[string(d.Path)] = true
+		if !slices.Contains(knownDrift, string(d.Path)) {
+			t.Errorf("unexpected drift: %s", d)
+		}
+	}
+	for _, known := range knownDrift {
+		if !found[known] {
+			t.Errorf("%s is now declared both by schema and Go types, remove it from knownDrift", known)
+		}
+	}
+}
+
+func TestExtend(t *testing.T) {
+	type monitoring struct {
+		Port    int    `yaml:"port"`
+		Enabled bool   `yaml:"enabled"`
+		Path    string `yaml:"path,omitempty"`
+	}
+	extended, err := Extend(map[string]any{"x-monitoring": monitoring{}})
+	assert.NilError(t, err)
+
+	validate := func(config dict) error {
+		result, err := gojsonschema.Validate(gojsonschema.NewStringLoader(extended), gojsonschema.NewGoLoader(config))
+		assert.NilError(t, err)
+		if !result.Valid() {
+			return toError(result)
+		}
+		return nil
+	}
+
+	assert.NilError(t, validate(dict{
+		"services": dict{
+			"foo": dict{
+				"image":        "foo",
+				"x-monitoring": dict{"port": 9090, "enabled": true},
+			},
+		},
+		"x-other": "anything",
+	}))
+
+	// interpolated values are validated as strings
+	assert.NilError(t, validate(dict{
+		"services": dict{
+			"foo": dict{
+				"image":        "foo",
+				"x-monitoring": dict{"port": "${PORT}", "enabled": "${ENABLED}"},
+			},
+		},
+	}))
+
+	err = validate(dict{
+		"services": dict{
+			"foo": dict{
+				"image":        "foo",
+				"x-monitoring": dict{"port": []any{9090}},
+			},
+		},
+	})
+	assert.ErrorContains(t, err, "services.foo.x-monitoring.port must be a integer")
+}
+
+func TestForVersion(t *testing.T) {
+	assert.DeepEqual(t, Versions(), []Version{Compose220, Compose222, Compose230, Compose232, Latest, Legacy})
+
+	latest, err := ForVersion("")
+	assert.NilError(t, err)
+	assert.Equal(t, latest, Schema)
+
+	legacy, err := ForVersion(Legacy)
+	assert.NilError(t, err)
+	config := dict{
+		"services": dict{
+			"foo": dict{
+				"image": "foo",
+				"net":   "host",
+			},
+		},
+	}
+	assert.ErrorContains(t, ValidateWith(latest, config), "Additional property net is not allowed")
+	assert.NilError(t, ValidateWith(legacy, config))
+}
+
+func TestRevisions(t *testing.T) {
+	config := dict{
+		"services": dict{
+			"foo": dict{
+				"image":   "foo",
+				"develop": dict{"watch": []any{dict{"path": "src", "action": "rebuild"}}},
+			},
+		},
+	}
+	for _, test := range []struct {
+		version  Version
+		rejected string
+	}{
+		{version: Compose220, rejected: "Additional property develop is not allowed"},
+		{version: Compose222},
+		{version: Compose232},
+	} {
+		t.Run(string(test.version), func(t *testing.T) {
+			s, err := ForVersion(test.version)
+			assert.NilError(t, err)
+			err = ValidateWith(s, config)
+			if test.rejected == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.rejected)
+			}
+		})
+	}
+
+	s, err := ForVersion(Compose222)
+	assert.NilError(t, err)
+	err = ValidateWith(s, dict{"services": dict{"foo": dict{"image": "foo", "label_file": "app.labels"}}})
+	assert.ErrorContains(t, err, "Additional property label_file is not allowed")
+}
@@ -0,0 +1,149 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Schema revisions supported by Docker Compose releases, computed from the embedded schema
+// by removing attributes introduced by later releases
+const (
+	Compose220 Version = "2.20"
+	Compose222 Version = "2.22"
+	Compose230 Version = "2.30"
+	Compose232 Version = "2.32"
+)
+
+// attribute is a compose attribute, with its location in the JSON schema
+type attribute struct {
+	path   string
+	schema []string
+}
+
+// introduced lists attributes by the Docker Compose release which introduced them, oldest first
+var introduced = []struct {
+	version    Version
+	attributes []attribute
+}{
+	{Compose220, []attribute{
+		{"include", []string{"properties", "include"}},
+	}},
+	{Compose222, []attribute{
+		{"services.*.develop", []string{"definitions", "service", "properties", "develop"}},
+	}},
+	{Compose230, []attribute{
+		{"services.*.gpus", []string{"definitions", "service", "properties", "gpus"}},
+		{"services.*.post_start", []string{"definitions", "service", "properties", "post_start"}},
+		{"services.*.pre_stop", []string{"definitions", "service", "properties", "pre_stop"}},
+	}},
+	{Compose232, []attribute{
+		{"services.*.label_file", []string{"definitions", "service", "properties", "label_file"}},
+	}},
+}
+
+// revision returns a function to compute schema for version
+func revision(version Version) func() (string, error) {
+	return func() (string, error) {
+		return revisionSchema(version)
+	}
+}
+
+// UnsupportedBy lists the attributes introduced after a Docker Compose release, like `2.21.0`,
+// as paths to be set as unsupported attributes
+func UnsupportedBy(release string) ([]string, error) {
+	var paths []string
+	for _, i := range introduced {
+		newer, err := isNewer(i.version, Version(release))
+		if err != nil {
+			return nil, err
+		}
+		if !newer {
+			continue
+		}
+		for _, a := range i.attributes {
+			paths = append(paths, a.path)
+		}
+	}
+	return paths, nil
+}
+
+// revisionSchema removes from embedded schema the attributes introduced after version
+func revisionSchema(version Version) (string, error) {
+	var spec map[string]any
+	if err := json.Unmarshal([]byte(Schema), &spec); err != nil {
+		return "", err
+	}
+	for _, i := range introduced {
+		newer, err := isNewer(i.version, version)
+		if err != nil {
+			return "", err
+		}
+		if !newer {
+			continue
+		}
+		for _, a := range i.attributes {
+			parent := spec
+			for _, key := range a.schema[:len(a.schema)-1] {
+				parent, _ = parent[key].(map[string]any)
+			}
+			if parent == nil {
+				return "", fmt.Errorf("compose-spec schema doesn't declare %s", a.path)
+			}
+			delete(parent, a.schema[len(a.schema)-1])
+		}
+	}
+	b, err := json.Marshal(spec)
+	return string(b), err
+}
+
+// isNewer compares two `major.minor[.patch]` release numbers
+func isNewer(version, than Version) (bool, error) {
+	a, err := parseRelease(version)
+	if err != nil {
+		return false, err
+	}
+	b, err := parseRelease(than)
+	if err != nil {
+		return false, err
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] > b[i], nil
+		}
+	}
+	return false, nil
+}
+
+func parseRelease(version Version) ([3]int, error) {
+	var release [3]int
+	parts := strings.Split(strings.TrimPrefix(string(version), "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return release, fmt.Errorf("invalid Docker Compose release %q, must be major.minor[.patch]", version)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return release, fmt.Errorf("invalid Docker Compose release %q, must be major.minor[.patch]", version)
+		}
+		release[i] = n
+	}
+	return release, nil
+}
@@ -57,7 +57,12 @@ var Schema string
 
 // Validate uses the jsonschema to validate the configuration
 func Validate(config map[string]interface{}) error {
-	schemaLoader := gojsonschema.NewStringLoader(Schema)
+	return ValidateWith(Schema, config)
+}
+
+// ValidateWith uses a custom jsonschema, typically produced by Extend, to validate the configuration
+func ValidateWith(schema string, config map[string]interface{}) error {
+	schemaLoader := gojsonschema.NewStringLoader(schema)
 	dataLoader := gojsonschema.NewGoLoader(config)
 
 	result, err := gojsonschema.Validate(schemaLoader, dataLoader)
@@ -128,6 +133,23 @@ func (err validationError) Error() string {
 	return fmt.Sprintf("%s %s", err.parent.Field(), description)
 }
 
+// Field returns the dotted path to the attribute in error, or an empty string for the top-level object
+func (err validationError) Field() string {
+	field := err.parent.Field()
+	if field == gojsonschema.STRING_CONTEXT_ROOT {
+		field = ""
+	}
+	if err.parent.Type() == "additional_property_not_allowed" {
+		if property, ok := err.parent.Details()["property"].(string); ok {
+			if field == "" {
+				return property
+			}
+			return field + "." + property
+		}
+	}
+	return field
+}
+
 func getMostSpecificError(errors []gojsonschema.ResultError) validationError {
 	mostSpecificError := 0
 	for i, err := range errors {
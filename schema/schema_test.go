@@ -17,6 +17,7 @@
 package schema
 
 import (
+	"errors"
 	"os"
 	"testing"
 
@@ -251,3 +252,23 @@ func TestValidateVariables(t *testing.T) {
 	assert.NilError(t, err)
 	assert.NilError(t, Validate(config))
 }
+
+func TestValidationErrorField(t *testing.T) {
+	var field interface{ Field() string }
+
+	err := Validate(dict{
+		"services": dict{
+			"foo": dict{
+				"image": 42,
+			},
+		},
+	})
+	assert.Check(t, errors.As(err, &field))
+	assert.Equal(t, field.Field(), "services.foo.image")
+
+	err = Validate(dict{
+		"helicopters": dict{},
+	})
+	assert.Check(t, errors.As(err, &field))
+	assert.Equal(t, field.Field(), "helicopters")
+}
@@ -0,0 +1,122 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+)
+
+// Version identifies a revision of the compose-spec schema
+type Version string
+
+const (
+	// Latest is the compose-spec schema embedded as Schema
+	Latest Version = "latest"
+	// Legacy accepts attributes from the legacy v2/v3 compose file formats which are still supported by Go types
+	Legacy Version = "legacy"
+)
+
+var (
+	versionsMu sync.Mutex
+	versions   = map[Version]func() (string, error){
+		Latest: func() (string, error) { return Schema, nil },
+		Legacy: legacySchema,
+
+		Compose220: revision(Compose220),
+		Compose222: revision(Compose222),
+		Compose230: revision(Compose230),
+		Compose232: revision(Compose232),
+	}
+	resolved = map[Version]string{}
+)
+
+// Register makes an additional schema revision available.
+// schema is computed lazily on first use
+func Register(version Version, schema func() (string, error)) {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	versions[version] = schema
+	delete(resolved, version)
+}
+
+// Versions lists the available schema revisions
+func Versions() []Version {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	var list []Version
+	for v := range versions {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i] < list[j]
+	})
+	return list
+}
+
+// ForVersion returns the JSON schema for a revision, Latest if version is not set
+func ForVersion(version Version) (string, error) {
+	if version == "" {
+		version = Latest
+	}
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	if s, ok := resolved[version]; ok {
+		return s, nil
+	}
+	fn, ok := versions[version]
+	if !ok {
+		return "", fmt.Errorf("unknown compose-spec schema version %q: %w", version, errdefs.ErrUnsupported)
+	}
+	s, err := fn()
+	if err != nil {
+		return "", err
+	}
+	resolved[version] = s
+	return s, nil
+}
+
+// legacyServiceAttributes are service attributes from the v2/v3 file formats
+var legacyServiceAttributes = map[string]any{
+	"dockerfile":    map[string]any{"type": "string"},
+	"log_driver":    map[string]any{"type": "string"},
+	"log_opt":       map[string]any{"type": "object", "patternProperties": map[string]any{"^.+$": map[string]any{"type": []any{"string", "number", "null"}}}},
+	"net":           map[string]any{"type": "string"},
+	"volume_driver": map[string]any{"type": "string"},
+}
+
+func legacySchema() (string, error) {
+	var spec map[string]any
+	if err := json.Unmarshal([]byte(Schema), &spec); err != nil {
+		return "", err
+	}
+	definitions, _ := spec["definitions"].(map[string]any)
+	service, _ := definitions["service"].(map[string]any)
+	properties, ok := service["properties"].(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("compose-spec schema doesn't declare service properties")
+	}
+	for name, s := range legacyServiceAttributes {
+		properties[name] = s
+	}
+	b, err := json.Marshal(spec)
+	return string(b), err
+}
@@ -21,9 +21,21 @@ import (
 
 	"github.com/compose-spec/compose-go/v2/tree"
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/go-connections/nat"
 	"github.com/go-viper/mapstructure/v2"
 )
 
+// PublishedRange returns the first and last host ports of a published port, which
+// is either a single port or a range like "8000-8010" left unexpanded by transformPorts
+// when mapped to a single target port
+func PublishedRange(published string) (start, end uint32, err error) {
+	first, last, err := nat.ParsePortRange(published)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(first), uint32(last), nil
+}
+
 func transformPorts(data any, p tree.Path, ignoreParseError bool) (any, error) {
 	switch entries := data.(type) {
 	case []any:
@@ -0,0 +1,68 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+// DependencyReason explains why a Dependency is part of a project
+type DependencyReason string
+
+const (
+	// DependencyComposeFile is a compose file set by ConfigDetails
+	DependencyComposeFile = DependencyReason("compose")
+	// DependencyOverride is a compose file set by ConfigDetails to override the first one
+	DependencyOverride = DependencyReason("override")
+	// DependencyInclude is a compose file loaded by `include`
+	DependencyInclude = DependencyReason("include")
+	// DependencyExtends is a compose file loaded by `extends.file`
+	DependencyExtends = DependencyReason("extends.file")
+	// DependencyDotEnv is a `.env` file used to interpolate compose files
+	DependencyDotEnv = DependencyReason(".env")
+	// DependencyIncludeEnvFile is a file set by `include.env_file`
+	DependencyIncludeEnvFile = DependencyReason("include.env_file")
+	// DependencyEnvFile is a file set by service `env_file`
+	DependencyEnvFile = DependencyReason("env_file")
+	// DependencyLabelFile is a file set by service `label_file`
+	DependencyLabelFile = DependencyReason("label_file")
+	// DependencyConfig is a file or directory set by `configs.*.file`
+	DependencyConfig = DependencyReason("configs.file")
+	// DependencySecret is a file or directory set by `secrets.*.file`
+	DependencySecret = DependencyReason("secrets.file")
+	// DependencyBuildContext is a directory set by service `build.context`
+	DependencyBuildContext = DependencyReason("build.context")
+)
+
+// Dependency is a local file or directory a project relies on
+type Dependency struct {
+	// Path is the absolute path to the file or directory
+	Path string `yaml:"path" json:"path"`
+	// Reason explains why Path is required
+	Reason DependencyReason `yaml:"reason" json:"reason"`
+	// Source is the compose file or resource which declares Path, if any
+	Source string `yaml:"source,omitempty" json:"source,omitempty"`
+}
+
+// DependencyPaths returns paths of all the dependencies, without duplicates
+func (p *Project) DependencyPaths() []string {
+	var paths []string
+	seen := map[string]bool{}
+	for _, d := range p.Dependencies {
+		if !seen[d.Path] {
+			seen[d.Path] = true
+			paths = append(paths, d.Path)
+		}
+	}
+	return paths
+}
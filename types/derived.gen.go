@@ -90,6 +90,24 @@ func deriveDeepCopyProject(dst, src *Project) {
 		}
 		copy(dst.Profiles, src.Profiles)
 	}
+	if src.Dependencies == nil {
+		dst.Dependencies = nil
+	} else {
+		if dst.Dependencies != nil {
+			if len(src.Dependencies) > len(dst.Dependencies) {
+				if cap(dst.Dependencies) >= len(src.Dependencies) {
+					dst.Dependencies = (dst.Dependencies)[:len(src.Dependencies)]
+				} else {
+					dst.Dependencies = make([]Dependency, len(src.Dependencies))
+				}
+			} else if len(src.Dependencies) < len(dst.Dependencies) {
+				dst.Dependencies = (dst.Dependencies)[:len(src.Dependencies)]
+			}
+		} else {
+			dst.Dependencies = make([]Dependency, len(src.Dependencies))
+		}
+		copy(dst.Dependencies, src.Dependencies)
+	}
 }
 
 // deriveDeepCopyService recursively copies the contents of src into dst.
@@ -0,0 +1,210 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"github.com/compose-spec/compose-go/v2/dotenv"
+	"github.com/compose-spec/compose-go/v2/vfs"
+	"golang.org/x/exp/slices"
+)
+
+// EnvLayer identifies where environment variables used for interpolation come from
+type EnvLayer string
+
+const (
+	// EnvLayerOS is the environment of the current process
+	EnvLayerOS = EnvLayer("os")
+	// EnvLayerDotEnv is the default `.env` file in project directory
+	EnvLayerDotEnv = EnvLayer(".env")
+	// EnvLayerEnvFile are env files explicitly set, like by `--env-file` or `include.env_file`
+	EnvLayerEnvFile = EnvLayer("env_file")
+	// EnvLayerCLI are variables set on command line, like by `--env`
+	EnvLayerCLI = EnvLayer("cli")
+	// EnvLayerProgrammatic are variables set by code embedding the loader
+	EnvLayerProgrammatic = EnvLayer("programmatic")
+	// EnvLayerRemote are variables fetched from remote providers, like a secret store
+	EnvLayerRemote = EnvLayer("remote")
+	// EnvLayerProject is the environment of the enclosing project, for an included project
+	EnvLayerProject = EnvLayer("project")
+)
+
+// DefaultEnvPrecedence lists layers from highest to lowest precedence
+var DefaultEnvPrecedence = []EnvLayer{
+	EnvLayerProgrammatic,
+	EnvLayerCLI,
+	EnvLayerOS,
+	EnvLayerProject,
+	EnvLayerRemote,
+	EnvLayerEnvFile,
+	EnvLayerDotEnv,
+}
+
+// EnvValue is the value of a variable, with its origin
+type EnvValue struct {
+	Value string
+	Layer EnvLayer
+	// Source is the file or provider which set the value, if any
+	Source string
+}
+
+// EnvSources manages environment variables as ordered layers, so a variable resolves to the value set by the
+// layer with the highest precedence. Within a layer, sources added last take precedence.
+type EnvSources struct {
+	// Precedence lists layers from highest to lowest precedence, DefaultEnvPrecedence if not set.
+	// Layers not listed have the lowest precedence
+	Precedence []EnvLayer
+	layers     map[EnvLayer][]envSource
+}
+
+type envSource struct {
+	name   string
+	values Mapping
+}
+
+// NewEnvSources creates an empty EnvSources with DefaultEnvPrecedence
+func NewEnvSources() *EnvSources {
+	return &EnvSources{
+		layers: map[EnvLayer][]envSource{},
+	}
+}
+
+// Add sets values for layer, source being the file or provider they come from
+func (e *EnvSources) Add(layer EnvLayer, source string, values Mapping) *EnvSources {
+	if e.layers == nil {
+		e.layers = map[EnvLayer][]envSource{}
+	}
+	e.layers[layer] = append(e.layers[layer], envSource{name: source, values: values.Clone()})
+	return e
+}
+
+// Set merges values into source of layer, adding it if not set yet, so repeated updates don't stack new sources
+func (e *EnvSources) Set(layer EnvLayer, source string, values Mapping) *EnvSources {
+	sources := e.layers[layer]
+	for i := len(sources) - 1; i >= 0; i-- {
+		if sources[i].name == source {
+			merged := sources[i].values.Clone()
+			for k, v := range values {
+				merged[k] = v
+			}
+			// replace, so clones sharing this source are not affected
+			sources[i] = envSource{name: source, values: merged}
+			return e
+		}
+	}
+	return e.Add(layer, source, values)
+}
+
+// Unset removes key from all layers
+func (e *EnvSources) Unset(key string) *EnvSources {
+	for _, sources := range e.layers {
+		for i, s := range sources {
+			if _, ok := s.values[key]; ok {
+				values := s.values.Clone()
+				delete(values, key)
+				sources[i] = envSource{name: s.name, values: values}
+			}
+		}
+	}
+	return e
+}
+
+// AddFiles reads env files from fsys as sources for layer. Variables in files are resolved with values
+// already set, including those set by previous files
+func (e *EnvSources) AddFiles(fsys vfs.FS, layer EnvLayer, files ...string) error {
+	for _, f := range files {
+		values, err := dotenv.GetEnvFromFileFS(fsys, e.Mapping(), []string{f})
+		if err != nil {
+			return err
+		}
+		e.Add(layer, f, values)
+	}
+	return nil
+}
+
+// Resolve returns the value for key, from the layer with highest precedence which sets it
+func (e *EnvSources) Resolve(key string) (EnvValue, bool) {
+	for _, layer := range e.order() {
+		sources := e.layers[layer]
+		for i := len(sources) - 1; i >= 0; i-- {
+			if v, ok := sources[i].values[key]; ok {
+				return EnvValue{Value: v, Layer: layer, Source: sources[i].name}, true
+			}
+		}
+	}
+	return EnvValue{}, false
+}
+
+// Lookup returns the value for key, as an interpolation LookupValue function
+func (e *EnvSources) Lookup(key string) (string, bool) {
+	v, ok := e.Resolve(key)
+	return v.Value, ok
+}
+
+// Mapping returns all variables with their resolved values
+func (e *EnvSources) Mapping() Mapping {
+	m := Mapping{}
+	for _, layer := range e.order() {
+		sources := e.layers[layer]
+		for i := len(sources) - 1; i >= 0; i-- {
+			m.Merge(sources[i].values)
+		}
+	}
+	return m
+}
+
+// Layers returns the layers which set key, from highest to lowest precedence
+func (e *EnvSources) Layers(key string) []EnvValue {
+	var values []EnvValue
+	for _, layer := range e.order() {
+		sources := e.layers[layer]
+		for i := len(sources) - 1; i >= 0; i-- {
+			if v, ok := sources[i].values[key]; ok {
+				values = append(values, EnvValue{Value: v, Layer: layer, Source: sources[i].name})
+			}
+		}
+	}
+	return values
+}
+
+// Clone returns a copy of e
+func (e *EnvSources) Clone() *EnvSources {
+	c := &EnvSources{
+		Precedence: append([]EnvLayer(nil), e.Precedence...),
+		layers:     make(map[EnvLayer][]envSource, len(e.layers)),
+	}
+	for layer, sources := range e.layers {
+		c.layers[layer] = append([]envSource(nil), sources...)
+	}
+	return c
+}
+
+// order returns layers from highest to lowest precedence, including those not listed by Precedence
+func (e *EnvSources) order() []EnvLayer {
+	precedence := e.Precedence
+	if precedence == nil {
+		precedence = DefaultEnvPrecedence
+	}
+	order := append([]EnvLayer(nil), precedence...)
+	var others []EnvLayer
+	for layer := range e.layers {
+		if !slices.Contains(order, layer) {
+			others = append(others, layer)
+		}
+	}
+	slices.Sort(others)
+	return append(order, others...)
+}
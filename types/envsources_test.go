@@ -0,0 +1,100 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/compose-spec/compose-go/v2/vfs"
+	"gotest.tools/v3/assert"
+)
+
+func TestEnvSources(t *testing.T) {
+	sources := NewEnvSources().
+		Add(EnvLayerDotEnv, "/project/.env", Mapping{"A": "dotenv", "B": "dotenv", "C": "dotenv"}).
+		Add(EnvLayerCLI, "", Mapping{"A": "cli"}).
+		Add(EnvLayerOS, "", Mapping{"A": "os", "B": "os"}).
+		Add(EnvLayerRemote, "vault", Mapping{"D": "first"}).
+		Add(EnvLayerRemote, "ssm", Mapping{"D": "second"})
+
+	v, ok := sources.Resolve("A")
+	assert.Check(t, ok)
+	assert.Equal(t, v, EnvValue{Value: "cli", Layer: EnvLayerCLI})
+	v, _ = sources.Resolve("B")
+	assert.Equal(t, v, EnvValue{Value: "os", Layer: EnvLayerOS})
+	v, _ = sources.Resolve("C")
+	assert.Equal(t, v, EnvValue{Value: "dotenv", Layer: EnvLayerDotEnv, Source: "/project/.env"})
+	v, _ = sources.Resolve("D")
+	assert.Equal(t, v, EnvValue{Value: "second", Layer: EnvLayerRemote, Source: "ssm"})
+	_, ok = sources.Resolve("E")
+	assert.Check(t, !ok)
+
+	assert.DeepEqual(t, sources.Mapping(), Mapping{"A": "cli", "B": "os", "C": "dotenv", "D": "second"})
+	assert.DeepEqual(t, sources.Layers("A"), []EnvValue{
+		{Value: "cli", Layer: EnvLayerCLI},
+		{Value: "os", Layer: EnvLayerOS},
+		{Value: "dotenv", Layer: EnvLayerDotEnv, Source: "/project/.env"},
+	})
+
+	custom := sources.Clone()
+	custom.Precedence = []EnvLayer{EnvLayerDotEnv, EnvLayerOS}
+	v, _ = custom.Resolve("A")
+	assert.Equal(t, v.Layer, EnvLayerDotEnv)
+	v, _ = custom.Resolve("D")
+	assert.Equal(t, v.Layer, EnvLayerRemote, "layers not listed have lowest precedence")
+	v, _ = sources.Resolve("A")
+	assert.Equal(t, v.Layer, EnvLayerCLI, "clone doesn't modify original")
+}
+
+func TestEnvSourcesSetUnset(t *testing.T) {
+	sources := NewEnvSources().
+		Add(EnvLayerOS, "", Mapping{"A": "os", "B": "os"}).
+		Set(EnvLayerProgrammatic, "", Mapping{"A": "first"}).
+		Set(EnvLayerProgrammatic, "", Mapping{"A": "second", "C": "direct"})
+	assert.DeepEqual(t, sources.Layers("A"), []EnvValue{
+		{Value: "second", Layer: EnvLayerProgrammatic},
+		{Value: "os", Layer: EnvLayerOS},
+	})
+
+	clone := sources.Clone()
+	sources.Unset("A")
+	_, ok := sources.Resolve("A")
+	assert.Check(t, !ok)
+	assert.DeepEqual(t, sources.Mapping(), Mapping{"B": "os", "C": "direct"})
+	v, _ := clone.Resolve("A")
+	assert.Equal(t, v.Value, "second", "clone is not modified")
+}
+
+func TestEnvSourcesAddFiles(t *testing.T) {
+	root := filepath.FromSlash("/project")
+	fsys := vfs.FromFS(fstest.MapFS{
+		"first.env":  {Data: []byte("GREETING=hello ${NAME}\nCOLOR=blue\n")},
+		"second.env": {Data: []byte("COLOR=red\nMESSAGE=${GREETING}!\n")},
+	}, root)
+	sources := NewEnvSources().Add(EnvLayerOS, "", Mapping{"NAME": "world"})
+	err := sources.AddFiles(fsys, EnvLayerEnvFile, filepath.Join(root, "first.env"), filepath.Join(root, "second.env"))
+	assert.NilError(t, err)
+
+	v, _ := sources.Resolve("COLOR")
+	assert.Equal(t, v, EnvValue{Value: "red", Layer: EnvLayerEnvFile, Source: filepath.Join(root, "second.env")})
+	assert.Equal(t, sources.Mapping()["MESSAGE"], "hello world!")
+
+	err = sources.AddFiles(fsys, EnvLayerEnvFile, filepath.Join(root, "missing.env"))
+	assert.ErrorContains(t, err, "Couldn't find env file")
+}
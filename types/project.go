@@ -28,6 +28,7 @@ import (
 	"github.com/compose-spec/compose-go/v2/dotenv"
 	"github.com/compose-spec/compose-go/v2/errdefs"
 	"github.com/compose-spec/compose-go/v2/utils"
+	"github.com/compose-spec/compose-go/v2/vfs"
 	"github.com/distribution/reference"
 	godigest "github.com/opencontainers/go-digest"
 	"golang.org/x/exp/maps"
@@ -54,6 +55,9 @@ type Project struct {
 	// DisabledServices track services which have been disable as profile is not active
 	DisabledServices Services `yaml:"-" json:"-"`
 	Profiles         []string `yaml:"-" json:"-"`
+
+	// Dependencies lists local files read or referenced while loading the project
+	Dependencies []Dependency `yaml:"-" json:"-"`
 }
 
 // ServiceNames return names for all services in this Compose config
@@ -631,6 +635,12 @@ func (p *Project) MarshalJSON(options ...func(*marshallOptions)) ([]byte, error)
 // WithServicesEnvironmentResolved parses env_files set for services to resolve the actual environment map for services
 // It returns a new Project instance with the changes and keep the original Project unchanged
 func (p Project) WithServicesEnvironmentResolved(discardEnvFiles bool) (*Project, error) {
+	return p.WithServicesEnvironmentResolvedFS(vfs.OS, discardEnvFiles)
+}
+
+// WithServicesEnvironmentResolvedFS parses env_files set for services from fsys to resolve the actual environment map for services
+// It returns a new Project instance with the changes and keep the original Project unchanged
+func (p Project) WithServicesEnvironmentResolvedFS(fsys vfs.FS, discardEnvFiles bool) (*Project, error) {
 	newProject := p.deepCopy()
 	for i, service := range newProject.Services {
 		service.Environment = service.Environment.Resolve(newProject.Environment.Resolve)
@@ -646,7 +656,7 @@ func (p Project) WithServicesEnvironmentResolved(discardEnvFiles bool) (*Project
 		}
 
 		for _, envFile := range service.EnvFiles {
-			vars, err := loadEnvFile(envFile, resolve)
+			vars, err := loadEnvFile(fsys, envFile, resolve)
 			if err != nil {
 				return nil, err
 			}
@@ -666,6 +676,12 @@ func (p Project) WithServicesEnvironmentResolved(discardEnvFiles bool) (*Project
 // WithServicesLabelsResolved parses label_files set for services to resolve the actual label map for services
 // It returns a new Project instance with the changes and keep the original Project unchanged
 func (p Project) WithServicesLabelsResolved(discardLabelFiles bool) (*Project, error) {
+	return p.WithServicesLabelsResolvedFS(vfs.OS, discardLabelFiles)
+}
+
+// WithServicesLabelsResolvedFS parses label_files set for services from fsys to resolve the actual label map for services
+// It returns a new Project instance with the changes and keep the original Project unchanged
+func (p Project) WithServicesLabelsResolvedFS(fsys vfs.FS, discardLabelFiles bool) (*Project, error) {
 	newProject := p.deepCopy()
 	for i, service := range newProject.Services {
 		labels := MappingWithEquals{}
@@ -679,7 +695,7 @@ func (p Project) WithServicesLabelsResolved(discardLabelFiles bool) (*Project, e
 		}
 
 		for _, labelFile := range service.LabelFiles {
-			vars, err := loadLabelFile(labelFile, resolve)
+			vars, err := loadLabelFile(fsys, labelFile, resolve)
 			if err != nil {
 				return nil, err
 			}
@@ -701,27 +717,31 @@ func (p Project) WithServicesLabelsResolved(discardLabelFiles bool) (*Project, e
 	return newProject, nil
 }
 
-func loadEnvFile(envFile EnvFile, resolve dotenv.LookupFn) (Mapping, error) {
-	if _, err := os.Stat(envFile.Path); os.IsNotExist(err) {
+func loadEnvFile(fsys vfs.FS, envFile EnvFile, resolve dotenv.LookupFn) (Mapping, error) {
+	if _, err := fsys.Stat(envFile.Path); os.IsNotExist(err) {
 		if envFile.Required {
 			return nil, fmt.Errorf("env file %s not found: %w", envFile.Path, err)
 		}
 		return nil, nil
 	}
 
-	return loadMappingFile(envFile.Path, envFile.Format, resolve)
+	format := envFile.Format
+	if format == "" {
+		format = dotenv.FormatFromFilename(envFile.Path)
+	}
+	return loadMappingFile(fsys, envFile.Path, format, resolve)
 }
 
-func loadLabelFile(labelFile string, resolve dotenv.LookupFn) (Mapping, error) {
-	if _, err := os.Stat(labelFile); os.IsNotExist(err) {
+func loadLabelFile(fsys vfs.FS, labelFile string, resolve dotenv.LookupFn) (Mapping, error) {
+	if _, err := fsys.Stat(labelFile); os.IsNotExist(err) {
 		return nil, fmt.Errorf("label file %s not found: %w", labelFile, err)
 	}
 
-	return loadMappingFile(labelFile, "", resolve)
+	return loadMappingFile(fsys, labelFile, "", resolve)
 }
 
-func loadMappingFile(path string, format string, resolve dotenv.LookupFn) (Mapping, error) {
-	file, err := os.Open(path)
+func loadMappingFile(fsys vfs.FS, path string, format string, resolve dotenv.LookupFn) (Mapping, error) {
+	file, err := fsys.Open(path)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,296 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/errdefs"
+	"github.com/compose-spec/compose-go/v2/utils"
+	"golang.org/x/exp/slices"
+)
+
+// Topology describes how services of a project are connected
+type Topology struct {
+	// Routes lists, for each service, the services it can reach
+	Routes map[string]map[string]Route
+	// ExternalLinks lists, for each service, the external containers it is linked to, by hostname
+	ExternalLinks map[string]map[string]string
+	// Egress tells if a service has access to networks outside the project
+	Egress map[string]bool
+	// Published lists ports published by services, indexed by host IP
+	Published map[string][]PublishedPort
+}
+
+// Route describes how a service can reach another one
+type Route struct {
+	// Networks shared by both services, empty when both share the same network stack
+	Networks []string
+	// Hostnames resolving to the target service
+	Hostnames []string
+}
+
+// PublishedPort is a port published on host by a service
+type PublishedPort struct {
+	Service   string
+	HostIP    string
+	Published string
+	Target    uint32
+	Protocol  string
+}
+
+// CanReach tells if service `from` can reach service `to`
+func (t Topology) CanReach(from, to string) bool {
+	_, ok := t.Routes[from][to]
+	return ok
+}
+
+const (
+	networkModeHost      = "host"
+	networkModeNone      = "none"
+	networkModeContainer = "container"
+)
+
+// networkStack is the network namespace used by a service
+type networkStack struct {
+	// owner is the service owning the network namespace, empty for host or isolated services
+	owner string
+	// mode is one of host, none or container for services not owning a namespace on project networks
+	mode     string
+	networks map[string]*ServiceNetworkConfig
+}
+
+// Topology computes reachability between services based on networks, network_mode and links
+func (p *Project) Topology() Topology {
+	t := Topology{
+		Routes:        map[string]map[string]Route{},
+		ExternalLinks: map[string]map[string]string{},
+		Egress:        map[string]bool{},
+		Published:     map[string][]PublishedPort{},
+	}
+
+	names := p.ServiceNames()
+	stacks := map[string]networkStack{}
+	for _, name := range names {
+		stacks[name] = p.networkStack(name, map[string]bool{})
+	}
+
+	for _, from := range names {
+		s := stacks[from]
+		routes := map[string]Route{}
+		for _, to := range names {
+			if from == to {
+				continue
+			}
+			d := stacks[to]
+			switch {
+			case s.mode == networkModeHost && d.mode == networkModeHost,
+				s.mode == "" && s.owner == d.owner:
+				routes[to] = Route{Hostnames: []string{"localhost"}}
+			case s.mode == "" && d.mode == "":
+				var shared []string
+				hostnames := utils.Set[string]{}
+				for _, network := range utils.MapKeys(s.networks) {
+					c, ok := d.networks[network]
+					if !ok {
+						continue
+					}
+					shared = append(shared, network)
+					// a service attached to another service's stack is only reachable using the owner's names
+					hostnames.Add(d.owner)
+					if c != nil {
+						hostnames.AddAll(c.Aliases...)
+					}
+				}
+				if len(shared) > 0 {
+					routes[to] = Route{Networks: shared, Hostnames: utils.MapKeys(hostnames)}
+				}
+			}
+		}
+		for _, link := range p.Services[from].Links {
+			target, alias, ok := strings.Cut(link, ":")
+			if !ok {
+				alias = target
+			}
+			if r, ok := routes[target]; ok && !slices.Contains(r.Hostnames, alias) {
+				r.Hostnames = append(r.Hostnames, alias)
+				sort.Strings(r.Hostnames)
+				routes[target] = r
+			}
+		}
+		t.Routes[from] = routes
+
+		for _, link := range p.Services[from].ExternalLinks {
+			container, alias, ok := strings.Cut(link, ":")
+			if !ok {
+				alias = container
+			}
+			if t.ExternalLinks[from] == nil {
+				t.ExternalLinks[from] = map[string]string{}
+			}
+			t.ExternalLinks[from][alias] = container
+		}
+
+		t.Egress[from] = p.hasEgress(s)
+
+		// published ports are ignored by the engine when a service doesn't own its network stack,
+		// and are not reachable from host if the service is only attached to internal networks
+		if s.mode != "" || s.owner != from || !t.Egress[from] {
+			continue
+		}
+		for _, port := range p.Services[from].Ports {
+			if port.Published == "" {
+				continue
+			}
+			ip := port.HostIP
+			if ip == "" {
+				ip = "0.0.0.0"
+			}
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			t.Published[ip] = append(t.Published[ip], PublishedPort{
+				Service:   from,
+				HostIP:    ip,
+				Published: port.Published,
+				Target:    port.Target,
+				Protocol:  protocol,
+			})
+		}
+	}
+	return t
+}
+
+func (p *Project) networkStack(name string, seen map[string]bool) networkStack {
+	s, ok := p.Services[name]
+	if !ok || seen[name] {
+		return networkStack{mode: networkModeNone}
+	}
+	seen[name] = true
+	switch {
+	case strings.HasPrefix(s.NetworkMode, ServicePrefix):
+		return p.networkStack(s.NetworkMode[len(ServicePrefix):], seen)
+	case s.NetworkMode == networkModeHost, s.NetworkMode == networkModeNone:
+		return networkStack{mode: s.NetworkMode}
+	case strings.HasPrefix(s.NetworkMode, ContainerPrefix):
+		return networkStack{mode: networkModeContainer}
+	}
+	networks := s.Networks
+	if len(networks) == 0 {
+		networks = map[string]*ServiceNetworkConfig{"default": nil}
+	}
+	return networkStack{owner: name, networks: networks}
+}
+
+func (p *Project) hasEgress(s networkStack) bool {
+	switch s.mode {
+	case networkModeHost:
+		return true
+	case "":
+		for network := range s.networks {
+			if !p.Networks[network].Internal {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckIPAM validates static IP addresses and subnets declared by networks don't conflict
+func (p *Project) CheckIPAM() error {
+	var errs []error
+	subnets := map[string][]netip.Prefix{}
+	for _, name := range p.NetworkNames() {
+		if p.Networks[name].External {
+			// IPAM of an external network is managed outside compose project
+			continue
+		}
+		for _, pool := range p.Networks[name].Ipam.Config {
+			if pool == nil || pool.Subnet == "" {
+				continue
+			}
+			subnet, err := netip.ParsePrefix(pool.Subnet)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("networks.%s: invalid subnet %q: %w", name, pool.Subnet, errdefs.ErrInvalid))
+				continue
+			}
+			subnets[name] = append(subnets[name], subnet.Masked())
+		}
+	}
+
+	networks := utils.MapKeys(subnets)
+	for i, name := range networks {
+		for _, other := range networks[i+1:] {
+			for _, a := range subnets[name] {
+				for _, b := range subnets[other] {
+					if a.Overlaps(b) {
+						errs = append(errs, fmt.Errorf("networks.%s: subnet %s overlaps with subnet %s of network %s: %w", name, a, b, other, errdefs.ErrInvalid))
+					}
+				}
+			}
+		}
+	}
+
+	type assignment struct {
+		service string
+		address netip.Addr
+	}
+	assigned := map[string][]assignment{}
+	for _, service := range p.ServiceNames() {
+		s := p.Services[service]
+		for _, network := range utils.MapKeys(s.Networks) {
+			c := s.Networks[network]
+			if c == nil {
+				continue
+			}
+			for _, address := range []string{c.Ipv4Address, c.Ipv6Address} {
+				if address == "" {
+					continue
+				}
+				path := fmt.Sprintf("services.%s.networks.%s", service, network)
+				ip, err := netip.ParseAddr(address)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: invalid IP address %q: %w", path, address, errdefs.ErrInvalid))
+					continue
+				}
+				// address is assigned by the engine when network declares no subnet of the same family
+				var family []netip.Prefix
+				for _, subnet := range subnets[network] {
+					if subnet.Addr().Is4() == ip.Is4() {
+						family = append(family, subnet)
+					}
+				}
+				if len(family) > 0 && !slices.ContainsFunc(family, func(subnet netip.Prefix) bool { return subnet.Contains(ip) }) {
+					errs = append(errs, fmt.Errorf("%s: IP address %s is not in any subnet of network %s: %w", path, ip, network, errdefs.ErrInvalid))
+					continue
+				}
+				for _, a := range assigned[network] {
+					if a.address == ip {
+						errs = append(errs, fmt.Errorf("%s: IP address %s is already assigned to service %s: %w", path, ip, a.service, errdefs.ErrInvalid))
+					}
+				}
+				assigned[network] = append(assigned[network], assignment{service: service, address: ip})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
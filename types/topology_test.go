@@ -0,0 +1,170 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package types
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestTopology(t *testing.T) {
+	p := &Project{
+		Networks: Networks{
+			"front": {},
+			"back":  {Internal: true},
+		},
+		Services: Services{
+			"proxy": {
+				Name:     "proxy",
+				Networks: map[string]*ServiceNetworkConfig{"front": nil},
+				Links:    []string{"web:app"},
+				Ports:    []ServicePortConfig{{Target: 80, Published: "8080"}, {Target: 443, Published: "8443", HostIP: "127.0.0.1", Protocol: "tcp"}},
+			},
+			"web": {
+				Name: "web",
+				Networks: map[string]*ServiceNetworkConfig{
+					"front": {Aliases: []string{"www"}},
+					"back":  nil,
+				},
+				ExternalLinks: []string{"legacy_db:olddb"},
+			},
+			"sidecar": {
+				Name:        "sidecar",
+				NetworkMode: "service:web",
+				Ports:       []ServicePortConfig{{Target: 9000, Published: "9000"}},
+			},
+			"db": {
+				Name:     "db",
+				Networks: map[string]*ServiceNetworkConfig{"back": nil},
+				Ports:    []ServicePortConfig{{Target: 5432, Published: "5432"}},
+			},
+			"agent": {
+				Name:        "agent",
+				NetworkMode: "host",
+			},
+			"monitor": {
+				Name:        "monitor",
+				NetworkMode: "host",
+			},
+		},
+	}
+
+	topology := p.Topology()
+	assert.DeepEqual(t, topology.Routes, map[string]map[string]Route{
+		"agent": {
+			"monitor": {Hostnames: []string{"localhost"}},
+		},
+		"db": {
+			"sidecar": {Networks: []string{"back"}, Hostnames: []string{"web"}},
+			"web":     {Networks: []string{"back"}, Hostnames: []string{"web"}},
+		},
+		"monitor": {
+			"agent": {Hostnames: []string{"localhost"}},
+		},
+		"proxy": {
+			"sidecar": {Networks: []string{"front"}, Hostnames: []string{"web", "www"}},
+			"web":     {Networks: []string{"front"}, Hostnames: []string{"app", "web", "www"}},
+		},
+		"sidecar": {
+			"db":    {Networks: []string{"back"}, Hostnames: []string{"db"}},
+			"proxy": {Networks: []string{"front"}, Hostnames: []string{"proxy"}},
+			"web":   {Hostnames: []string{"localhost"}},
+		},
+		"web": {
+			"db":      {Networks: []string{"back"}, Hostnames: []string{"db"}},
+			"proxy":   {Networks: []string{"front"}, Hostnames: []string{"proxy"}},
+			"sidecar": {Hostnames: []string{"localhost"}},
+		},
+	})
+	assert.Check(t, topology.CanReach("proxy", "web"))
+	assert.Check(t, !topology.CanReach("proxy", "db"))
+	assert.DeepEqual(t, topology.ExternalLinks, map[string]map[string]string{
+		"web": {"olddb": "legacy_db"},
+	})
+	assert.DeepEqual(t, topology.Egress, map[string]bool{
+		"agent":   true,
+		"db":      false,
+		"monitor": true,
+		"proxy":   true,
+		"sidecar": true,
+		"web":     true,
+	})
+	assert.DeepEqual(t, topology.Published, map[string][]PublishedPort{
+		"0.0.0.0":   {{Service: "proxy", HostIP: "0.0.0.0", Published: "8080", Target: 80, Protocol: "tcp"}},
+		"127.0.0.1": {{Service: "proxy", HostIP: "127.0.0.1", Published: "8443", Target: 443, Protocol: "tcp"}},
+	})
+}
+
+func TestCheckIPAM(t *testing.T) {
+	p := &Project{
+		Networks: Networks{
+			"front": {Ipam: IPAMConfig{Config: []*IPAMPool{{Subnet: "172.28.0.0/16"}}}},
+			"back":  {Ipam: IPAMConfig{Config: []*IPAMPool{{Subnet: "172.28.5.0/24"}}}},
+			"plain": {},
+			"outer": {External: true, Ipam: IPAMConfig{Config: []*IPAMPool{{Subnet: "172.28.0.0/16"}}}},
+		},
+		Services: Services{
+			"a": {
+				Name: "a",
+				Networks: map[string]*ServiceNetworkConfig{
+					"front": {Ipv4Address: "172.28.1.10"},
+					"back":  {Ipv4Address: "10.0.0.1"},
+				},
+			},
+			"b": {
+				Name: "b",
+				Networks: map[string]*ServiceNetworkConfig{
+					"front": {Ipv4Address: "172.28.1.10"},
+					"plain": {Ipv4Address: "192.168.1.1"},
+					"outer": {Ipv4Address: "10.0.0.2"},
+				},
+			},
+		},
+	}
+	assert.Error(t, p.CheckIPAM(), `networks.back: subnet 172.28.5.0/24 overlaps with subnet 172.28.0.0/16 of network front: invalid compose project
+services.a.networks.back: IP address 10.0.0.1 is not in any subnet of network back: invalid compose project
+services.b.networks.front: IP address 172.28.1.10 is already assigned to service a: invalid compose project`)
+
+	p.Networks["back"] = NetworkConfig{Ipam: IPAMConfig{Config: []*IPAMPool{{Subnet: "10.0.0.0/8"}}}}
+	p.Services["b"].Networks["front"].Ipv4Address = "172.28.1.11"
+	assert.NilError(t, p.CheckIPAM())
+}
+
+func TestCheckIPAMDualStack(t *testing.T) {
+	ipv6 := true
+	p := &Project{
+		Networks: Networks{
+			"n": {EnableIPv6: &ipv6, Ipam: IPAMConfig{Config: []*IPAMPool{{Subnet: "172.28.0.0/16"}}}},
+		},
+		Services: Services{
+			"a": {
+				Name: "a",
+				Networks: map[string]*ServiceNetworkConfig{
+					"n": {Ipv4Address: "172.28.1.10", Ipv6Address: "2001:db8::5"},
+				},
+			},
+		},
+	}
+	assert.NilError(t, p.CheckIPAM())
+
+	p.Networks["n"] = NetworkConfig{EnableIPv6: &ipv6, Ipam: IPAMConfig{Config: []*IPAMPool{
+		{Subnet: "172.28.0.0/16"},
+		{Subnet: "2001:db8:1::/64"},
+	}}}
+	assert.Error(t, p.CheckIPAM(), "services.a.networks.n: IP address 2001:db8::5 is not in any subnet of network n: invalid compose project")
+}
@@ -20,6 +20,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/compose-spec/compose-go/v2/vfs"
 )
 
 // ResolveSymbolicLink converts the section of an absolute path if it is a
@@ -32,7 +34,18 @@ import (
 //   - converted path if it has a symbolic link or the same path if there is
 //     no symbolic link
 func ResolveSymbolicLink(path string) (string, error) {
-	sym, part, err := getSymbolinkLink(path)
+	return ResolveSymbolicLinkFS(vfs.OS, path)
+}
+
+// ResolveSymbolicLinkFS converts the section of an absolute path if it is a
+// symbolic link on fsys. Path is returned unchanged if fsys doesn't support
+// symbolic links
+func ResolveSymbolicLinkFS(fsys vfs.FS, path string) (string, error) {
+	links, ok := fsys.(vfs.SymlinkFS)
+	if !ok {
+		return path, nil
+	}
+	sym, part, err := getSymbolinkLink(links, path)
 	if err != nil {
 		return "", err
 	}
@@ -53,7 +66,7 @@ func ResolveSymbolicLink(path string) (string, error) {
 //   - string section of the path that is a symbolic link
 //   - string correspondent path section of the symbolic link
 //   - An error
-func getSymbolinkLink(path string) (string, string, error) {
+func getSymbolinkLink(fsys vfs.SymlinkFS, path string) (string, string, error) {
 	parts := strings.Split(path, string(os.PathSeparator))
 
 	// Reconstruct the path step by step, checking each component
@@ -68,9 +81,9 @@ func getSymbolinkLink(path string) (string, string, error) {
 		}
 		currentPath = filepath.Join(currentPath, part)
 
-		if isSymLink := isSymbolicLink(currentPath); isSymLink {
+		if isSymLink := isSymbolicLink(fsys, currentPath); isSymLink {
 			// return symbolic link, and correspondent part
-			target, err := filepath.EvalSymlinks(currentPath)
+			target, err := fsys.EvalSymlinks(currentPath)
 			if err != nil {
 				return "", "", err
 			}
@@ -81,8 +94,8 @@ func getSymbolinkLink(path string) (string, string, error) {
 }
 
 // isSymbolicLink validates if the path is a symbolic link
-func isSymbolicLink(path string) bool {
-	info, err := os.Lstat(path)
+func isSymbolicLink(fsys vfs.SymlinkFS, path string) bool {
+	info, err := fsys.Lstat(path)
 	if err != nil {
 		return false
 	}
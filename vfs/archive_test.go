@@ -0,0 +1,100 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package vfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+var archiveFiles = map[string]string{
+	"compose.yaml":  "services: {}\n",
+	"conf/app.conf": "x=1\n",
+}
+
+func TestReadArchive(t *testing.T) {
+	for name, content := range map[string][]byte{
+		"tar":    makeTar(t, false),
+		"tar.gz": makeTar(t, true),
+		"zip":    makeZip(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			fsys, err := ReadArchive(bytes.NewReader(content), filepath.FromSlash("/bundle"))
+			assert.NilError(t, err)
+
+			b, err := fsys.ReadFile(filepath.FromSlash("/bundle/conf/app.conf"))
+			assert.NilError(t, err)
+			assert.Equal(t, string(b), "x=1\n")
+
+			info, err := fsys.Stat(filepath.FromSlash("/bundle/conf"))
+			assert.NilError(t, err)
+			assert.Check(t, info.IsDir())
+
+			entries, err := fs.ReadDir(fsys, filepath.FromSlash("/bundle"))
+			assert.NilError(t, err)
+			assert.Equal(t, len(entries), 2)
+			assert.Equal(t, entries[0].Name(), "compose.yaml")
+			assert.Equal(t, entries[1].Name(), "conf")
+			assert.Check(t, entries[1].IsDir())
+
+			_, err = fsys.Stat(filepath.FromSlash("/bundle/missing.yaml"))
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+			_, err = fsys.Stat(filepath.FromSlash("/elsewhere/compose.yaml"))
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+		})
+	}
+}
+
+func makeTar(t *testing.T, compress bool) []byte {
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	}
+	for name, content := range archiveFiles {
+		assert.NilError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tw.Close())
+	if gz != nil {
+		assert.NilError(t, gz.Close())
+	}
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range archiveFiles {
+		w, err := zw.Create(name)
+		assert.NilError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, zw.Close())
+	return buf.Bytes()
+}
@@ -0,0 +1,147 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FS gives read access to files. Unlike io/fs, names are OS paths, either absolute or relative
+// to the current directory, as compose model references files relative to the project directory.
+// Directories are listed by io/fs.ReadDir, so implementations should also implement fs.ReadDirFS.
+type FS interface {
+	Open(name string) (fs.File, error)
+	Stat(name string) (fs.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+}
+
+// SymlinkFS is implemented by filesystems supporting symbolic links
+type SymlinkFS interface {
+	FS
+	Lstat(name string) (fs.FileInfo, error)
+	EvalSymlinks(name string) (string, error)
+}
+
+// OS is the host filesystem
+var OS SymlinkFS = osFS{}
+
+// Or returns fsys, or the host filesystem if nil
+func Or(fsys FS) FS {
+	if fsys == nil {
+		return OS
+	}
+	return fsys
+}
+
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFS) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (osFS) EvalSymlinks(name string) (string, error) {
+	return filepath.EvalSymlinks(name)
+}
+
+// FromFS exposes an io/fs filesystem, like an in-memory fstest.MapFS or a zip.Reader, as mounted on root.
+// Paths outside root don't exist.
+func FromFS(fsys fs.FS, root string) FS {
+	return mountedFS{fsys: fsys, root: filepath.Clean(root)}
+}
+
+type mountedFS struct {
+	fsys fs.FS
+	root string
+}
+
+// rel converts an OS path into a io/fs path relative to root
+func (m mountedFS) rel(op, name string) (string, error) {
+	abs := name
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(m.root, abs)
+	}
+	rel, err := filepath.Rel(m.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func (m mountedFS) Open(name string) (fs.File, error) {
+	rel, err := m.rel("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := m.fsys.Open(rel)
+	return f, pathError(err, name)
+}
+
+func (m mountedFS) Stat(name string) (fs.FileInfo, error) {
+	rel, err := m.rel("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := fs.Stat(m.fsys, rel)
+	return fi, pathError(err, name)
+}
+
+func (m mountedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	rel, err := m.rel("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(m.fsys, rel)
+	return entries, pathError(err, name)
+}
+
+func (m mountedFS) ReadFile(name string) ([]byte, error) {
+	rel, err := m.rel("open", name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := fs.ReadFile(m.fsys, rel)
+	return b, pathError(err, name)
+}
+
+// pathError reports errors using the OS path requested by caller
+func pathError(err error, name string) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return &fs.PathError{Op: pathErr.Op, Path: name, Err: pathErr.Err}
+	}
+	return err
+}
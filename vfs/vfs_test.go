@@ -0,0 +1,64 @@
+/*
+   Copyright 2020 The Compose Specification Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package vfs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestFromFS(t *testing.T) {
+	root := filepath.FromSlash("/project")
+	fsys := FromFS(fstest.MapFS{
+		"compose.yaml":     {Data: []byte("services: {}")},
+		"config/app.conf":  {Data: []byte("debug")},
+		"config/empty.txt": {},
+	}, root)
+
+	b, err := fsys.ReadFile(filepath.Join(root, "compose.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "services: {}")
+
+	fi, err := fsys.Stat(filepath.Join(root, "config"))
+	assert.NilError(t, err)
+	assert.Check(t, fi.IsDir())
+
+	f, err := fsys.Open(filepath.Join(root, "config", "app.conf"))
+	assert.NilError(t, err)
+	b, err = io.ReadAll(f)
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), "debug")
+	assert.NilError(t, f.Close())
+
+	_, err = fsys.Stat(filepath.Join(root, "missing"))
+	assert.Check(t, os.IsNotExist(err))
+	assert.ErrorContains(t, err, filepath.Join(root, "missing"))
+
+	_, err = fsys.ReadFile(filepath.Join(root, "..", "etc", "passwd"))
+	assert.Check(t, os.IsNotExist(err))
+}
+
+func TestOr(t *testing.T) {
+	assert.Equal(t, Or(nil), FS(OS))
+	_, isMounted := Or(FromFS(fstest.MapFS{}, "/")).(mountedFS)
+	assert.Check(t, isMounted)
+}